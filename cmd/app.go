@@ -2,16 +2,12 @@ package cmd
 
 import (
 	"errors"
-	"fmt"
 	"io"
 	"os"
-	"path/filepath"
 	"runtime/debug"
-	"strings"
 	"time"
 
 	"github.com/crumbyte/noxdir/config"
-	"github.com/crumbyte/noxdir/drive"
 	"github.com/crumbyte/noxdir/filter"
 	"github.com/crumbyte/noxdir/pkg/cache"
 	"github.com/crumbyte/noxdir/render"
@@ -21,6 +17,10 @@ import (
 	"github.com/spf13/cobra"
 )
 
+// savingIndicatorDelay defines the delay before showing the cache saving
+// message on exit. The message is not shown if the cache is saved faster.
+const savingIndicatorDelay = time.Millisecond * 200
+
 var (
 	ErrUnknown = errors.New("unknown error")
 
@@ -53,14 +53,18 @@ selected drive and presents the space consumption in a clear, user-friendly layo
 				return
 			}
 
-			done, err := tree.PersistCache()
-			if err != nil {
-				printError(err.Error())
-			}
+			result, pending := tree.PersistCache(), tree.WaitCache()
 
 			select {
-			case <-done:
-			case <-time.After(time.Second * 5):
+			case <-pending:
+			case <-time.After(savingIndicatorDelay):
+				printMsg("saving cache...")
+
+				<-pending
+			}
+
+			if err := <-result; err != nil {
+				printError(err.Error())
 			}
 		},
 	}
@@ -176,9 +180,10 @@ color settings for the UI elements.
 file system scan will be performed only once. After that, the cache will be
 used as long as the flag is provided.
 
-The cache will always store the last session data. In order to update the
-cache and the application's state, use the "r" (refresh) command on a 
-target directory.
+The cache keeps a history of timestamped snapshots. The latest snapshot is
+used on startup, and older snapshots can be compared with the current state
+using the diff view. In order to update the cache and the application's
+state, use the "r" (refresh) command on a target directory.
 
 Default value is "false".
 
@@ -221,9 +226,15 @@ Example: -v|--version (provide a flag)
 }
 
 func Execute() {
-	if err := appCmd.Execute(); err != nil {
+	if c, err := appCmd.ExecuteC(); err != nil {
 		var cliErr *CLIError
 
+		// the subcommands' errors are already printed along with their usage,
+		// and they are not caused by the application failures.
+		if c != appCmd {
+			os.Exit(1)
+		}
+
 		if errors.As(err, &cliErr) {
 			printError(cliErr.Error())
 		} else {
@@ -348,57 +359,25 @@ func initViewModel(s *config.Settings) (*render.ViewModel, error) {
 }
 
 func resolveNavigation(s *config.Settings) (*render.Navigation, error) {
-	var (
-		opts          []structure.TreeOpt
-		fif           []drive.FileInfoFilter
-		cacheInstance *cache.Cache
-	)
-
-	if len(s.Exclude) > 0 {
-		opts = append(opts, structure.WithExclude(s.Exclude))
-	}
-
-	sizeLimitFilter, err := parseSizeLimit()
+	opts, err := treeOptions(s)
 	if err != nil {
-		return nil, NewCLIError(
-			fmt.Errorf("invalid value for size-limit flag: %s", err.Error()),
-		)
-	}
-
-	if sizeLimitFilter != nil {
-		fif = append(fif, sizeLimitFilter)
-	}
-
-	if s.NoHidden {
-		fif = append(fif, drive.HiddenFilter)
+		return nil, err
 	}
 
-	cacheInstance, err = cache.NewCache(
-		func(w io.Writer) cache.Encoder { return structure.NewEncoder(w) },
-		func(r io.Reader) cache.Decoder { return structure.NewDecoder(r) },
-		clearCache,
-		s.Path,
-		cache.WithCompress(),
-	)
+	cacheInstance, err := newCache(s)
 	if err != nil {
 		return nil, err
 	}
 
-	opts = append(
-		opts,
-		structure.WithFileInfoFilter(fif),
-		structure.WithCache(cacheInstance),
-	)
+	opts = append(opts, structure.WithCache(cacheInstance))
 
 	if s.UseCache {
 		opts = append(opts, structure.WithUseCache())
 	}
 
 	if root != "" {
-		root = strings.TrimSuffix(root, string(os.PathSeparator))
-
-		if root, err = filepath.Abs(root); err != nil {
-			return nil, fmt.Errorf("resolve absolute root path: %s", err.Error())
+		if root, err = resolveRoot(root); err != nil {
+			return nil, err
 		}
 
 		tree = structure.NewTree(
@@ -414,8 +393,41 @@ func resolveNavigation(s *config.Settings) (*render.Navigation, error) {
 	return render.NewNavigation(tree, *settings), nil
 }
 
+// newCache creates a new *cache.Cache instance for storing the *structure.Tree
+// state using the application's settings.
+func newCache(s *config.Settings) (*cache.Cache, error) {
+	return cache.NewCache(
+		func(w io.Writer) cache.Encoder { return structure.NewEncoder(w) },
+		func(r io.Reader) cache.Decoder { return structure.NewDecoder(r) },
+		clearCache,
+		s.Path,
+		cacheRetention(s.CacheRetention),
+	)
+}
+
+// cacheRetention converts the retention settings into the corresponding cache
+// option. The zero snapshots limit falls back to the default value.
+func cacheRetention(cr config.CacheRetention) cache.Option {
+	maxSnapshots := cr.MaxSnapshots
+
+	switch {
+	case maxSnapshots == 0:
+		maxSnapshots = cache.DefaultMaxSnapshots
+	case maxSnapshots < 0:
+		maxSnapshots = 0
+	}
+
+	return cache.WithRetention(
+		maxSnapshots, time.Duration(max(cr.MaxAgeDays, 0))*time.Hour*24,
+	)
+}
+
 func printError(errMsg string) {
-	if _, err := os.Stdout.WriteString(errMsg + "\n"); err != nil {
+	printMsg(errMsg)
+}
+
+func printMsg(msg string) {
+	if _, err := os.Stdout.WriteString(msg + "\n"); err != nil {
 		return
 	}
 }
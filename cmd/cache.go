@@ -0,0 +1,401 @@
+package cmd
+
+import (
+	"cmp"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"slices"
+	"strconv"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	"github.com/crumbyte/noxdir/drive"
+	"github.com/crumbyte/noxdir/pkg/cache"
+	"github.com/crumbyte/noxdir/render"
+	"github.com/crumbyte/noxdir/structure"
+
+	"github.com/spf13/cobra"
+)
+
+const cacheTimeFormat = "02 Jan 2006 15:04:05"
+
+var (
+	olderThan string
+
+	cacheCmd = &cobra.Command{
+		Use:   "cache",
+		Short: "Manage the cached scan results.",
+		Long: `Manage the cached scan results stored in the application's directory. Each
+cached root directory or drive can have multiple snapshots created by different
+sessions.`,
+	}
+
+	cacheListCmd = &cobra.Command{
+		Use:   "list",
+		Short: "List all cached root directories and drives.",
+		Args:  cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, _ []string) error {
+			return runCache(cmd, cacheList)
+		},
+	}
+
+	cacheInfoCmd = &cobra.Command{
+		Use:   "info <path>",
+		Short: "Show the cache snapshots of the root directory or drive.",
+		Args:  cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return runCache(cmd, func(w io.Writer, c *cache.Cache) error {
+				return cacheInfo(w, c, args[0])
+			})
+		},
+	}
+
+	cacheRmCmd = &cobra.Command{
+		Use:   "rm <path>",
+		Short: "Delete all cache snapshots of the root directory or drive.",
+		Args:  cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return runCache(cmd, func(w io.Writer, c *cache.Cache) error {
+				return cacheRm(w, c, args[0])
+			})
+		},
+	}
+
+	cachePruneCmd = &cobra.Command{
+		Use:   "prune",
+		Short: "Delete the cache snapshots older than the provided age.",
+		Args:  cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, _ []string) error {
+			age, err := drive.ParseAge(olderThan)
+			if err != nil {
+				return err
+			}
+
+			return runCache(cmd, func(w io.Writer, c *cache.Cache) error {
+				return cachePrune(w, c, age)
+			})
+		},
+	}
+)
+
+func init() {
+	cachePruneCmd.Flags().StringVarP(
+		&olderThan,
+		"older-than",
+		"",
+		"",
+		`Delete the snapshots created earlier than the provided age ago. The age
+can be defined in days or using the regular duration units, e.g., "h", "m".
+
+Example: --older-than=30d`,
+	)
+
+	_ = cachePruneCmd.MarkFlagRequired("older-than")
+
+	cacheCmd.AddCommand(cacheListCmd, cacheInfoCmd, cacheRmCmd, cachePruneCmd)
+	appCmd.AddCommand(cacheCmd)
+}
+
+// cachedRoot contains the snapshots of a single cache key along with the
+// header of the most recent snapshot.
+type cachedRoot struct {
+	header    structure.Header
+	headerErr error
+	snapshots []cache.Snapshot
+}
+
+func (cr *cachedRoot) size() int64 {
+	var size int64
+
+	for _, s := range cr.snapshots {
+		size += s.Size
+	}
+
+	return size
+}
+
+func (cr *cachedRoot) root() string {
+	if cr.headerErr != nil {
+		return cr.snapshots[0].KeyHash + " (unreadable)"
+	}
+
+	return cr.header.Root
+}
+
+func runCache(cmd *cobra.Command, run func(io.Writer, *cache.Cache) error) error {
+	// the arguments are valid at this point, so the usage is not relevant to
+	// any further errors.
+	cmd.SilenceUsage = true
+
+	s, err := initConfig()
+	if err != nil {
+		return err
+	}
+
+	c, err := newCache(s)
+	if err != nil {
+		return err
+	}
+
+	return run(cmd.OutOrStdout(), c)
+}
+
+func cacheList(w io.Writer, c *cache.Cache) error {
+	roots, err := readCachedRoots(c)
+	if err != nil {
+		return err
+	}
+
+	if len(roots) == 0 {
+		_, err = fmt.Fprintln(w, "no cached entries found")
+
+		return err
+	}
+
+	tw := tabwriter.NewWriter(w, 0, 0, 3, ' ', 0)
+
+	_, err = fmt.Fprintln(tw, "ROOT\tOPTIONS\tSCANNED\tENTRIES\tSNAPSHOTS\tSIZE")
+	if err != nil {
+		return err
+	}
+
+	for _, cr := range roots {
+		options, scanned, entries := "-", "-", "-"
+
+		if cr.headerErr == nil {
+			options = cr.header.Options
+			scanned = cr.header.Created.Format(cacheTimeFormat)
+			entries = strconv.FormatUint(cr.header.Entries, 10)
+		}
+
+		_, err = fmt.Fprintf(
+			tw,
+			"%s\t%s\t%s\t%s\t%d\t%s\n",
+			cr.root(),
+			options,
+			scanned,
+			entries,
+			len(cr.snapshots),
+			render.FmtSize(cr.size(), 0),
+		)
+		if err != nil {
+			return err
+		}
+	}
+
+	return tw.Flush()
+}
+
+func cacheInfo(w io.Writer, c *cache.Cache, path string) error {
+	roots, err := findCachedRoots(c, path)
+	if err != nil {
+		return err
+	}
+
+	for i, cr := range roots {
+		if i > 0 {
+			if _, err = fmt.Fprintln(w); err != nil {
+				return err
+			}
+		}
+
+		if err = cachedRootInfo(w, c, cr); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func cachedRootInfo(w io.Writer, c *cache.Cache, cr *cachedRoot) error {
+	_, err := fmt.Fprintf(
+		w,
+		"Root:      %s\nOptions:   %s\nSnapshots: %d\nSize:      %s\n\n",
+		cr.header.Root,
+		cr.header.Options,
+		len(cr.snapshots),
+		render.FmtSize(cr.size(), 0),
+	)
+	if err != nil {
+		return err
+	}
+
+	tw := tabwriter.NewWriter(w, 0, 0, 3, ' ', 0)
+
+	if _, err = fmt.Fprintln(tw, "ID\tSCANNED\tENTRIES\tVERSION\tSIZE"); err != nil {
+		return err
+	}
+
+	for _, s := range cr.snapshots {
+		scanned, entries, version := s.Created.Format(cacheTimeFormat), "-", "-"
+
+		if h, headerErr := readHeader(c, s); headerErr == nil {
+			scanned = h.Created.Format(cacheTimeFormat)
+			entries = strconv.FormatUint(h.Entries, 10)
+			version = strconv.FormatUint(uint64(h.Version), 10)
+		}
+
+		_, err = fmt.Fprintf(
+			tw,
+			"%s\t%s\t%s\t%s\t%s\n",
+			s.ID,
+			scanned,
+			entries,
+			version,
+			render.FmtSize(s.Size, 0),
+		)
+		if err != nil {
+			return err
+		}
+	}
+
+	return tw.Flush()
+}
+
+func cacheRm(w io.Writer, c *cache.Cache, path string) error {
+	roots, err := findCachedRoots(c, path)
+	if err != nil {
+		return err
+	}
+
+	for _, cr := range roots {
+		if err = c.Remove(cr.snapshots...); err != nil {
+			return err
+		}
+
+		_, err = fmt.Fprintf(
+			w,
+			"deleted %d snapshot(s) of %s (%s), freed %s\n",
+			len(cr.snapshots),
+			cr.header.Root,
+			cr.header.Options,
+			render.FmtSize(cr.size(), 0),
+		)
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func cachePrune(w io.Writer, c *cache.Cache, age time.Duration) error {
+	snapshots, err := c.List()
+	if err != nil {
+		return err
+	}
+
+	var (
+		expired []cache.Snapshot
+		size    int64
+	)
+
+	for _, s := range snapshots {
+		if time.Since(s.Created) > age {
+			expired = append(expired, s)
+			size += s.Size
+		}
+	}
+
+	if err = c.Remove(expired...); err != nil {
+		return err
+	}
+
+	_, err = fmt.Fprintf(
+		w,
+		"deleted %d snapshot(s), freed %s\n",
+		len(expired),
+		render.FmtSize(size, 0),
+	)
+
+	return err
+}
+
+// readCachedRoots reads all cache snapshots and groups them by their keys. The
+// result is sorted by the root path.
+func readCachedRoots(c *cache.Cache) ([]*cachedRoot, error) {
+	snapshots, err := c.List()
+	if err != nil {
+		return nil, err
+	}
+
+	var (
+		roots  []*cachedRoot
+		byHash = make(map[string]*cachedRoot)
+	)
+
+	// the snapshots are sorted starting from the most recent one, so the first
+	// snapshot within the group is always the latest one.
+	for _, s := range snapshots {
+		cr, ok := byHash[s.KeyHash]
+		if !ok {
+			cr = &cachedRoot{}
+			cr.header, cr.headerErr = readHeader(c, s)
+
+			byHash[s.KeyHash] = cr
+			roots = append(roots, cr)
+		}
+
+		cr.snapshots = append(cr.snapshots, s)
+	}
+
+	slices.SortFunc(roots, func(a, b *cachedRoot) int {
+		return cmp.Or(
+			strings.Compare(a.root(), b.root()),
+			strings.Compare(a.header.Options, b.header.Options),
+		)
+	})
+
+	return roots, nil
+}
+
+// findCachedRoots finds the cached roots by their path. The same path can be
+// cached multiple times with different scan options. The path is resolved to
+// the absolute path, and the trailing separators are ignored, so both drive
+// roots and regular directories can be provided in any form.
+func findCachedRoots(c *cache.Cache, path string) ([]*cachedRoot, error) {
+	path, err := filepath.Abs(path)
+	if err != nil {
+		return nil, fmt.Errorf("resolve absolute path: %w", err)
+	}
+
+	roots, err := readCachedRoots(c)
+	if err != nil {
+		return nil, err
+	}
+
+	trimSep := func(p string) string {
+		return strings.TrimRight(p, string(os.PathSeparator))
+	}
+
+	roots = slices.DeleteFunc(roots, func(cr *cachedRoot) bool {
+		return cr.headerErr != nil || trimSep(cr.header.Root) != trimSep(path)
+	})
+
+	if len(roots) == 0 {
+		return nil, fmt.Errorf("%w: %s", cache.ErrNoCache, path)
+	}
+
+	return roots, nil
+}
+
+func readHeader(c *cache.Cache, s cache.Snapshot) (structure.Header, error) {
+	r, err := c.Open(s)
+	if err != nil {
+		return structure.Header{}, err
+	}
+
+	defer func() {
+		_ = r.Close()
+	}()
+
+	h, err := structure.NewDecoder(r).ReadHeader()
+	if err != nil {
+		return h, fmt.Errorf("snapshot %s: %w", s.ID, err)
+	}
+
+	return h, nil
+}
@@ -0,0 +1,224 @@
+package cmd
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"time"
+
+	"github.com/crumbyte/noxdir/config"
+	"github.com/crumbyte/noxdir/drive"
+	"github.com/crumbyte/noxdir/export"
+	"github.com/crumbyte/noxdir/pkg/atomicfile"
+	"github.com/crumbyte/noxdir/structure"
+
+	"github.com/spf13/cobra"
+)
+
+const (
+	defaultPromTopDirs = 10
+	defaultPromDepth   = 1
+)
+
+var (
+	promOutput  string
+	promTopDirs int
+	promDepth   int
+
+	prometheusCmd = &cobra.Command{
+		Use:   "prometheus [root...]",
+		Short: "Scan the root directories and export the results for Prometheus.",
+		Long: `Scan the root directories without starting the UI and write the results to
+a file in the Prometheus text format. The file is intended for the node_exporter
+textfile collector and is replaced atomically, so the collector never reads a
+partially written file.
+
+The roots, output file, and limits can also be defined in the "prometheus"
+section of the configuration file. The arguments and flags take precedence over
+the configuration file.
+
+Example:
+	noxdir prometheus /home /var --output=/var/lib/node_exporter/noxdir.prom`,
+		RunE: runPrometheus,
+	}
+)
+
+func init() {
+	prometheusCmd.Flags().StringVarP(
+		&promOutput,
+		"output",
+		"o",
+		"",
+		`Path of the resulting ".prom" file.
+
+Example: --output=/var/lib/node_exporter/noxdir.prom`,
+	)
+
+	prometheusCmd.Flags().IntVarP(
+		&promTopDirs,
+		"top",
+		"",
+		0,
+		`Number of the biggest directories exported for each root in addition to
+the depth-limited directories. Default value is 10.
+
+Example: --top=20`,
+	)
+
+	prometheusCmd.Flags().IntVarP(
+		&promDepth,
+		"depth",
+		"",
+		0,
+		`Number of directory levels below each root that are always exported.
+Default value is 1.
+
+Example: --depth=2`,
+	)
+
+	appCmd.AddCommand(prometheusCmd)
+}
+
+func runPrometheus(cmd *cobra.Command, args []string) error {
+	cmd.SilenceUsage = true
+
+	s, err := initConfig()
+	if err != nil {
+		return err
+	}
+
+	cfg := prometheusSettings(s.Prometheus, args)
+
+	if len(cfg.Roots) == 0 {
+		return errors.New("no root directories provided")
+	}
+
+	if len(cfg.Output) == 0 {
+		return errors.New("no output file provided")
+	}
+
+	reports := make([]export.ScanReport, 0, len(cfg.Roots))
+
+	for _, r := range cfg.Roots {
+		report, err := scanReport(s, r)
+		if err != nil {
+			return err
+		}
+
+		reports = append(reports, report)
+	}
+
+	dl, err := drive.NewList()
+	if err != nil {
+		return fmt.Errorf("list drives: %w", err)
+	}
+
+	return writePrometheus(
+		cfg.Output,
+		reports,
+		dl.Sort("", false),
+		export.PrometheusOptions{TopDirs: cfg.TopDirs, Depth: cfg.Depth},
+	)
+}
+
+// prometheusSettings merges the exporter settings from the configuration file
+// with the arguments and flags, and applies the default limits.
+func prometheusSettings(cfg config.PrometheusExporter, args []string) config.PrometheusExporter {
+	if len(args) > 0 {
+		cfg.Roots = args
+	}
+
+	if len(promOutput) > 0 {
+		cfg.Output = promOutput
+	}
+
+	if promTopDirs > 0 {
+		cfg.TopDirs = promTopDirs
+	}
+
+	if promDepth > 0 {
+		cfg.Depth = promDepth
+	}
+
+	if cfg.TopDirs == 0 {
+		cfg.TopDirs = defaultPromTopDirs
+	}
+
+	if cfg.Depth == 0 {
+		cfg.Depth = defaultPromDepth
+	}
+
+	return cfg
+}
+
+// scanReport performs a full blocking scan of the root directory. The cache is
+// neither used nor updated, so the report always reflects the current state.
+func scanReport(s *config.Settings, path string) (export.ScanReport, error) {
+	path, err := resolveRoot(path)
+	if err != nil {
+		return export.ScanReport{}, err
+	}
+
+	fi, err := os.Stat(path)
+	if err != nil {
+		return export.ScanReport{}, fmt.Errorf("invalid root: %w", err)
+	}
+
+	if !fi.IsDir() {
+		return export.ScanReport{}, fmt.Errorf("invalid root: %s is not a directory", path)
+	}
+
+	opts, err := treeOptions(s)
+	if err != nil {
+		return export.ScanReport{}, err
+	}
+
+	t := structure.NewTree(
+		structure.NewDirEntry(path, fi.ModTime().Unix()),
+		append(opts, structure.WithPartialRoot())...,
+	)
+
+	start := time.Now()
+	scanErr := t.Traverse(true)
+
+	t.CalculateSize()
+
+	return export.ScanReport{
+		Root:     t.Root(),
+		Duration: time.Since(start),
+		Errors:   countErrors(scanErr),
+	}, nil
+}
+
+// countErrors returns the number of errors joined into the provided error.
+func countErrors(err error) int {
+	if err == nil {
+		return 0
+	}
+
+	if joined, ok := err.(interface{ Unwrap() []error }); ok {
+		return len(joined.Unwrap())
+	}
+
+	return 1
+}
+
+func writePrometheus(
+	path string,
+	reports []export.ScanReport,
+	drives []*drive.Info,
+	opts export.PrometheusOptions,
+) error {
+	f, err := atomicfile.Create(path, 0644)
+	if err != nil {
+		return err
+	}
+
+	defer f.Abort()
+
+	if err = export.WritePrometheus(f, reports, drives, opts); err != nil {
+		return fmt.Errorf("write metrics: %w", err)
+	}
+
+	return f.Commit()
+}
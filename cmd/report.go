@@ -0,0 +1,229 @@
+package cmd
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"path/filepath"
+	"strings"
+
+	"github.com/crumbyte/noxdir/drive"
+	"github.com/crumbyte/noxdir/export"
+	"github.com/crumbyte/noxdir/pkg/atomicfile"
+	"github.com/crumbyte/noxdir/structure"
+
+	"github.com/spf13/cobra"
+)
+
+const (
+	defaultReportDepth  = 3
+	defaultReportTop    = 20
+	defaultReportWidth  = 1600
+	defaultReportHeight = 1000
+)
+
+var (
+	reportHTML   string
+	reportImage  string
+	reportDepth  int
+	reportTop    int
+	reportWidth  int
+	reportHeight int
+
+	reportCmd = &cobra.Command{
+		Use:   "report [root]",
+		Short: "Scan the root directory and export the results as a report.",
+		Long: `Scan the root directory without starting the UI and export the results as a
+report. The root can be provided either as an argument or using the "--root"
+flag.
+
+The HTML report is a single self-contained file without any external assets,
+so it can be attached to a ticket or sent by email. It contains an interactive
+treemap, a sortable directory table, the biggest files and directories, and the
+drives usage summary.
+
+The image report is a treemap of the root directory colored by the file type
+category. The format is chosen by the file extension: the SVG image contains the
+entry labels, while the PNG image contains only the colored tiles.
+
+Example:
+	noxdir report ~/projects --html=report.html --depth=4
+	noxdir report ~/projects --image=treemap.svg --width=1920 --height=1080`,
+		Args: cobra.MaximumNArgs(1),
+		RunE: runReport,
+	}
+)
+
+func init() {
+	reportCmd.Flags().StringVarP(
+		&reportHTML,
+		"html",
+		"",
+		"",
+		`Path of the resulting HTML report.
+
+Example: --html=report.html`,
+	)
+
+	reportCmd.Flags().StringVarP(
+		&reportImage,
+		"image",
+		"",
+		"",
+		`Path of the resulting treemap image. The supported formats are SVG and PNG.
+
+Example: --image=treemap.svg`,
+	)
+
+	reportCmd.Flags().IntVarP(
+		&reportWidth,
+		"width",
+		"",
+		defaultReportWidth,
+		`Width of the treemap image in pixels.
+
+Example: --width=1920`,
+	)
+
+	reportCmd.Flags().IntVarP(
+		&reportHeight,
+		"height",
+		"",
+		defaultReportHeight,
+		`Height of the treemap image in pixels.
+
+Example: --height=1080`,
+	)
+
+	reportCmd.Flags().IntVarP(
+		&reportDepth,
+		"depth",
+		"",
+		defaultReportDepth,
+		`Number of directory levels below the root included in the report. The
+deeper directories are shown without their child entries. For the treemap
+image, it limits the number of nested directory frames.
+
+Example: --depth=4`,
+	)
+
+	reportCmd.Flags().IntVarP(
+		&reportTop,
+		"top",
+		"",
+		defaultReportTop,
+		`Number of the biggest files and directories included in the report.
+
+Example: --top=50`,
+	)
+
+	appCmd.AddCommand(reportCmd)
+}
+
+func runReport(cmd *cobra.Command, args []string) error {
+	cmd.SilenceUsage = true
+
+	reportRoot := root
+	if len(args) > 0 {
+		reportRoot = args[0]
+	}
+
+	if len(reportRoot) == 0 {
+		return errors.New("no root directory provided")
+	}
+
+	if len(reportHTML) == 0 && len(reportImage) == 0 {
+		return errors.New("no report output provided")
+	}
+
+	var writeImage imageEncoder
+
+	if len(reportImage) > 0 {
+		var err error
+
+		// the image format is resolved before the scan to fail fast.
+		if writeImage, err = imageWriter(reportImage); err != nil {
+			return err
+		}
+	}
+
+	s, err := initConfig()
+	if err != nil {
+		return err
+	}
+
+	printMsg("scanning " + reportRoot + "...")
+
+	report, err := scanReport(s, reportRoot)
+	if err != nil {
+		return err
+	}
+
+	if writeImage != nil {
+		err = writeReportFile(reportImage, func(w io.Writer) error {
+			return writeImage(w, report.Root, export.ImageOptions{
+				Width:  reportWidth,
+				Height: reportHeight,
+				Depth:  reportDepth,
+			})
+		})
+		if err != nil {
+			return fmt.Errorf("write image: %w", err)
+		}
+	}
+
+	if len(reportHTML) == 0 {
+		return nil
+	}
+
+	dl, err := drive.NewList()
+	if err != nil {
+		return fmt.Errorf("list drives: %w", err)
+	}
+
+	err = writeReportFile(reportHTML, func(w io.Writer) error {
+		return export.WriteHTML(
+			w,
+			report,
+			dl.Sort("", false),
+			export.HTMLOptions{Depth: reportDepth, TopEntries: reportTop},
+		)
+	})
+	if err != nil {
+		return fmt.Errorf("write html report: %w", err)
+	}
+
+	return nil
+}
+
+// imageEncoder renders the treemap image of the root entry.
+type imageEncoder func(io.Writer, *structure.Entry, export.ImageOptions) error
+
+// imageWriter resolves the image encoder by the file extension.
+func imageWriter(path string) (imageEncoder, error) {
+	switch strings.ToLower(filepath.Ext(path)) {
+	case ".svg":
+		return export.WriteSVG, nil
+	case ".png":
+		return export.WritePNG, nil
+	default:
+		return nil, fmt.Errorf("unsupported image format: %s", path)
+	}
+}
+
+// writeReportFile atomically replaces the file with the content produced by the
+// provided write function.
+func writeReportFile(path string, write func(io.Writer) error) error {
+	f, err := atomicfile.Create(path, 0644)
+	if err != nil {
+		return err
+	}
+
+	defer f.Abort()
+
+	if err = write(f); err != nil {
+		return err
+	}
+
+	return f.Commit()
+}
@@ -0,0 +1,66 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/crumbyte/noxdir/config"
+	"github.com/crumbyte/noxdir/drive"
+	"github.com/crumbyte/noxdir/structure"
+)
+
+// treeOptions builds the *structure.Tree options shared by all scanning modes
+// using the application's settings and flags. The cache related options are not
+// included, since not all modes use the cache.
+func treeOptions(s *config.Settings) ([]structure.TreeOpt, error) {
+	var (
+		opts []structure.TreeOpt
+		fif  []drive.FileInfoFilter
+	)
+
+	if len(s.Exclude) > 0 {
+		opts = append(opts, structure.WithExclude(s.Exclude))
+	}
+
+	sizeLimitFilter, err := parseSizeLimit()
+	if err != nil {
+		return nil, NewCLIError(
+			fmt.Errorf("invalid value for size-limit flag: %s", err.Error()),
+		)
+	}
+
+	if sizeLimitFilter != nil {
+		fif = append(fif, sizeLimitFilter)
+	}
+
+	if s.NoHidden {
+		fif = append(fif, drive.HiddenFilter)
+	}
+
+	return append(
+		opts,
+		structure.WithFileInfoFilter(fif),
+		structure.WithScanOptions(
+			structure.ScanOptions{
+				Exclude:   s.Exclude,
+				SizeLimit: sizeLimit,
+				NoHidden:  s.NoHidden,
+			},
+		),
+	), nil
+}
+
+// resolveRoot converts the provided root directory path into an absolute path
+// without trailing slash characters.
+func resolveRoot(path string) (string, error) {
+	path = strings.TrimSuffix(path, string(os.PathSeparator))
+
+	absPath, err := filepath.Abs(path)
+	if err != nil {
+		return "", fmt.Errorf("resolve absolute root path: %s", err.Error())
+	}
+
+	return absPath, nil
+}
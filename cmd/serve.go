@@ -0,0 +1,169 @@
+package cmd
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"os"
+	"os/signal"
+	"syscall"
+	"time"
+
+	"github.com/crumbyte/noxdir/config"
+	"github.com/crumbyte/noxdir/drive"
+	"github.com/crumbyte/noxdir/server"
+	"github.com/crumbyte/noxdir/structure"
+
+	"github.com/spf13/cobra"
+)
+
+const shutdownTimeout = time.Second * 10
+
+var (
+	listenAddr string
+
+	serveCmd = &cobra.Command{
+		Use:   "serve [root]",
+		Short: "Scan the root directory and serve the results over HTTP.",
+		Long: `Scan the root directory and start a local HTTP server exposing the scan
+results as read-only JSON endpoints. The root can be provided either as an
+argument or using the "--root" flag.
+
+Endpoints:
+	GET  /api/drives                       list of the available drives
+	GET  /api/entries?path=<dir>           directory with its child entries
+	GET  /api/top/files?path=<dir>&limit=N biggest files within the directory
+	GET  /api/top/dirs?path=<dir>&limit=N  biggest directories within the directory
+	GET  /api/snapshots                    cache snapshots of the root
+	GET  /api/diff?path=<dir>&snapshot=<id>&limit=N
+	                                       delta against the cache snapshot
+	POST /api/rescan?path=<dir>            rescan the directory
+
+The "path" parameter defaults to the root directory, and the "snapshot"
+parameter defaults to the most recent snapshot. The current state is saved to
+the cache on shutdown.
+
+Example:
+	noxdir serve ~/projects --listen=127.0.0.1:8080`,
+		Args: cobra.MaximumNArgs(1),
+		RunE: runServe,
+	}
+)
+
+func init() {
+	serveCmd.Flags().StringVarP(
+		&listenAddr,
+		"listen",
+		"",
+		"127.0.0.1:8080",
+		`Address the HTTP server listens on. It's strongly recommended to use the
+loopback interface, since the API does not provide any authentication.
+
+Example: --listen=127.0.0.1:8080`,
+	)
+
+	appCmd.AddCommand(serveCmd)
+}
+
+func runServe(cmd *cobra.Command, args []string) error {
+	cmd.SilenceUsage = true
+
+	serveRoot := root
+	if len(args) > 0 {
+		serveRoot = args[0]
+	}
+
+	if len(serveRoot) == 0 {
+		return errors.New("no root directory provided")
+	}
+
+	s, err := initConfig()
+	if err != nil {
+		return err
+	}
+
+	t, err := scanServeRoot(s, serveRoot)
+	if err != nil {
+		return err
+	}
+
+	httpServer := &http.Server{
+		Addr:              listenAddr,
+		Handler:           server.New(t, drive.NewList),
+		ReadHeaderTimeout: time.Second * 10,
+	}
+
+	ctx, stop := signal.NotifyContext(
+		context.Background(), os.Interrupt, syscall.SIGTERM,
+	)
+	defer stop()
+
+	serveErr := make(chan error, 1)
+
+	go func() {
+		printMsg("listening on " + listenAddr)
+
+		serveErr <- httpServer.ListenAndServe()
+	}()
+
+	select {
+	case err = <-serveErr:
+		return fmt.Errorf("serve: %w", err)
+	case <-ctx.Done():
+	}
+
+	shutdownCtx, cancel := context.WithTimeout(
+		context.Background(), shutdownTimeout,
+	)
+	defer cancel()
+
+	if err = httpServer.Shutdown(shutdownCtx); err != nil {
+		return fmt.Errorf("shutdown: %w", err)
+	}
+
+	return <-t.PersistCache()
+}
+
+// scanServeRoot performs a blocking scan of the root directory. If caching is
+// enabled, the root will be restored from the cache instead.
+func scanServeRoot(s *config.Settings, path string) (*structure.Tree, error) {
+	path, err := resolveRoot(path)
+	if err != nil {
+		return nil, err
+	}
+
+	if fi, err := os.Stat(path); err != nil || !fi.IsDir() {
+		return nil, fmt.Errorf("invalid root: %s", path)
+	}
+
+	opts, err := treeOptions(s)
+	if err != nil {
+		return nil, err
+	}
+
+	cacheInstance, err := newCache(s)
+	if err != nil {
+		return nil, err
+	}
+
+	opts = append(
+		opts, structure.WithCache(cacheInstance), structure.WithPartialRoot(),
+	)
+
+	if s.UseCache {
+		opts = append(opts, structure.WithUseCache())
+	}
+
+	t := structure.NewTree(structure.NewDirEntry(path, time.Now().Unix()), opts...)
+
+	printMsg("scanning " + path + "...")
+
+	// the permission related errors are ignored in the same way as in the
+	// interactive mode.
+	_ = t.Traverse(false)
+
+	t.CalculateSize()
+
+	return t, nil
+}
@@ -53,12 +53,16 @@ type Model struct {
 	messages      []string
 	onStateChange func()
 	history       *History
+	subCommands   []CreateSubCommand
 	path          string
 	locked        uint32
 	enabled       bool
 }
 
-func NewModel(onStateChange func()) *Model {
+// NewModel creates a new command *Model instance. The built-in commands are
+// always available, and the provided CreateSubCommand functions extend the list
+// of supported commands.
+func NewModel(onStateChange func(), csc ...CreateSubCommand) *Model {
 	ti := textinput.New()
 	ti.Focus()
 	ti.Prompt = "$ "
@@ -73,6 +77,7 @@ func NewModel(onStateChange func()) *Model {
 		viewport:      vp,
 		onStateChange: onStateChange,
 		history:       NewHistory(50),
+		subCommands:   csc,
 		enabled:       false,
 	}
 
@@ -224,8 +229,10 @@ func (m *Model) executeCmd() {
 
 	rootCmd := NewRootCmd(
 		m.onStateChange,
-		archive.NewPackCmd,
-		checksum.NewFileHashCmd,
+		append(
+			[]CreateSubCommand{archive.NewPackCmd, checksum.NewFileHashCmd},
+			m.subCommands...,
+		)...,
 	)
 
 	err := Execute(rootCmd, args, outBuffer)
@@ -0,0 +1,118 @@
+package snapshot
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"strconv"
+	"text/tabwriter"
+
+	"github.com/crumbyte/noxdir/pkg/cache"
+
+	"github.com/spf13/cobra"
+)
+
+const timeFormat = "02 Jan 2006 15:04:05"
+
+// Store defines a contract for the cache snapshots storage of the currently
+// scanned drive or root directory.
+type Store interface {
+	Snapshots() ([]cache.Snapshot, error)
+	DeleteSnapshot(id string) error
+}
+
+// NewSnapshotCmd returns a function creating a new "snapshot" command bound to
+// the provided Store instance. The command allows listing and deleting the
+// cache snapshots of the currently scanned drive or root directory.
+func NewSnapshotCmd(store Store) func(onStateChange func()) *cobra.Command {
+	return func(_ func()) *cobra.Command {
+		var (
+			entries []string
+			ctxPath string
+
+			snapshotCmd = &cobra.Command{
+				Short: "list/delete cache snapshots",
+				Use:   "snapshot [list|rm <id>...]",
+			}
+
+			listCmd = &cobra.Command{
+				Short: "list cache snapshots",
+				Use:   "list",
+				RunE: func(cmd *cobra.Command, _ []string) error {
+					return listRun(cmd.OutOrStdout(), store)
+				},
+			}
+
+			rmCmd = &cobra.Command{
+				Short: "delete cache snapshots by their ids",
+				Use:   "rm <id>...",
+				Args:  cobra.MinimumNArgs(1),
+				RunE: func(cmd *cobra.Command, args []string) error {
+					return rmRun(cmd.OutOrStdout(), store, args)
+				},
+			}
+		)
+
+		snapshotCmd.PersistentFlags().StringSliceVarP(&entries, "entries", "", nil, "")
+		snapshotCmd.PersistentFlags().StringVarP(&ctxPath, "ctx-path", "", "", "")
+
+		snapshotCmd.PersistentFlags().Lookup("entries").Hidden = true
+		snapshotCmd.PersistentFlags().Lookup("ctx-path").Hidden = true
+
+		snapshotCmd.AddCommand(listCmd, rmCmd)
+
+		return snapshotCmd
+	}
+}
+
+func listRun(out io.Writer, store Store) error {
+	snapshots, err := store.Snapshots()
+	if err != nil {
+		return err
+	}
+
+	if len(snapshots) == 0 {
+		_, err = io.WriteString(out, "no snapshots found")
+
+		return err
+	}
+
+	tw := tabwriter.NewWriter(out, 0, 0, 3, ' ', 0)
+
+	if _, err = fmt.Fprintln(tw, "ID\tCREATED\tSIZE"); err != nil {
+		return err
+	}
+
+	for _, s := range snapshots {
+		_, err = fmt.Fprintf(
+			tw,
+			"%s\t%s\t%s\n",
+			s.ID,
+			s.Created.Format(timeFormat),
+			strconv.FormatInt(s.Size, 10)+" B",
+		)
+		if err != nil {
+			return err
+		}
+	}
+
+	return tw.Flush()
+}
+
+func rmRun(out io.Writer, store Store, ids []string) error {
+	var errList []error
+
+	for _, id := range ids {
+		if err := store.DeleteSnapshot(id); err != nil {
+			errList = append(errList, fmt.Errorf("snapshot %s: %w", id, err))
+
+			continue
+		}
+
+		if _, err := fmt.Fprintf(out, "snapshot %s deleted\n", id); err != nil {
+			return err
+		}
+	}
+
+	return errors.Join(errList...)
+}
@@ -1,17 +1,26 @@
 package config
 
 import (
+	"bytes"
 	"encoding/json"
 	"errors"
 	"fmt"
 	"os"
 	"path/filepath"
 	"runtime"
+	"slices"
+	"unicode"
+
+	"github.com/crumbyte/noxdir/pkg/atomicfile"
 )
 
 const (
 	DirName  = ".noxdir"
 	FileName = "settings.json"
+
+	// VisitsFileName defines the name of the file storing the history of the
+	// visited directories.
+	VisitsFileName = "visits.json"
 )
 
 type DriveBindings struct {
@@ -27,9 +36,22 @@ type DirBindings struct {
 	FilesOnly       []string `json:"filesOnly"`
 	DirsOnly        []string `json:"dirsOnly"`
 	NameFilter      []string `json:"nameFilter"`
+	SizeFilter      []string `json:"sizeFilter"`
+	AgeFilter       []string `json:"ageFilter"`
+	Query           []string `json:"query"`
 	ToggleSelectAll []string `json:"toggleSelectAll"`
 	Chart           []string `json:"chart"`
 	Diff            []string `json:"diff"`
+	Compare         []string `json:"compare"`
+	Trends          []string `json:"trends"`
+	TrendsWindow    []string `json:"trendsWindow"`
+	Treemap         []string `json:"treemap"`
+	TreeView        []string `json:"treeView"`
+	Expand          []string `json:"expand"`
+	Search          []string `json:"search"`
+	GoTo            []string `json:"goTo"`
+	Bookmark        []string `json:"bookmark"`
+	Breadcrumbs     []string `json:"breadcrumbs"`
 	ToggleSelection []string `json:"toggleSelection"`
 	ToDrives        []string `json:"toDrives"`
 }
@@ -42,17 +64,59 @@ type Bindings struct {
 	Refresh       []string      `json:"refresh"`
 	Help          []string      `json:"help"`
 	Config        []string      `json:"config"`
+	Bookmarks     []string      `json:"bookmarks"`
+	PathPrompt    []string      `json:"pathPrompt"`
+}
+
+// CacheRetention defines the retention policy for the cache snapshots stored
+// for each scanned drive or root directory.
+type CacheRetention struct {
+	// MaxSnapshots limits the number of snapshots stored per drive or root
+	// directory. A zero value means the default limit, and a negative value
+	// disables the limit.
+	MaxSnapshots int `json:"maxSnapshots"`
+
+	// MaxAgeDays discards the snapshots older than the specified number of
+	// days. A zero value disables the limit.
+	MaxAgeDays int `json:"maxAgeDays"`
+}
+
+// PrometheusExporter defines the settings of the headless mode that exports the
+// scan results for the node_exporter textfile collector.
+type PrometheusExporter struct {
+	// Roots contains the list of root directories to scan.
+	Roots []string `json:"roots"`
+
+	// Output defines the path of the resulting ".prom" file.
+	Output string `json:"output"`
+
+	// TopDirs defines the number of the biggest directories exported for each
+	// root in addition to the depth-limited ones.
+	TopDirs int `json:"topDirs"`
+
+	// Depth defines the number of directory levels below each root that are
+	// always exported.
+	Depth int `json:"depth"`
 }
 
 type Settings struct {
-	Path        string   `json:"-"`
-	ColorSchema string   `json:"colorSchema"`
-	Exclude     []string `json:"exclude"`
-	NoEmptyDirs bool     `json:"noEmptyDirs"`
-	NoHidden    bool     `json:"noHidden"`
-	SimpleColor bool     `json:"simpleColor"`
-	UseCache    bool     `json:"useCache"`
-	Bindings    Bindings `json:"bindings"`
+	Path           string             `json:"-"`
+	ColorSchema    string             `json:"colorSchema"`
+	Exclude        []string           `json:"exclude"`
+	NoEmptyDirs    bool               `json:"noEmptyDirs"`
+	NoHidden       bool               `json:"noHidden"`
+	SimpleColor    bool               `json:"simpleColor"`
+	UseCache       bool               `json:"useCache"`
+	CacheRetention CacheRetention     `json:"cacheRetention"`
+	Prometheus     PrometheusExporter `json:"prometheus"`
+	Bindings       Bindings           `json:"bindings"`
+
+	// Queries contains the named filter queries that can be referenced in the
+	// query filter as "@name".
+	Queries map[string]string `json:"queries"`
+
+	// Bookmarks contains the paths of the bookmarked directories.
+	Bookmarks []string `json:"bookmarks"`
 }
 
 func LoadSettings() (*Settings, error) {
@@ -85,6 +149,107 @@ func (s Settings) ConfigPath() string {
 	return filepath.Join(s.Path, FileName)
 }
 
+// VisitsPath returns the path of the visited directories history file. An empty
+// path is returned if the settings were not loaded from the config directory.
+func (s Settings) VisitsPath() string {
+	if len(s.Path) == 0 {
+		return ""
+	}
+
+	return filepath.Join(s.Path, VisitsFileName)
+}
+
+// SaveBookmarks replaces the bookmarks and writes them to the settings file.
+// Only the "bookmarks" value of the file is replaced, so the other fields,
+// including the ones unknown to the current version, keep their order and
+// formatting. If the file has no bookmarks yet, the field is appended to the
+// end of the settings object. If the settings were not loaded from the config
+// directory, only the in-memory value is changed.
+func (s *Settings) SaveBookmarks(bookmarks []string) error {
+	s.Bookmarks = bookmarks
+
+	if len(s.Path) == 0 {
+		return nil
+	}
+
+	data, err := os.ReadFile(s.ConfigPath())
+	if err != nil && !errors.Is(err, os.ErrNotExist) {
+		return fmt.Errorf("cannot read settings file: %w", err)
+	}
+
+	value, err := json.MarshalIndent(bookmarks, "  ", "  ")
+	if err != nil {
+		return err
+	}
+
+	if data, err = replaceField(data, "bookmarks", value); err != nil {
+		return fmt.Errorf("cannot parse settings file: %w", err)
+	}
+
+	if err = atomicfile.WriteFile(s.ConfigPath(), data, 0600); err != nil {
+		return fmt.Errorf("save settings: %w", err)
+	}
+
+	return nil
+}
+
+// replaceField replaces the value of the top-level field in the encoded JSON
+// object and keeps the rest of the data as is. If the field does not exist, it
+// is appended to the end of the object. An empty data is treated as an empty
+// object.
+func replaceField(data []byte, name string, value []byte) ([]byte, error) {
+	if len(bytes.TrimSpace(data)) == 0 {
+		return fmt.Appendf(nil, "{\n  %q: %s\n}\n", name, value), nil
+	}
+
+	dec := json.NewDecoder(bytes.NewReader(data))
+
+	if tok, err := dec.Token(); err != nil || tok != json.Delim('{') {
+		return nil, errors.Join(errors.New("settings must be a JSON object"), err)
+	}
+
+	fields, valueStart, valueEnd := 0, int64(-1), int64(-1)
+
+	for dec.More() {
+		tok, err := dec.Token()
+		if err != nil {
+			return nil, err
+		}
+
+		var raw json.RawMessage
+
+		if err = dec.Decode(&raw); err != nil {
+			return nil, err
+		}
+
+		fields++
+
+		// the decoder uses the last value of the duplicated fields.
+		if tok == name {
+			valueEnd = dec.InputOffset()
+			valueStart = valueEnd - int64(len(raw))
+		}
+	}
+
+	if _, err := dec.Token(); err != nil {
+		return nil, err
+	}
+
+	if valueStart != -1 {
+		return slices.Concat(data[:valueStart], value, data[valueEnd:]), nil
+	}
+
+	// the offset of the closing brace of the object.
+	end := dec.InputOffset() - 1
+	head := bytes.TrimRightFunc(data[:end], unicode.IsSpace)
+
+	if fields != 0 {
+		head = append(slices.Clip(head), ',')
+	}
+
+	return slices.Concat(head, fmt.Appendf(nil, "\n  %q: %s\n", name, value), data[end:]), nil
+}
+
 func ResolveConfigPath(configDir string) (string, error) {
 	configPath, err := os.UserHomeDir()
 	if err != nil {
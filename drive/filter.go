@@ -1,7 +1,12 @@
 package drive
 
 import (
+	"errors"
+	"fmt"
 	"math"
+	"strconv"
+	"strings"
+	"time"
 )
 
 // FileInfoFilter defines a custom function type for filtering *FileInfo
@@ -47,3 +52,77 @@ func (sf *SizeFilter) Filter(fi FileInfo) bool {
 func HiddenFilter(fi FileInfo) bool {
 	return len(fi.name) != 0 && fi.name[0] != '.'
 }
+
+// ParseSizeLimit parses the size limits defined in the "<size><unit>:<size><unit>"
+// format, where "unit" value can be: KB, MB, GB, TB, PB. Both values are
+// optional, and an empty value results in a 0 limit, i.e., no limit.
+func ParseSizeLimit(rawValue string) (int64, int64, error) {
+	limits := strings.Split(strings.TrimSpace(rawValue), ":")
+	if len(limits) != 2 {
+		return 0, 0, fmt.Errorf("check the usage example: %s", rawValue)
+	}
+
+	minLimit, err := parseSize(limits[0])
+	if err != nil {
+		return 0, 0, fmt.Errorf("cannot parse min limit: %w", err)
+	}
+
+	maxLimit, err := parseSize(limits[1])
+	if err != nil {
+		return 0, 0, fmt.Errorf("cannot parse max limit: %w", err)
+	}
+
+	if maxLimit != 0 && minLimit > maxLimit {
+		return 0, 0, errors.New("min value is bigger than max value")
+	}
+
+	return minLimit, maxLimit, nil
+}
+
+// parseSize parses a single size raw value. If the value is empty a 0 limit
+// will be returned.
+func parseSize(rawValue string) (int64, error) {
+	multiplier := map[string]int{"pb": 40, "tb": 30, "gb": 20, "mb": 10, "kb": 0}
+
+	if rawValue = strings.ToLower(strings.TrimSpace(rawValue)); len(rawValue) == 0 {
+		return 0, nil
+	}
+
+	if len(rawValue) < 3 {
+		return 0, fmt.Errorf("invalid size-limit value: %s", rawValue)
+	}
+
+	size, err := strconv.ParseInt(rawValue[:len(rawValue)-2], 10, 64)
+	if err != nil {
+		return 0, errors.New("unknown size unit")
+	}
+
+	offset, ok := multiplier[rawValue[len(rawValue)-2:]]
+	if !ok {
+		return 0, errors.New("unknown size unit")
+	}
+
+	return size * 1024 << offset, nil
+}
+
+// ParseAge parses the age value. Along with the regular duration units, the
+// value supports days, e.g., "30d".
+func ParseAge(rawValue string) (time.Duration, error) {
+	rawValue = strings.TrimSpace(rawValue)
+
+	if days, ok := strings.CutSuffix(rawValue, "d"); ok {
+		n, err := strconv.ParseUint(days, 10, 32)
+		if err != nil {
+			return 0, fmt.Errorf("invalid number of days: %s", rawValue)
+		}
+
+		return time.Duration(n) * time.Hour * 24, nil
+	}
+
+	age, err := time.ParseDuration(rawValue)
+	if err != nil || age < 0 {
+		return 0, fmt.Errorf("invalid age value: %s", rawValue)
+	}
+
+	return age, nil
+}
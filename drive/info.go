@@ -3,7 +3,9 @@ package drive
 import (
 	"cmp"
 	"maps"
+	"os"
 	"slices"
+	"strings"
 )
 
 type SortKey string
@@ -55,6 +57,27 @@ func (l *List) Find(diskPath string) *Info {
 	return nil
 }
 
+// Containing returns the drive/volume/mount the provided path belongs to. If the
+// mounts are nested, the deepest mount point is returned. The nil value is
+// returned if no drive contains the path.
+func (l *List) Containing(path string) *Info {
+	var found *Info
+
+	for disk := range maps.Values(l.pathInfoMap) {
+		mountPoint := strings.TrimSuffix(disk.Path, string(os.PathSeparator))
+
+		if path != disk.Path && !strings.HasPrefix(path, mountPoint+string(os.PathSeparator)) {
+			continue
+		}
+
+		if found == nil || len(disk.Path) > len(found.Path) {
+			found = disk
+		}
+	}
+
+	return found
+}
+
 func (l *List) Sort(sk SortKey, desc bool) []*Info {
 	drives := make([]*Info, 0, len(l.pathInfoMap))
 
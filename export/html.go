@@ -0,0 +1,168 @@
+package export
+
+import (
+	"cmp"
+	"container/heap"
+	_ "embed"
+	"errors"
+	"fmt"
+	"html/template"
+	"io"
+	"slices"
+	"time"
+
+	"github.com/crumbyte/noxdir/drive"
+	"github.com/crumbyte/noxdir/structure"
+)
+
+// maxHTMLChildren limits the number of child entries of a single directory in
+// the HTML report. The smallest entries that exceed the limit are merged into a
+// single entry to keep the report size reasonable.
+const maxHTMLChildren = 100
+
+//go:embed report.html
+var htmlReportTemplate string
+
+var htmlReport = template.Must(template.New("report").Parse(htmlReportTemplate))
+
+// HTMLOptions defines the content of the HTML report. Depth limits the number
+// of directory levels below the root included in the report, and TopEntries
+// limits the number of the biggest files and directories.
+type HTMLOptions struct {
+	Depth      int
+	TopEntries int
+}
+
+// htmlNode represents a single entry of the report tree. The short JSON keys
+// keep the size of the embedded tree reasonable.
+type htmlNode struct {
+	Name     string      `json:"n"`
+	Size     int64       `json:"s"`
+	Files    uint64      `json:"f"`
+	Dirs     uint64      `json:"d"`
+	ModTime  int64       `json:"m"`
+	IsDir    bool        `json:"dir,omitempty"`
+	Merged   bool        `json:"merged,omitempty"`
+	Children []*htmlNode `json:"c,omitempty"`
+}
+
+type htmlEntry struct {
+	Path    string `json:"path"`
+	Size    int64  `json:"size"`
+	ModTime int64  `json:"modTime"`
+}
+
+type htmlData struct {
+	Root      string        `json:"root"`
+	Generated int64         `json:"generated"`
+	Duration  float64       `json:"duration"`
+	Errors    int           `json:"errors"`
+	Tree      *htmlNode     `json:"tree"`
+	TopFiles  []htmlEntry   `json:"topFiles"`
+	TopDirs   []htmlEntry   `json:"topDirs"`
+	Drives    []*drive.Info `json:"drives"`
+}
+
+// WriteHTML writes the scan report as a single self-contained HTML page. The
+// page doesn't use any external assets and contains an interactive treemap,
+// the directory table, the biggest files and directories, and the drives usage.
+func WriteHTML(
+	w io.Writer,
+	report ScanReport,
+	drives []*drive.Info,
+	opts HTMLOptions,
+) error {
+	if report.Root == nil {
+		return errors.New("empty scan report")
+	}
+
+	data := htmlData{
+		Root:      report.Root.Path,
+		Generated: time.Now().Unix(),
+		Duration:  report.Duration.Seconds(),
+		Errors:    report.Errors,
+		Tree:      newHTMLNode(report.Root, opts.Depth),
+		Drives:    make([]*drive.Info, 0, len(drives)),
+	}
+
+	if opts.TopEntries > 0 {
+		te := structure.NewTopEntries(opts.TopEntries)
+		te.ScanFiles(report.Root)
+		te.ScanDirs(report.Root)
+
+		data.TopFiles = htmlTopEntries(te.Files())
+		data.TopDirs = htmlTopEntries(te.Dirs())
+	}
+
+	for _, d := range drives {
+		// the device entries duplicate the usage of their first mount point.
+		if d.IsDev == 0 {
+			data.Drives = append(data.Drives, d)
+		}
+	}
+
+	return htmlReport.Execute(w, data)
+}
+
+// newHTMLNode converts the entry to the report tree node. The child entries
+// are added up to the provided depth and sorted by size.
+func newHTMLNode(e *structure.Entry, depth int) *htmlNode {
+	node := &htmlNode{
+		Name:    e.Name(),
+		Size:    e.Size,
+		Files:   e.TotalFiles,
+		Dirs:    e.TotalDirs,
+		ModTime: e.ModTime,
+		IsDir:   e.IsDir,
+	}
+
+	if !e.IsDir || depth <= 0 {
+		return node
+	}
+
+	children := slices.Collect(e.Entries())
+
+	slices.SortFunc(children, func(a, b *structure.Entry) int {
+		return cmp.Compare(b.Size, a.Size)
+	})
+
+	node.Children = make([]*htmlNode, 0, min(len(children), maxHTMLChildren+1))
+
+	for _, child := range children[:min(len(children), maxHTMLChildren)] {
+		node.Children = append(node.Children, newHTMLNode(child, depth-1))
+	}
+
+	if len(children) > maxHTMLChildren {
+		merged := &htmlNode{
+			Name:   fmt.Sprintf("%d more entries", len(children)-maxHTMLChildren),
+			Merged: true,
+		}
+
+		for _, child := range children[maxHTMLChildren:] {
+			merged.Size += child.Size
+		}
+
+		node.Children = append(node.Children, merged)
+	}
+
+	return node
+}
+
+// htmlTopEntries drains the provided heap of the structure.TopEntries and
+// returns the entries sorted by size, starting from the biggest one.
+func htmlTopEntries(h heap.Interface) []htmlEntry {
+	entries := make([]htmlEntry, 0, h.Len())
+
+	for h.Len() > 0 {
+		if e, ok := heap.Pop(h).(*structure.Entry); ok {
+			entries = append(
+				entries,
+				htmlEntry{Path: e.Path, Size: e.Size, ModTime: e.ModTime},
+			)
+		}
+	}
+
+	slices.Reverse(entries)
+
+	return entries
+}
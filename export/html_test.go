@@ -0,0 +1,67 @@
+package export_test
+
+import (
+	"bytes"
+	"path/filepath"
+	"testing"
+
+	"github.com/crumbyte/noxdir/drive"
+	"github.com/crumbyte/noxdir/export"
+	"github.com/crumbyte/noxdir/structure"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestWriteHTML(t *testing.T) {
+	root := structure.NewDirEntry("root", 0)
+	level1 := structure.NewDirEntry(filepath.Join("root", "level1"), 0)
+	level2 := structure.NewDirEntry(filepath.Join("root", "level1", "level2"), 0)
+
+	root.AddChild(level1)
+	level1.AddChild(level2)
+	level1.AddChild(
+		structure.NewFileEntry(filepath.Join(level1.Path, "</script>.txt"), 100, 0),
+	)
+	level2.AddChild(
+		structure.NewFileEntry(filepath.Join(level2.Path, "deep.txt"), 50, 0),
+	)
+
+	tree := structure.NewTree(root)
+	tree.CalculateSize()
+
+	drives := []*drive.Info{
+		{Path: "/", Device: "/dev/sda1", FSName: "ext4", TotalBytes: 10},
+		{Path: "/", Device: "/dev/sda1", FSName: "ext4", TotalBytes: 10, IsDev: 1},
+	}
+
+	buf := bytes.NewBuffer(nil)
+
+	err := export.WriteHTML(
+		buf,
+		export.ScanReport{Root: root},
+		drives,
+		export.HTMLOptions{Depth: 2, TopEntries: 5},
+	)
+	require.NoError(t, err)
+
+	output := buf.String()
+
+	require.Contains(t, output, `"n":"level1","s":150`)
+	require.Contains(t, output, `"n":"level2","s":50`)
+
+	// the entries below the depth limit are available only in the top files.
+	require.NotContains(t, output, `"n":"deep.txt"`)
+	require.Contains(t, output, `deep.txt","size":50`)
+
+	// the entry names are escaped within the script.
+	require.NotContains(t, output, "</script>.txt")
+
+	require.Contains(t, output, `"device":"/dev/sda1"`)
+	require.NotContains(t, output, `"isDev":1`)
+
+	// the report is self-contained.
+	require.NotContains(t, output, "<link")
+	require.NotContains(t, output, "src=")
+
+	require.Error(t, export.WriteHTML(buf, export.ScanReport{}, nil, export.HTMLOptions{}))
+}
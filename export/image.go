@@ -0,0 +1,327 @@
+package export
+
+import (
+	"bufio"
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"image"
+	"image/color"
+	"image/draw"
+	"image/png"
+	"io"
+	"math"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/crumbyte/noxdir/pkg/bytesize"
+	"github.com/crumbyte/noxdir/pkg/filetype"
+	"github.com/crumbyte/noxdir/pkg/treemap"
+	"github.com/crumbyte/noxdir/structure"
+)
+
+const (
+	// imageFramePadding defines the gap between the directory frame and its
+	// child tiles.
+	imageFramePadding = 2
+
+	// imageHeaderHeight defines the height of the directory frame header with
+	// the directory name and size.
+	imageHeaderHeight = 16
+
+	// imageMinFrameSize defines the minimal width and height of the directory
+	// frame. The smaller directories are drawn as a single tile.
+	imageMinFrameSize = 40
+
+	// imageLegendHeight defines the height of the categories legend placed
+	// below the SVG treemap.
+	imageLegendHeight = 24
+
+	// imageCharWidth is an approximate width of a single label character used
+	// for fitting the labels into the tiles.
+	imageCharWidth = 7
+)
+
+// categoryColors contains the tile colors of the file type categories.
+var categoryColors = map[filetype.Category]color.RGBA{
+	filetype.Code:       {R: 0x3a, G: 0x86, B: 0xff, A: 0xff},
+	filetype.Image:      {R: 0xff, G: 0x00, B: 0x6e, A: 0xff},
+	filetype.Video:      {R: 0x83, G: 0x38, B: 0xec, A: 0xff},
+	filetype.Audio:      {R: 0x00, G: 0xf5, B: 0xd4, A: 0xff},
+	filetype.Archive:    {R: 0xfb, G: 0x56, B: 0x07, A: 0xff},
+	filetype.Document:   {R: 0xff, G: 0xbe, B: 0x0b, A: 0xff},
+	filetype.Config:     {R: 0xb5, G: 0x83, B: 0x8d, A: 0xff},
+	filetype.Executable: {R: 0xff, G: 0x85, B: 0xa1, A: 0xff},
+	filetype.Other:      {R: 0x90, G: 0x8c, B: 0xaa, A: 0xff},
+}
+
+var (
+	imageBackground = color.RGBA{R: 0x1e, G: 0x1e, B: 0x2e, A: 0xff}
+	imageFrame      = color.RGBA{R: 0x27, G: 0x27, B: 0x3a, A: 0xff}
+	imageText       = color.RGBA{R: 0xe0, G: 0xde, B: 0xf4, A: 0xff}
+)
+
+// ImageOptions defines the treemap image dimensions in pixels and the number
+// of directory levels below the root drawn as nested frames.
+type ImageOptions struct {
+	Width  int
+	Height int
+	Depth  int
+}
+
+// imageTile represents a single laid out treemap tile. A frame tile contains
+// the child tiles of the directory, while a regular tile is filled with the
+// color of the entry's category.
+type imageTile struct {
+	rect     treemap.Rect
+	entry    *structure.Entry
+	category filetype.Category
+	frame    bool
+}
+
+// EntryCategory resolves the category of the entry. The category of a
+// directory is the category that takes the most space within it.
+func EntryCategory(e *structure.Entry) filetype.Category {
+	if !e.IsDir {
+		return filetype.Of(e.Ext())
+	}
+
+	sizes := make(map[filetype.Category]int64)
+	collectCategorySizes(e, sizes)
+
+	dominant, dominantSize := filetype.Other, int64(0)
+
+	for _, c := range filetype.Categories {
+		if sizes[c] > dominantSize {
+			dominant, dominantSize = c, sizes[c]
+		}
+	}
+
+	return dominant
+}
+
+// WriteSVG renders the treemap of the root directory as an SVG image. Each tile
+// is labeled with the entry name and size if there is enough space, and the
+// categories legend is placed below the treemap.
+func WriteSVG(w io.Writer, root *structure.Entry, opts ImageOptions) error {
+	if err := opts.validate(root); err != nil {
+		return err
+	}
+
+	mapHeight := opts.Height - imageLegendHeight
+	if mapHeight <= 0 {
+		return errors.New("image height is too small")
+	}
+
+	tiles := layoutImage(
+		root,
+		treemap.Rect{W: float64(opts.Width), H: float64(mapHeight)},
+		opts.Depth,
+	)
+
+	bw := bufio.NewWriter(w)
+
+	_, _ = fmt.Fprintf(
+		bw,
+		`<svg xmlns="http://www.w3.org/2000/svg" width="%d" height="%d" viewBox="0 0 %d %d" font-family="sans-serif" font-size="11">`+"\n",
+		opts.Width, opts.Height, opts.Width, opts.Height,
+	)
+	_, _ = fmt.Fprintf(
+		bw, `<rect width="100%%" height="100%%" fill="%s"/>`+"\n", hexColor(imageBackground),
+	)
+
+	used := make(map[filetype.Category]bool)
+
+	for _, t := range tiles {
+		fill := imageFrame
+		if !t.frame {
+			fill = categoryColors[t.category]
+			used[t.category] = true
+		}
+
+		label := t.entry.Name() + " " + bytesize.Format(t.entry.Size)
+
+		_, _ = fmt.Fprintf(
+			bw,
+			`<rect x="%.2f" y="%.2f" width="%.2f" height="%.2f" fill="%s" stroke="%s"><title>%s</title></rect>`+"\n",
+			t.rect.X, t.rect.Y, t.rect.W, t.rect.H,
+			hexColor(fill), hexColor(imageBackground),
+			escapeXML(t.entry.Path+" "+bytesize.Format(t.entry.Size)),
+		)
+
+		textColor := imageText
+		if !t.frame {
+			textColor = imageBackground
+		}
+
+		if fitted := fitLabel(label, t.rect.W-6); len(fitted) > 0 && t.rect.H >= 14 {
+			_, _ = fmt.Fprintf(
+				bw,
+				`<text x="%.2f" y="%.2f" fill="%s">%s</text>`+"\n",
+				t.rect.X+3, t.rect.Y+12, hexColor(textColor), escapeXML(fitted),
+			)
+		}
+	}
+
+	x := 4
+
+	for _, c := range filetype.Categories {
+		if !used[c] {
+			continue
+		}
+
+		_, _ = fmt.Fprintf(
+			bw,
+			`<rect x="%d" y="%d" width="12" height="12" fill="%s"/><text x="%d" y="%d" fill="%s">%s</text>`+"\n",
+			x, mapHeight+6, hexColor(categoryColors[c]),
+			x+16, mapHeight+16, hexColor(imageText), c,
+		)
+
+		x += 16 + len(c)*imageCharWidth + 12
+	}
+
+	_, _ = bw.WriteString("</svg>\n")
+
+	return bw.Flush()
+}
+
+// WritePNG renders the treemap of the root directory as a PNG image. The
+// standard library doesn't provide any text rendering, so unlike the SVG image,
+// the tiles are not labeled.
+func WritePNG(w io.Writer, root *structure.Entry, opts ImageOptions) error {
+	if err := opts.validate(root); err != nil {
+		return err
+	}
+
+	img := image.NewRGBA(image.Rect(0, 0, opts.Width, opts.Height))
+	draw.Draw(img, img.Bounds(), image.NewUniform(imageBackground), image.Point{}, draw.Src)
+
+	tiles := layoutImage(
+		root,
+		treemap.Rect{W: float64(opts.Width), H: float64(opts.Height)},
+		opts.Depth,
+	)
+
+	for _, t := range tiles {
+		fill := imageFrame
+		if !t.frame {
+			fill = categoryColors[t.category]
+		}
+
+		r := image.Rect(
+			int(math.Round(t.rect.X)),
+			int(math.Round(t.rect.Y)),
+			int(math.Round(t.rect.X+t.rect.W)),
+			int(math.Round(t.rect.Y+t.rect.H)),
+		)
+
+		// the background color is used as a border between the tiles.
+		draw.Draw(img, r, image.NewUniform(imageBackground), image.Point{}, draw.Src)
+		draw.Draw(img, r.Inset(1), image.NewUniform(fill), image.Point{}, draw.Src)
+	}
+
+	return png.Encode(w, img)
+}
+
+func (opts ImageOptions) validate(root *structure.Entry) error {
+	if root == nil {
+		return errors.New("empty root entry")
+	}
+
+	if opts.Width <= 0 || opts.Height <= 0 {
+		return fmt.Errorf("invalid image size: %dx%d", opts.Width, opts.Height)
+	}
+
+	return nil
+}
+
+// layoutImage lays out the child entries of the root within the provided
+// rectangle. The directories within the depth limit are drawn as frames with
+// their own child entries laid out inside.
+func layoutImage(root *structure.Entry, r treemap.Rect, depth int) []imageTile {
+	var tiles []imageTile
+
+	var layout func(e *structure.Entry, r treemap.Rect, depth int)
+
+	layout = func(e *structure.Entry, r treemap.Rect, depth int) {
+		var (
+			children []*structure.Entry
+			values   []float64
+		)
+
+		for child := range e.Entries() {
+			children = append(children, child)
+			values = append(values, float64(child.Size))
+		}
+
+		for i, cr := range treemap.Squarify(values, r) {
+			if cr.W < 1 || cr.H < 1 {
+				continue
+			}
+
+			child := children[i]
+
+			if !child.IsDir || !child.HasChild() || depth <= 1 ||
+				cr.W < imageMinFrameSize || cr.H < imageMinFrameSize {
+				tiles = append(
+					tiles,
+					imageTile{rect: cr, entry: child, category: EntryCategory(child)},
+				)
+
+				continue
+			}
+
+			tiles = append(tiles, imageTile{rect: cr, entry: child, frame: true})
+
+			layout(child, treemap.Rect{
+				X: cr.X + imageFramePadding,
+				Y: cr.Y + imageHeaderHeight,
+				W: cr.W - imageFramePadding*2,
+				H: cr.H - imageHeaderHeight - imageFramePadding,
+			}, depth-1)
+		}
+	}
+
+	layout(root, r, depth)
+
+	return tiles
+}
+
+func collectCategorySizes(e *structure.Entry, sizes map[filetype.Category]int64) {
+	for child := range e.Entries() {
+		if child.IsDir {
+			collectCategorySizes(child, sizes)
+
+			continue
+		}
+
+		sizes[filetype.Of(child.Ext())] += child.Size
+	}
+}
+
+// fitLabel truncates the label to fit the provided width. It returns an empty
+// string if even the shortest label doesn't fit.
+func fitLabel(label string, width float64) string {
+	maxChars := int(width / imageCharWidth)
+
+	if utf8.RuneCountInString(label) <= maxChars {
+		return label
+	}
+
+	if maxChars < 4 {
+		return ""
+	}
+
+	return string([]rune(label)[:maxChars-1]) + "…"
+}
+
+func hexColor(c color.RGBA) string {
+	return fmt.Sprintf("#%02x%02x%02x", c.R, c.G, c.B)
+}
+
+func escapeXML(s string) string {
+	sb := strings.Builder{}
+	_ = xml.EscapeText(&sb, []byte(s))
+
+	return sb.String()
+}
@@ -0,0 +1,67 @@
+package export_test
+
+import (
+	"bytes"
+	"image/png"
+	"path/filepath"
+	"testing"
+
+	"github.com/crumbyte/noxdir/export"
+	"github.com/crumbyte/noxdir/pkg/filetype"
+	"github.com/crumbyte/noxdir/structure"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestWriteImage(t *testing.T) {
+	root := imageTestTree()
+
+	require.Equal(t, filetype.Video, export.EntryCategory(root))
+
+	buf := bytes.NewBuffer(nil)
+	opts := export.ImageOptions{Width: 800, Height: 600, Depth: 2}
+
+	require.NoError(t, export.WriteSVG(buf, root, opts))
+
+	svg := buf.String()
+
+	require.Contains(t, svg, `width="800" height="600"`)
+	require.Contains(t, svg, "movies 9.80 KB")
+	require.Contains(t, svg, "film.mkv 9.80 KB")
+	require.Contains(t, svg, "a&lt;b&gt;.go 2.00 KB")
+	require.Contains(t, svg, ">video</text>")
+	require.Contains(t, svg, ">code</text>")
+	require.NotContains(t, svg, ">audio</text>")
+
+	buf.Reset()
+
+	require.NoError(t, export.WritePNG(buf, root, opts))
+
+	img, err := png.Decode(buf)
+	require.NoError(t, err)
+	require.Equal(t, 800, img.Bounds().Dx())
+	require.Equal(t, 600, img.Bounds().Dy())
+
+	require.Error(t, export.WriteSVG(buf, root, export.ImageOptions{Width: 10}))
+	require.Error(t, export.WritePNG(buf, nil, opts))
+}
+
+func imageTestTree() *structure.Entry {
+	root := structure.NewDirEntry("root", 0)
+	movies := structure.NewDirEntry(filepath.Join("root", "movies"), 0)
+	src := structure.NewDirEntry(filepath.Join("root", "src"), 0)
+
+	root.AddChild(movies)
+	root.AddChild(src)
+
+	movies.AddChild(
+		structure.NewFileEntry(filepath.Join(movies.Path, "film.mkv"), 10000, 0),
+	)
+	src.AddChild(
+		structure.NewFileEntry(filepath.Join(src.Path, "a<b>.go"), 2000, 0),
+	)
+
+	structure.NewTree(root).CalculateSize()
+
+	return root
+}
@@ -0,0 +1,224 @@
+// Package export provides the encoders for exporting the scan results to the
+// formats consumed by the external tools.
+package export
+
+import (
+	"bufio"
+	"cmp"
+	"container/heap"
+	"fmt"
+	"io"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/crumbyte/noxdir/drive"
+	"github.com/crumbyte/noxdir/structure"
+)
+
+// ScanReport contains the result of a single root directory scan.
+type ScanReport struct {
+	Root     *structure.Entry
+	Duration time.Duration
+	Errors   int
+}
+
+// PrometheusOptions defines which directories are exported for each scanned
+// root. A directory is exported if it is within the depth limit or if it is one
+// of the top N biggest directories.
+type PrometheusOptions struct {
+	TopDirs int
+	Depth   int
+}
+
+// metricFamily describes a single metric with its samples. The samples of the
+// same family must be grouped together in the Prometheus text format.
+type metricFamily struct {
+	name    string
+	help    string
+	samples []sample
+}
+
+type sample struct {
+	labels [][2]string
+	value  string
+}
+
+// WritePrometheus writes the scan reports and the drives usage in the Prometheus
+// text exposition format. The output is suitable for the node_exporter textfile
+// collector.
+func WritePrometheus(
+	w io.Writer,
+	reports []ScanReport,
+	drives []*drive.Info,
+	opts PrometheusOptions,
+) error {
+	dirBytes := metricFamily{
+		name: "noxdir_directory_bytes",
+		help: "Total size of the directory in bytes.",
+	}
+	dirFiles := metricFamily{
+		name: "noxdir_directory_files",
+		help: "Total number of files within the directory.",
+	}
+	dirDirs := metricFamily{
+		name: "noxdir_directory_dirs",
+		help: "Total number of directories within the directory.",
+	}
+	scanDuration := metricFamily{
+		name: "noxdir_scan_duration_seconds",
+		help: "Duration of the root directory scan in seconds.",
+	}
+	scanErrors := metricFamily{
+		name: "noxdir_scan_errors",
+		help: "Number of errors occurred during the root directory scan.",
+	}
+
+	for _, r := range reports {
+		if r.Root == nil {
+			continue
+		}
+
+		rootLabel := [2]string{"root", r.Root.Path}
+
+		for _, dir := range ExportedDirs(r.Root, opts) {
+			labels := [][2]string{rootLabel, {"path", dir.Path}}
+
+			dirBytes.add(labels, strconv.FormatInt(dir.Size, 10))
+			dirFiles.add(labels, strconv.FormatUint(dir.TotalFiles, 10))
+			dirDirs.add(labels, strconv.FormatUint(dir.TotalDirs, 10))
+		}
+
+		scanDuration.add(
+			[][2]string{rootLabel},
+			strconv.FormatFloat(r.Duration.Seconds(), 'f', -1, 64),
+		)
+		scanErrors.add([][2]string{rootLabel}, strconv.Itoa(r.Errors))
+	}
+
+	driveTotal := metricFamily{
+		name: "noxdir_drive_capacity_bytes",
+		help: "Total capacity of the drive in bytes.",
+	}
+	driveUsed := metricFamily{
+		name: "noxdir_drive_used_bytes",
+		help: "Used space of the drive in bytes.",
+	}
+	driveFree := metricFamily{
+		name: "noxdir_drive_free_bytes",
+		help: "Free space of the drive in bytes.",
+	}
+
+	for _, d := range drives {
+		// the device entries duplicate the usage of their first mount point.
+		if d.IsDev != 0 {
+			continue
+		}
+
+		labels := [][2]string{
+			{"path", d.Path}, {"device", d.Device}, {"fstype", d.FSName},
+		}
+
+		driveTotal.add(labels, strconv.FormatUint(d.TotalBytes, 10))
+		driveUsed.add(labels, strconv.FormatUint(d.UsedBytes, 10))
+		driveFree.add(labels, strconv.FormatUint(d.FreeBytes, 10))
+	}
+
+	bw := bufio.NewWriter(w)
+
+	for _, mf := range []metricFamily{
+		dirBytes, dirFiles, dirDirs, scanDuration, scanErrors,
+		driveTotal, driveUsed, driveFree,
+	} {
+		mf.write(bw)
+	}
+
+	return bw.Flush()
+}
+
+// ExportedDirs returns the directories of the root that must be exported with
+// the provided options. It includes the directories within the depth limit and
+// the top N biggest directories found by the structure.TopEntries. The result
+// is sorted by path and always contains the root itself.
+func ExportedDirs(root *structure.Entry, opts PrometheusOptions) []*structure.Entry {
+	dirs := make(map[string]*structure.Entry)
+
+	var walk func(e *structure.Entry, depth int)
+
+	walk = func(e *structure.Entry, depth int) {
+		dirs[e.Path] = e
+
+		if depth <= 0 {
+			return
+		}
+
+		for child := range e.EntriesByType(true) {
+			walk(child, depth-1)
+		}
+	}
+
+	walk(root, opts.Depth)
+
+	if opts.TopDirs > 0 {
+		te := structure.NewTopEntries(opts.TopDirs)
+		te.ScanDirs(root)
+
+		for topDirs := te.Dirs(); topDirs.Len() > 0; {
+			if e, ok := heap.Pop(topDirs).(*structure.Entry); ok {
+				dirs[e.Path] = e
+			}
+		}
+	}
+
+	result := make([]*structure.Entry, 0, len(dirs))
+
+	for _, e := range dirs {
+		result = append(result, e)
+	}
+
+	slices.SortFunc(result, func(a, b *structure.Entry) int {
+		return cmp.Compare(a.Path, b.Path)
+	})
+
+	return result
+}
+
+func (mf *metricFamily) add(labels [][2]string, value string) {
+	mf.samples = append(mf.samples, sample{labels: labels, value: value})
+}
+
+func (mf *metricFamily) write(w *bufio.Writer) {
+	if len(mf.samples) == 0 {
+		return
+	}
+
+	_, _ = fmt.Fprintf(w, "# HELP %s %s\n# TYPE %s gauge\n", mf.name, mf.help, mf.name)
+
+	for _, s := range mf.samples {
+		_, _ = w.WriteString(mf.name)
+
+		if len(s.labels) > 0 {
+			_ = w.WriteByte('{')
+
+			for i, l := range s.labels {
+				if i > 0 {
+					_ = w.WriteByte(',')
+				}
+
+				_, _ = w.WriteString(l[0] + `="` + escapeLabel(l[1]) + `"`)
+			}
+
+			_ = w.WriteByte('}')
+		}
+
+		_, _ = w.WriteString(" " + s.value + "\n")
+	}
+}
+
+// labelReplacer escapes the label value according to the Prometheus text format.
+var labelReplacer = strings.NewReplacer(`\`, `\\`, `"`, `\"`, "\n", `\n`)
+
+func escapeLabel(value string) string {
+	return labelReplacer.Replace(value)
+}
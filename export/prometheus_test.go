@@ -0,0 +1,72 @@
+package export_test
+
+import (
+	"bytes"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/crumbyte/noxdir/drive"
+	"github.com/crumbyte/noxdir/export"
+	"github.com/crumbyte/noxdir/structure"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestWritePrometheus(t *testing.T) {
+	root := structure.NewDirEntry("root", 0)
+	level1 := structure.NewDirEntry(filepath.Join("root", "level1"), 0)
+	level2 := structure.NewDirEntry(filepath.Join("root", "level1", `le"vel2`), 0)
+
+	root.AddChild(level1)
+	level1.AddChild(level2)
+	level2.AddChild(
+		structure.NewFileEntry(filepath.Join(level2.Path, "file"), 100, 0),
+	)
+
+	tree := structure.NewTree(root)
+	tree.CalculateSize()
+
+	reports := []export.ScanReport{
+		{Root: root, Duration: time.Millisecond * 1500, Errors: 2},
+	}
+	drives := []*drive.Info{
+		{Path: "/", Device: "/dev/sda1", FSName: "ext4", TotalBytes: 10, UsedBytes: 4, FreeBytes: 6},
+		{Path: "/", Device: "/dev/sda1", FSName: "ext4", TotalBytes: 10, IsDev: 1},
+	}
+
+	buf := bytes.NewBuffer(nil)
+
+	err := export.WritePrometheus(
+		buf, reports, drives, export.PrometheusOptions{Depth: 1},
+	)
+	require.NoError(t, err)
+
+	output := buf.String()
+
+	require.Contains(t, output, "# TYPE noxdir_directory_bytes gauge\n")
+	require.Contains(t, output, `noxdir_directory_bytes{root="root",path="root"} 100`+"\n")
+	require.Contains(t, output, `noxdir_directory_files{root="root",path="`+level1.Path+`"} 1`+"\n")
+	require.Contains(t, output, `noxdir_directory_dirs{root="root",path="root"} 2`+"\n")
+	require.NotContains(t, output, "vel2")
+	require.Contains(t, output, `noxdir_scan_duration_seconds{root="root"} 1.5`+"\n")
+	require.Contains(t, output, `noxdir_scan_errors{root="root"} 2`+"\n")
+	require.Contains(t, output, `noxdir_drive_capacity_bytes{path="/",device="/dev/sda1",fstype="ext4"} 10`+"\n")
+	require.Contains(t, output, `noxdir_drive_used_bytes{path="/",device="/dev/sda1",fstype="ext4"} 4`+"\n")
+	require.Equal(t, 1, strings.Count(output, "noxdir_drive_capacity_bytes{"))
+	require.Contains(t, output, `noxdir_drive_free_bytes{path="/",device="/dev/sda1",fstype="ext4"} 6`+"\n")
+
+	buf.Reset()
+
+	err = export.WritePrometheus(
+		buf, reports, nil, export.PrometheusOptions{TopDirs: 1},
+	)
+	require.NoError(t, err)
+
+	output = buf.String()
+
+	require.Contains(t, output, `path="`+filepath.Join("root", "level1", `le\"vel2`)+`"} 100`)
+	require.NotContains(t, output, `path="`+level1.Path+`"`)
+	require.NotContains(t, output, "noxdir_drive_")
+}
@@ -1,9 +1,12 @@
 package filter
 
 import (
+	"fmt"
 	"regexp"
 	"strings"
+	"time"
 
+	"github.com/crumbyte/noxdir/drive"
 	"github.com/crumbyte/noxdir/structure"
 
 	"charm.land/bubbles/v2/textinput"
@@ -16,8 +19,13 @@ const (
 	FilesOnlyFilterID ID = "FilesOnly"
 	NameFilterID      ID = "NameFilter"
 	EmptyDirFilterID  ID = "EmptyDirFilter"
+	SizeFilterID      ID = "SizeFilter"
+	AgeFilterID       ID = "AgeFilter"
 )
 
+// ageDateLayout defines the date format supported by the AgeFilter.
+const ageDateLayout = "2006-01-02"
+
 // DirsFilter filters *Entry by its type and allows directories only.
 type DirsFilter struct {
 	enabled bool
@@ -125,20 +133,11 @@ type NameFilter struct {
 }
 
 func NewNameFilter(textColor string) *NameFilter {
-	ti := textinput.New()
-
-	textStyle := lipgloss.NewStyle().Foreground(lipgloss.Color(textColor))
-
-	tiStyle := textinput.DefaultStyles(true)
-	tiStyle.Focused.Prompt = textStyle
-	tiStyle.Focused.Text = textStyle
-
-	ti.SetStyles(tiStyle)
-
-	ti.Placeholder = `Filter… Examples: "mp4" (match), "\mp4" (exclude), ":regex" ":^.+?\.mp4" (regular expression)`
-	ti.Focus()
-	ti.SetWidth(lipgloss.Width(ti.Placeholder))
-	ti.Prompt = "\uE68F  "
+	ti := NewInput(
+		textColor,
+		`Filter… Examples: "mp4" (match), "\mp4" (exclude), ":regex" ":^.+?\.mp4" (regular expression)`,
+		"\uE68F  ",
+	)
 
 	return &NameFilter{input: ti, enabled: false}
 }
@@ -212,12 +211,7 @@ func (nf *NameFilter) View() tea.View {
 		return tea.View{}
 	}
 
-	s := lipgloss.NewStyle().
-		BorderStyle(lipgloss.NormalBorder()).
-		BorderForeground(lipgloss.Color("240")).
-		BorderTop(true)
-
-	return tea.NewView(s.Render(nf.input.View()))
+	return inputView(nf.input, nil)
 }
 
 func (nf *NameFilter) resolveFilterType(filterInput string) NameFilterType {
@@ -236,3 +230,333 @@ func (nf *NameFilter) resolveFilterType(filterInput string) NameFilterType {
 
 	return resolvedType
 }
+
+// SizeFilter filters a single instance of the *structure.Entry by its size. The
+// size range is defined by the user's input in the same format as the
+// "--size-limit" flag, e.g., "1GB:5GB", "1GB:", ":10GB". Unlike the flag, the
+// filter is applied to the already scanned entries, including directories, so
+// the directory totals remain accurate.
+//
+// The filter is not applied while the input is empty or invalid.
+type SizeFilter struct {
+	input    textinput.Model
+	minLimit int64
+	maxLimit int64
+	err      error
+	enabled  bool
+}
+
+func NewSizeFilter(textColor string) *SizeFilter {
+	ti := NewInput(
+		textColor,
+		`Size range… Examples: "1GB:5GB" (between), "500MB:" (at least), ":10KB" (at most)`,
+		"\uF0AD  ",
+	)
+
+	return &SizeFilter{input: ti}
+}
+
+func (sf *SizeFilter) ID() ID {
+	return SizeFilterID
+}
+
+func (sf *SizeFilter) Toggle() {
+	sf.enabled = !sf.enabled
+}
+
+func (sf *SizeFilter) Enabled() bool {
+	return sf.enabled
+}
+
+// Active checks whether the filter has a valid size range and affects the
+// entries.
+func (sf *SizeFilter) Active() bool {
+	return sf.err == nil && (sf.minLimit > 0 || sf.maxLimit > 0)
+}
+
+// Limits returns the current size range. A zero limit means there is no
+// corresponding boundary.
+func (sf *SizeFilter) Limits() (int64, int64) {
+	return sf.minLimit, sf.maxLimit
+}
+
+// Filter filters an instance of *structure.Entry by checking if its size is
+// within the current size range.
+func (sf *SizeFilter) Filter(e *structure.Entry) bool {
+	if !sf.Active() {
+		return true
+	}
+
+	return e.Size >= sf.minLimit && (sf.maxLimit == 0 || e.Size <= sf.maxLimit)
+}
+
+func (sf *SizeFilter) Update(msg tea.Msg) {
+	switch msg := msg.(type) {
+	case tea.WindowSizeMsg:
+		sf.input.SetWidth(msg.Width)
+	case tea.KeyPressMsg:
+		if msg.String() == "esc" {
+			sf.enabled = false
+		}
+	}
+
+	if !sf.enabled {
+		return
+	}
+
+	sf.input, _ = sf.input.Update(msg)
+
+	sf.minLimit, sf.maxLimit, sf.err = 0, 0, nil
+
+	if value := strings.TrimSpace(sf.input.Value()); len(value) > 0 {
+		// a single value without the separator defines the lower bound.
+		if !strings.Contains(value, ":") {
+			value += ":"
+		}
+
+		sf.minLimit, sf.maxLimit, sf.err = drive.ParseSizeLimit(value)
+	}
+}
+
+func (sf *SizeFilter) Reset() {
+	sf.enabled = false
+	sf.minLimit, sf.maxLimit, sf.err = 0, 0, nil
+	sf.input.Reset()
+}
+
+func (sf *SizeFilter) View() tea.View {
+	if !sf.enabled {
+		return tea.View{}
+	}
+
+	return inputView(sf.input, sf.err)
+}
+
+// AgeFilter filters a single instance of the *structure.Entry by its last
+// modification time. The user's input contains one or more space-separated
+// conditions, where each condition is either an age or a date prefixed by the
+// comparison operator:
+//
+//   - ">90d", "<12h" - the entry is older/newer than the provided age;
+//   - ">2024-01-01", "<2024-01-01" - the entry was modified after/before the
+//     provided date.
+//
+// Files are evaluated against their own modification time, and directories are
+// evaluated against the newest modification time within their subtree. The
+// filter is not applied while the input is empty or invalid.
+type AgeFilter struct {
+	input textinput.Model
+
+	// after and before define the modification time range in Unix seconds.
+	// A zero value means there is no corresponding boundary.
+	after  int64
+	before int64
+
+	modTimes newestModTimes
+	err      error
+	enabled  bool
+}
+
+func NewAgeFilter(textColor string) *AgeFilter {
+	ti := NewInput(
+		textColor,
+		`Age… Examples: ">90d" (older than), "<12h" (newer than), "<2024-01-01" (before), ">30d <365d" (range)`,
+		"\uF017  ",
+	)
+
+	return &AgeFilter{input: ti, modTimes: make(newestModTimes)}
+}
+
+func (af *AgeFilter) ID() ID {
+	return AgeFilterID
+}
+
+func (af *AgeFilter) Toggle() {
+	af.enabled = !af.enabled
+}
+
+func (af *AgeFilter) Enabled() bool {
+	return af.enabled
+}
+
+// Active checks whether the filter has a valid modification time range and
+// affects the entries.
+func (af *AgeFilter) Active() bool {
+	return af.err == nil && (af.after > 0 || af.before > 0)
+}
+
+// Value returns the current filter input.
+func (af *AgeFilter) Value() string {
+	return strings.TrimSpace(af.input.Value())
+}
+
+// Filter filters an instance of *structure.Entry by checking if its
+// modification time is within the current range.
+func (af *AgeFilter) Filter(e *structure.Entry) bool {
+	if !af.Active() {
+		return true
+	}
+
+	modTime := e.ModTime
+
+	if e.IsDir {
+		modTime = af.modTimes.get(e)
+	}
+
+	return (af.after == 0 || modTime > af.after) &&
+		(af.before == 0 || modTime < af.before)
+}
+
+func (af *AgeFilter) Update(msg tea.Msg) {
+	switch msg := msg.(type) {
+	case tea.WindowSizeMsg:
+		af.input.SetWidth(msg.Width)
+	case tea.KeyPressMsg:
+		if msg.String() == "esc" {
+			af.enabled = false
+		}
+	}
+
+	if !af.enabled {
+		return
+	}
+
+	af.input, _ = af.input.Update(msg)
+	af.after, af.before, af.err = parseAgeRange(af.Value(), time.Now())
+}
+
+func (af *AgeFilter) Reset() {
+	af.enabled = false
+	af.after, af.before, af.err = 0, 0, nil
+	af.input.Reset()
+
+	af.Invalidate()
+}
+
+// Invalidate discards the cached modification times of the directories.
+func (af *AgeFilter) Invalidate() {
+	clear(af.modTimes)
+}
+
+func (af *AgeFilter) View() tea.View {
+	if !af.enabled {
+		return tea.View{}
+	}
+
+	return inputView(af.input, af.err)
+}
+
+// newestModTimes caches the newest modification time of the directories'
+// subtrees since walking them is expensive. The cache refers to the entries, so
+// it must be cleared once the tree changes. See Invalidator.
+type newestModTimes map[*structure.Entry]int64
+
+// get returns the newest modification time within the entry's subtree,
+// including the entry itself.
+func (nmt newestModTimes) get(e *structure.Entry) int64 {
+	if modTime, ok := nmt[e]; ok {
+		return modTime
+	}
+
+	modTime := e.ModTime
+
+	for child := range e.Entries() {
+		if child.IsDir {
+			modTime = max(modTime, nmt.get(child))
+
+			continue
+		}
+
+		modTime = max(modTime, child.ModTime)
+	}
+
+	nmt[e] = modTime
+
+	return modTime
+}
+
+// parseAgeRange parses the space-separated age conditions and returns the
+// resulting modification time range relative to the provided time. Multiple
+// conditions narrow the range.
+func parseAgeRange(value string, now time.Time) (int64, int64, error) {
+	var after, before int64
+
+	for _, cond := range strings.Fields(value) {
+		op, rawValue := cond[0], strings.TrimSpace(cond[1:])
+
+		if op != '<' && op != '>' {
+			return 0, 0, fmt.Errorf("missing comparison operator: %s", cond)
+		}
+
+		var (
+			bound int64
+			older bool
+		)
+
+		if date, err := time.ParseInLocation(ageDateLayout, rawValue, time.Local); err == nil {
+			// ">date" means modified after the date.
+			bound, older = date.Unix(), op == '<'
+		} else {
+			age, err := drive.ParseAge(rawValue)
+			if err != nil {
+				return 0, 0, err
+			}
+
+			// ">age" means older than the age, i.e., modified before.
+			bound, older = now.Add(-age).Unix(), op == '>'
+		}
+
+		if older {
+			if before == 0 || bound < before {
+				before = bound
+			}
+
+			continue
+		}
+
+		after = max(after, bound)
+	}
+
+	return after, before, nil
+}
+
+// NewInput creates a focused text input for the prompt with the provided
+// placeholder and prompt symbol. The input's text and prompt use the provided
+// color. It's shared by all prompts, so they look the same.
+func NewInput(textColor, placeholder, prompt string) textinput.Model {
+	ti := textinput.New()
+
+	textStyle := lipgloss.NewStyle().Foreground(lipgloss.Color(textColor))
+
+	tiStyle := textinput.DefaultStyles(true)
+	tiStyle.Focused.Prompt = textStyle
+	tiStyle.Focused.Text = textStyle
+
+	ti.SetStyles(tiStyle)
+
+	ti.Placeholder = placeholder
+	ti.Focus()
+	ti.SetWidth(lipgloss.Width(ti.Placeholder))
+	ti.Prompt = prompt
+
+	return ti
+}
+
+// inputView renders the filter's prompt. If the input cannot be parsed, the
+// error is shown next to the input.
+func inputView(input textinput.Model, err error) tea.View {
+	s := lipgloss.NewStyle().
+		BorderStyle(lipgloss.NormalBorder()).
+		BorderForeground(lipgloss.Color("240")).
+		BorderTop(true)
+
+	content := input.View()
+
+	if err != nil {
+		content += lipgloss.NewStyle().
+			Foreground(lipgloss.Color("240")).
+			Render("  " + err.Error())
+	}
+
+	return tea.NewView(s.Render(content))
+}
@@ -0,0 +1,129 @@
+package filter_test
+
+import (
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/crumbyte/noxdir/filter"
+	"github.com/crumbyte/noxdir/structure"
+
+	tea "charm.land/bubbletea/v2"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSizeFilter(t *testing.T) {
+	small := structure.NewFileEntry("small", 512, 0)
+	medium := structure.NewFileEntry("medium", 2048*1024, 0)
+	large := structure.NewDirEntry("large", 0)
+	large.Size = 2048 * 1024 * 1024
+
+	sf := filter.NewSizeFilter("")
+	sf.Toggle()
+
+	input := func(value string) {
+		sf.Update(tea.KeyPressMsg{Code: 'u', Mod: tea.ModCtrl})
+
+		for _, r := range value {
+			sf.Update(tea.KeyPressMsg{Code: r, Text: string(r)})
+		}
+	}
+
+	tests := []struct {
+		input  string
+		active bool
+		passed []bool
+	}{
+		{"", false, []bool{true, true, true}},
+		{"1MB:1GB", true, []bool{false, true, false}},
+		{"1MB", true, []bool{false, true, true}},
+		{":1KB", true, []bool{true, false, false}},
+		{"1XB:", false, []bool{true, true, true}},
+	}
+
+	for _, tt := range tests {
+		input(tt.input)
+
+		require.Equal(t, tt.active, sf.Active(), tt.input)
+
+		for i, e := range []*structure.Entry{small, medium, large} {
+			require.Equal(t, tt.passed[i], sf.Filter(e), "%s: %s", tt.input, e.Path)
+		}
+	}
+
+	// the filter remains active with the closed prompt.
+	input("1MB:")
+	sf.Update(tea.KeyPressMsg{Code: tea.KeyEscape})
+
+	require.False(t, sf.Enabled())
+	require.True(t, sf.Active())
+	require.False(t, sf.Filter(small))
+
+	sf.Reset()
+
+	require.False(t, sf.Active())
+	require.True(t, sf.Filter(small))
+}
+
+func TestAgeFilter(t *testing.T) {
+	now := time.Now()
+	day := int64(24 * 60 * 60)
+
+	recent := structure.NewFileEntry("recent", 0, now.Unix()-day)
+	old := structure.NewFileEntry("old", 0, now.Unix()-day*100)
+
+	// the directory is old itself, but contains a recently modified file.
+	dir := structure.NewDirEntry("dir", now.Unix()-day*200)
+	dir.AddChild(structure.NewFileEntry(filepath.Join("dir", "file"), 0, now.Unix()-day*10))
+
+	af := filter.NewAgeFilter("")
+	af.Toggle()
+
+	input := func(value string) {
+		af.Update(tea.KeyPressMsg{Code: 'u', Mod: tea.ModCtrl})
+
+		for _, r := range value {
+			af.Update(tea.KeyPressMsg{Code: r, Text: string(r)})
+		}
+	}
+
+	tests := []struct {
+		input  string
+		active bool
+		passed []bool
+	}{
+		{"", false, []bool{true, true, true}},
+		{">90d", true, []bool{false, true, false}},
+		{"<30d", true, []bool{true, false, true}},
+		{">5d <50d", true, []bool{false, false, true}},
+		{"<" + now.AddDate(0, 0, -50).Format("2006-01-02"), true, []bool{false, true, false}},
+		{">" + now.AddDate(0, 0, -50).Format("2006-01-02"), true, []bool{true, false, true}},
+		{"90d", false, []bool{true, true, true}},
+		{">90x", false, []bool{true, true, true}},
+	}
+
+	for _, tt := range tests {
+		input(tt.input)
+
+		require.Equal(t, tt.active, af.Active(), tt.input)
+
+		for i, e := range []*structure.Entry{recent, old, dir} {
+			require.Equal(t, tt.passed[i], af.Filter(e), "%s: %s", tt.input, e.Path)
+		}
+	}
+
+	// the cached modification time of the changed directory is discarded.
+	input(">90d")
+	require.False(t, af.Filter(dir))
+
+	require.True(t, dir.RemoveChild(dir.Child[0]))
+	require.False(t, af.Filter(dir))
+
+	af.Invalidate()
+	require.True(t, af.Filter(dir))
+
+	af.Reset()
+
+	require.False(t, af.Active())
+	require.Empty(t, af.Value())
+}
@@ -14,6 +14,13 @@ type Reset interface {
 	Reset()
 }
 
+// Invalidator defines an interface for filters caching the state of the
+// entries. The cached state must be discarded once the tree changes, e.g., after
+// the entries were rescanned or deleted.
+type Invalidator interface {
+	Invalidate()
+}
+
 // Toggler enables or disables the filter.
 type Toggler interface {
 	Toggle()
@@ -95,6 +102,16 @@ func (fl *FiltersList) Reset() {
 	}
 }
 
+// Invalidate discards the cached entries state of all filters implementing the
+// Invalidator interface. The filters remain enabled.
+func (fl *FiltersList) Invalidate() {
+	for _, filter := range *fl {
+		if i, ok := filter.(Invalidator); ok {
+			i.Invalidate()
+		}
+	}
+}
+
 // Update traverses all filters implementing the Updater interface in order to
 // update the filters' state based on incoming input.
 func (fl *FiltersList) Update(msg tea.Msg) {
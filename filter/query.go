@@ -0,0 +1,715 @@
+package filter
+
+import (
+	"errors"
+	"fmt"
+	"regexp"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/crumbyte/noxdir/drive"
+	"github.com/crumbyte/noxdir/structure"
+
+	"charm.land/bubbles/v2/textinput"
+	tea "charm.land/bubbletea/v2"
+)
+
+const QueryFilterID ID = "QueryFilter"
+
+// maxQueryDepth limits the nesting of the named queries references.
+const maxQueryDepth = 16
+
+// QueryFilter filters a single instance of the *structure.Entry by the query
+// expression. The expression consists of the comparisons combined with the
+// "and", "or", and "not" operators and parentheses, e.g.:
+//
+//	size>1G and ext in (mp4,mkv) and age>30d and not name~"^tmp"
+//
+// The adjacent comparisons without an operator are combined with "and". The
+// supported fields are:
+//
+//   - name, path - the entry name or full path; supports "=", "!=", "~" and
+//     "!~" (regular expression), and "in";
+//   - ext - the file extension; supports "=", "!=", and "in";
+//   - type - either "file" or "dir"; supports "=" and "!=";
+//   - size - the entry size, e.g., "1G", "500MB", "1024";
+//   - age - the time since the last modification, e.g., "30d", "12h";
+//   - mtime - the last modification date, e.g., "2024-01-01".
+//
+// The numeric fields support "=", "!=", ">", ">=", "<", and "<=". Directories
+// are evaluated against the newest modification time within their subtree.
+// Named queries defined in the settings file can be referenced as "@name".
+//
+// The filter is not applied while the input is empty or invalid.
+type QueryFilter struct {
+	input    textinput.Model
+	named    map[string]string
+	query    queryNode
+	modTimes newestModTimes
+	err      error
+	enabled  bool
+}
+
+func NewQueryFilter(textColor string, named map[string]string) *QueryFilter {
+	ti := NewInput(
+		textColor,
+		`Query… Example: size>1G and ext in (mp4,mkv) and age>30d and not name~"^tmp", @saved`,
+		"\uF0B0  ",
+	)
+
+	return &QueryFilter{
+		input:    ti,
+		named:    named,
+		modTimes: make(newestModTimes),
+	}
+}
+
+func (qf *QueryFilter) ID() ID {
+	return QueryFilterID
+}
+
+func (qf *QueryFilter) Toggle() {
+	qf.enabled = !qf.enabled
+}
+
+func (qf *QueryFilter) Enabled() bool {
+	return qf.enabled
+}
+
+// Active checks whether the filter has a valid query and affects the entries.
+func (qf *QueryFilter) Active() bool {
+	return qf.err == nil && qf.query != nil
+}
+
+// Value returns the current query input.
+func (qf *QueryFilter) Value() string {
+	return strings.TrimSpace(qf.input.Value())
+}
+
+// Filter filters an instance of *structure.Entry by evaluating the query.
+func (qf *QueryFilter) Filter(e *structure.Entry) bool {
+	if !qf.Active() {
+		return true
+	}
+
+	return qf.query.match(e)
+}
+
+func (qf *QueryFilter) Update(msg tea.Msg) {
+	switch msg := msg.(type) {
+	case tea.WindowSizeMsg:
+		qf.input.SetWidth(msg.Width)
+	case tea.KeyPressMsg:
+		if msg.String() == "esc" {
+			qf.enabled = false
+		}
+	}
+
+	if !qf.enabled {
+		return
+	}
+
+	qf.input, _ = qf.input.Update(msg)
+	qf.query, qf.err = parseQuery(qf.Value(), qf.named, qf.modTimes)
+}
+
+func (qf *QueryFilter) Reset() {
+	qf.enabled = false
+	qf.query, qf.err = nil, nil
+	qf.input.Reset()
+
+	qf.Invalidate()
+}
+
+// Invalidate discards the cached modification times of the directories.
+func (qf *QueryFilter) Invalidate() {
+	clear(qf.modTimes)
+}
+
+func (qf *QueryFilter) View() tea.View {
+	if !qf.enabled {
+		return tea.View{}
+	}
+
+	return inputView(qf.input, qf.err)
+}
+
+// queryNode represents a single node of the parsed query tree.
+type queryNode interface {
+	match(e *structure.Entry) bool
+}
+
+type andNode struct {
+	left, right queryNode
+}
+
+func (n andNode) match(e *structure.Entry) bool {
+	return n.left.match(e) && n.right.match(e)
+}
+
+type orNode struct {
+	left, right queryNode
+}
+
+func (n orNode) match(e *structure.Entry) bool {
+	return n.left.match(e) || n.right.match(e)
+}
+
+type notNode struct {
+	node queryNode
+}
+
+func (n notNode) match(e *structure.Entry) bool {
+	return !n.node.match(e)
+}
+
+// predicate represents a single comparison of the query.
+type predicate func(e *structure.Entry) bool
+
+func (p predicate) match(e *structure.Entry) bool {
+	return p(e)
+}
+
+type queryTokenType int
+
+const (
+	tokenEOF queryTokenType = iota
+	tokenWord
+	tokenString
+	tokenOperator
+	tokenRef
+	tokenLParen
+	tokenRParen
+	tokenComma
+)
+
+type queryToken struct {
+	value string
+	typ   queryTokenType
+	pos   int
+}
+
+// is checks whether the token is the provided case-insensitive keyword.
+func (t queryToken) is(keyword string) bool {
+	return t.typ == tokenWord && strings.EqualFold(t.value, keyword)
+}
+
+func (t queryToken) String() string {
+	if t.typ == tokenEOF {
+		return "end of query"
+	}
+
+	return strconv.Quote(t.value)
+}
+
+// queryError represents a syntax error at the specific position of the query.
+type queryError struct {
+	msg string
+	pos int
+}
+
+func (qe *queryError) Error() string {
+	return fmt.Sprintf("syntax error at %d: %s", qe.pos+1, qe.msg)
+}
+
+func tokenizeQuery(query string) ([]queryToken, error) {
+	var tokens []queryToken
+
+	for i := 0; i < len(query); {
+		c := query[i]
+
+		switch {
+		case c == ' ' || c == '\t':
+			i++
+		case c == '(' || c == ')' || c == ',':
+			typ := map[byte]queryTokenType{
+				'(': tokenLParen, ')': tokenRParen, ',': tokenComma,
+			}[c]
+
+			tokens = append(tokens, queryToken{value: string(c), typ: typ, pos: i})
+			i++
+		case c == '"':
+			value, n, err := readQueryString(query[i:])
+			if err != nil {
+				return nil, &queryError{msg: err.Error(), pos: i}
+			}
+
+			tokens = append(tokens, queryToken{value: value, typ: tokenString, pos: i})
+			i += n
+		case strings.IndexByte("=!<>~", c) != -1:
+			op := string(c)
+
+			if i+1 < len(query) && strings.IndexByte("=~", query[i+1]) != -1 {
+				op += string(query[i+1])
+			}
+
+			if !slices.Contains(
+				[]string{"=", "==", "!=", "<", "<=", ">", ">=", "~", "!~"}, op,
+			) {
+				return nil, &queryError{msg: "unknown operator " + strconv.Quote(op), pos: i}
+			}
+
+			tokens = append(tokens, queryToken{value: op, typ: tokenOperator, pos: i})
+			i += len(op)
+		default:
+			start := i
+
+			for i < len(query) && strings.IndexByte(" \t(),\"=!<>~", query[i]) == -1 {
+				i++
+			}
+
+			value, typ := query[start:i], tokenWord
+
+			if ref, ok := strings.CutPrefix(value, "@"); ok {
+				value, typ = ref, tokenRef
+			}
+
+			tokens = append(tokens, queryToken{value: value, typ: typ, pos: start})
+		}
+	}
+
+	return append(tokens, queryToken{typ: tokenEOF, pos: len(query)}), nil
+}
+
+// readQueryString reads the double-quoted string and returns its value along
+// with the number of consumed bytes. Only the quote and the backslash itself
+// can be escaped, so the regular expressions don't require double escaping.
+func readQueryString(s string) (string, int, error) {
+	sb := strings.Builder{}
+
+	for i := 1; i < len(s); i++ {
+		switch s[i] {
+		case '\\':
+			if i+1 < len(s) && (s[i+1] == '"' || s[i+1] == '\\') {
+				i++
+			}
+
+			sb.WriteByte(s[i])
+		case '"':
+			return sb.String(), i + 1, nil
+		default:
+			sb.WriteByte(s[i])
+		}
+	}
+
+	return "", 0, errors.New("unterminated string")
+}
+
+type queryParser struct {
+	tokens   []queryToken
+	pos      int
+	named    map[string]string
+	refs     []string
+	modTimes newestModTimes
+	now      time.Time
+}
+
+// parseQuery parses the query expression into the query tree. The empty query
+// results in a nil tree.
+func parseQuery(query string, named map[string]string, mt newestModTimes) (queryNode, error) {
+	p := &queryParser{named: named, modTimes: mt, now: time.Now()}
+
+	return p.parse(query)
+}
+
+func (p *queryParser) parse(query string) (queryNode, error) {
+	tokens, err := tokenizeQuery(query)
+	if err != nil {
+		return nil, err
+	}
+
+	//nolint:nilnil // an empty query doesn't filter anything
+	if tokens[0].typ == tokenEOF {
+		return nil, nil
+	}
+
+	outer, outerPos := p.tokens, p.pos
+	p.tokens, p.pos = tokens, 0
+
+	defer func() {
+		p.tokens, p.pos = outer, outerPos
+	}()
+
+	node, err := p.parseOr()
+	if err != nil {
+		return nil, err
+	}
+
+	if t := p.peek(); t.typ != tokenEOF {
+		return nil, p.errorf(t, "unexpected %s", t)
+	}
+
+	return node, nil
+}
+
+func (p *queryParser) parseOr() (queryNode, error) {
+	left, err := p.parseAnd()
+	if err != nil {
+		return nil, err
+	}
+
+	for p.peek().is("or") {
+		p.next()
+
+		right, err := p.parseAnd()
+		if err != nil {
+			return nil, err
+		}
+
+		left = orNode{left: left, right: right}
+	}
+
+	return left, nil
+}
+
+func (p *queryParser) parseAnd() (queryNode, error) {
+	left, err := p.parseUnary()
+	if err != nil {
+		return nil, err
+	}
+
+	for {
+		t := p.peek()
+
+		switch {
+		case t.is("and"):
+			p.next()
+		case t.is("or") || t.typ == tokenEOF || t.typ == tokenRParen:
+			return left, nil
+		}
+
+		// the adjacent expressions without an operator are combined with
+		// "and".
+		right, err := p.parseUnary()
+		if err != nil {
+			return nil, err
+		}
+
+		left = andNode{left: left, right: right}
+	}
+}
+
+func (p *queryParser) parseUnary() (queryNode, error) {
+	if !p.peek().is("not") {
+		return p.parsePrimary()
+	}
+
+	p.next()
+
+	node, err := p.parseUnary()
+	if err != nil {
+		return nil, err
+	}
+
+	return notNode{node: node}, nil
+}
+
+func (p *queryParser) parsePrimary() (queryNode, error) {
+	t := p.next()
+
+	switch t.typ {
+	case tokenLParen:
+		node, err := p.parseOr()
+		if err != nil {
+			return nil, err
+		}
+
+		if closing := p.next(); closing.typ != tokenRParen {
+			return nil, p.errorf(closing, "expected \")\", got %s", closing)
+		}
+
+		return node, nil
+	case tokenRef:
+		return p.parseRef(t)
+	case tokenWord:
+		return p.parseComparison(t)
+	default:
+		return nil, p.errorf(t, "expected field name, got %s", t)
+	}
+}
+
+// parseRef parses the named query referenced by the token.
+func (p *queryParser) parseRef(t queryToken) (queryNode, error) {
+	query, ok := p.named[t.value]
+	if !ok {
+		return nil, p.errorf(t, "unknown query @%s", t.value)
+	}
+
+	if slices.Contains(p.refs, t.value) || len(p.refs) >= maxQueryDepth {
+		return nil, p.errorf(t, "recursive query @%s", t.value)
+	}
+
+	p.refs = append(p.refs, t.value)
+
+	defer func() {
+		p.refs = p.refs[:len(p.refs)-1]
+	}()
+
+	node, err := p.parse(query)
+	if err != nil {
+		return nil, p.errorf(t, "@%s: %s", t.value, err)
+	}
+
+	if node == nil {
+		return nil, p.errorf(t, "empty query @%s", t.value)
+	}
+
+	return node, nil
+}
+
+func (p *queryParser) parseComparison(field queryToken) (queryNode, error) {
+	op := p.next()
+
+	if op.is("in") {
+		values, err := p.parseList()
+		if err != nil {
+			return nil, err
+		}
+
+		return p.inPredicate(field, values)
+	}
+
+	if op.typ != tokenOperator {
+		return nil, p.errorf(op, "expected operator after %s, got %s", field, op)
+	}
+
+	value := p.next()
+	if value.typ != tokenWord && value.typ != tokenString {
+		return nil, p.errorf(value, "expected value, got %s", value)
+	}
+
+	switch strings.ToLower(field.value) {
+	case "name":
+		return p.stringPredicate(op, value, func(e *structure.Entry) string {
+			return e.Name()
+		})
+	case "path":
+		return p.stringPredicate(op, value, func(e *structure.Entry) string {
+			return e.Path
+		})
+	case "ext":
+		if op.value == "~" || op.value == "!~" {
+			return nil, p.errorf(op, "unsupported operator %s for ext", op)
+		}
+
+		return p.stringPredicate(op, value, entryExt)
+	case "type":
+		return p.typePredicate(op, value)
+	case "size":
+		size, err := parseQuerySize(value.value)
+		if err != nil {
+			return nil, p.errorf(value, "%s", err)
+		}
+
+		return p.numericPredicate(op, size, func(e *structure.Entry) int64 {
+			return e.Size
+		})
+	case "age":
+		age, err := drive.ParseAge(value.value)
+		if err != nil {
+			return nil, p.errorf(value, "%s", err)
+		}
+
+		now := p.now.Unix()
+
+		return p.numericPredicate(op, int64(age.Seconds()), func(e *structure.Entry) int64 {
+			return now - p.modTime(e)
+		})
+	case "mtime":
+		date, err := time.ParseInLocation(ageDateLayout, value.value, time.Local)
+		if err != nil {
+			return nil, p.errorf(value, "invalid date %s, expected YYYY-MM-DD", value)
+		}
+
+		return p.numericPredicate(op, date.Unix(), p.modTime)
+	default:
+		return nil, p.errorf(field, "unknown field %s", field)
+	}
+}
+
+func (p *queryParser) parseList() ([]string, error) {
+	if t := p.next(); t.typ != tokenLParen {
+		return nil, p.errorf(t, "expected \"(\", got %s", t)
+	}
+
+	var values []string
+
+	for {
+		t := p.next()
+		if t.typ != tokenWord && t.typ != tokenString {
+			return nil, p.errorf(t, "expected value, got %s", t)
+		}
+
+		values = append(values, strings.ToLower(t.value))
+
+		switch sep := p.next(); sep.typ {
+		case tokenComma:
+			continue
+		case tokenRParen:
+			return values, nil
+		default:
+			return nil, p.errorf(sep, "expected \",\" or \")\", got %s", sep)
+		}
+	}
+}
+
+func (p *queryParser) inPredicate(field queryToken, values []string) (queryNode, error) {
+	var value func(*structure.Entry) string
+
+	switch strings.ToLower(field.value) {
+	case "name":
+		value = (*structure.Entry).Name
+	case "path":
+		value = func(e *structure.Entry) string { return e.Path }
+	case "ext":
+		value = entryExt
+	default:
+		return nil, p.errorf(field, "unsupported operator \"in\" for %s", field)
+	}
+
+	return predicate(func(e *structure.Entry) bool {
+		return slices.Contains(values, strings.ToLower(value(e)))
+	}), nil
+}
+
+func (p *queryParser) stringPredicate(
+	op, value queryToken,
+	entryValue func(*structure.Entry) string,
+) (queryNode, error) {
+	switch op.value {
+	case "=", "==", "!=":
+		negate := op.value == "!="
+
+		return predicate(func(e *structure.Entry) bool {
+			return strings.EqualFold(entryValue(e), value.value) != negate
+		}), nil
+	case "~", "!~":
+		re, err := regexp.Compile(value.value)
+		if err != nil {
+			return nil, p.errorf(value, "invalid regular expression")
+		}
+
+		negate := op.value == "!~"
+
+		return predicate(func(e *structure.Entry) bool {
+			return re.MatchString(entryValue(e)) != negate
+		}), nil
+	default:
+		return nil, p.errorf(op, "unsupported operator %s for text", op)
+	}
+}
+
+func (p *queryParser) typePredicate(op, value queryToken) (queryNode, error) {
+	if op.value != "=" && op.value != "==" && op.value != "!=" {
+		return nil, p.errorf(op, "unsupported operator %s for type", op)
+	}
+
+	var isDir bool
+
+	switch strings.ToLower(value.value) {
+	case "dir":
+		isDir = true
+	case "file":
+	default:
+		return nil, p.errorf(value, "expected \"file\" or \"dir\", got %s", value)
+	}
+
+	negate := op.value == "!="
+
+	return predicate(func(e *structure.Entry) bool {
+		return (e.IsDir == isDir) != negate
+	}), nil
+}
+
+func (p *queryParser) numericPredicate(
+	op queryToken,
+	value int64,
+	entryValue func(*structure.Entry) int64,
+) (queryNode, error) {
+	var compare func(a, b int64) bool
+
+	switch op.value {
+	case "=", "==":
+		compare = func(a, b int64) bool { return a == b }
+	case "!=":
+		compare = func(a, b int64) bool { return a != b }
+	case ">":
+		compare = func(a, b int64) bool { return a > b }
+	case ">=":
+		compare = func(a, b int64) bool { return a >= b }
+	case "<":
+		compare = func(a, b int64) bool { return a < b }
+	case "<=":
+		compare = func(a, b int64) bool { return a <= b }
+	default:
+		return nil, p.errorf(op, "unsupported operator %s for numbers", op)
+	}
+
+	return predicate(func(e *structure.Entry) bool {
+		return compare(entryValue(e), value)
+	}), nil
+}
+
+// modTime returns the entry modification time. For directories, it returns the
+// newest modification time within the subtree.
+func (p *queryParser) modTime(e *structure.Entry) int64 {
+	if e.IsDir {
+		return p.modTimes.get(e)
+	}
+
+	return e.ModTime
+}
+
+func (p *queryParser) peek() queryToken {
+	return p.tokens[p.pos]
+}
+
+func (p *queryParser) next() queryToken {
+	t := p.tokens[p.pos]
+
+	if t.typ != tokenEOF {
+		p.pos++
+	}
+
+	return t
+}
+
+func (p *queryParser) errorf(t queryToken, format string, args ...any) error {
+	return &queryError{msg: fmt.Sprintf(format, args...), pos: t.pos}
+}
+
+// entryExt returns the file extension without the leading dot. Directories
+// and files without an extension have an empty extension.
+func entryExt(e *structure.Entry) string {
+	name := e.Name()
+
+	if i := strings.LastIndexByte(name, '.'); !e.IsDir && i != -1 {
+		return name[i+1:]
+	}
+
+	return ""
+}
+
+// parseQuerySize parses the size value with an optional unit, e.g., "1G",
+// "500MB", "1024".
+func parseQuerySize(rawValue string) (int64, error) {
+	value := strings.TrimSuffix(strings.ToUpper(rawValue), "B")
+
+	shift := 0
+
+	if len(value) > 0 {
+		if i := strings.IndexByte("KMGTP", value[len(value)-1]); i != -1 {
+			shift = (i + 1) * 10
+			value = value[:len(value)-1]
+		}
+	}
+
+	size, err := strconv.ParseFloat(value, 64)
+	if err != nil || size < 0 {
+		return 0, fmt.Errorf("invalid size %q", rawValue)
+	}
+
+	return int64(size * float64(int64(1)<<shift)), nil
+}
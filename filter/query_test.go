@@ -0,0 +1,116 @@
+package filter_test
+
+import (
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/crumbyte/noxdir/filter"
+	"github.com/crumbyte/noxdir/structure"
+
+	tea "charm.land/bubbletea/v2"
+	"github.com/charmbracelet/x/ansi"
+	"github.com/stretchr/testify/require"
+)
+
+func TestQueryFilter(t *testing.T) {
+	now := time.Now().Unix()
+	day := int64(24 * 60 * 60)
+
+	movie := structure.NewFileEntry(filepath.Join("root", "movie.MKV"), 2<<30, now-day*60)
+	tmp := structure.NewFileEntry(filepath.Join("root", "tmp_clip.mp4"), 3<<30, now-day*90)
+	notes := structure.NewFileEntry(filepath.Join("root", "notes.txt"), 1024, now-day)
+
+	// the directory is old itself, but contains a recently modified file.
+	dir := structure.NewDirEntry(filepath.Join("root", "backup"), now-day*200)
+	dir.AddChild(structure.NewFileEntry(filepath.Join(dir.Path, "db.dump"), 5<<30, now-day*10))
+	dir.Size = 5 << 30
+
+	entries := []*structure.Entry{movie, tmp, notes, dir}
+
+	qf := filter.NewQueryFilter("", map[string]string{
+		"videos":    "ext in (mp4, mkv)",
+		"big":       "size>=2G",
+		"bigVideos": "@videos @big",
+		"loop":      "@loop",
+	})
+	qf.Toggle()
+
+	tests := []struct {
+		query  string
+		passed []bool
+	}{
+		{``, []bool{true, true, true, true}},
+		{`size>1G and ext in (mp4,mkv) and age>30d and not name~"^tmp"`, []bool{true, false, false, false}},
+		{`size>1G ext=mkv`, []bool{true, false, false, false}},
+		{`type=dir or ext="txt"`, []bool{false, false, true, true}},
+		{`not (type=dir or size<1K)`, []bool{true, true, true, false}},
+		{`size<=1KB`, []bool{false, false, true, false}},
+		{`age<30d`, []bool{false, false, true, true}},
+		{`mtime<` + time.Unix(now-day*30, 0).Format("2006-01-02"), []bool{true, true, false, false}},
+		{`name!~"\.(mkv|mp4)$"`, []bool{true, false, true, true}},
+		{`path~"backup"`, []bool{false, false, false, true}},
+		{`name="NOTES.txt"`, []bool{false, false, true, false}},
+		{`@bigVideos and not @big or type=dir`, []bool{false, false, false, true}},
+		{`@bigVideos`, []bool{true, true, false, false}},
+	}
+
+	for _, tt := range tests {
+		setQuery(qf, tt.query)
+
+		require.Empty(t, errorView(qf), tt.query)
+		require.Equal(t, tt.query != "", qf.Active(), tt.query)
+
+		for i, e := range entries {
+			require.Equal(t, tt.passed[i], qf.Filter(e), "%s: %s", tt.query, e.Path)
+		}
+	}
+
+	invalid := map[string]string{
+		`size>`:              "syntax error at 6: expected value, got end of query",
+		`size>1X`:            `syntax error at 6: invalid size "1X"`,
+		`size 1G`:            `syntax error at 6: expected operator after "size", got "1G"`,
+		`owner=root`:         `syntax error at 1: unknown field "owner"`,
+		`(size>1G`:           `syntax error at 9: expected ")", got end of query`,
+		`name~"unterminated`: "syntax error at 6: unterminated string",
+		`ext in (mp4 mkv)`:   `syntax error at 13: expected "," or ")", got "mkv"`,
+		`size~1G`:            `syntax error at 5: unsupported operator "~" for numbers`,
+		`@unknown`:           "syntax error at 1: unknown query @unknown",
+		`@loop`:              "syntax error at 1: @loop: syntax error at 1: recursive query @loop",
+		`name=a !b`:          `syntax error at 8: unknown operator "!"`,
+	}
+
+	for query, expected := range invalid {
+		setQuery(qf, query)
+
+		require.False(t, qf.Active(), query)
+		require.Contains(t, errorView(qf), expected, query)
+		require.True(t, qf.Filter(movie))
+	}
+
+	qf.Reset()
+
+	require.False(t, qf.Active())
+	require.Empty(t, qf.Value())
+}
+
+func setQuery(qf *filter.QueryFilter, query string) {
+	qf.Update(tea.KeyPressMsg{Code: 'u', Mod: tea.ModCtrl})
+
+	for _, r := range query {
+		qf.Update(tea.KeyPressMsg{Code: r, Text: string(r)})
+	}
+}
+
+// errorView returns the part of the filter view following the input, i.e., the
+// syntax error.
+func errorView(qf *filter.QueryFilter) string {
+	view := qf.View().Content
+
+	if i := strings.Index(view, "syntax error"); i != -1 {
+		return ansi.Strip(view[i:])
+	}
+
+	return ""
+}
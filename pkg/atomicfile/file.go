@@ -0,0 +1,139 @@
+// Package atomicfile provides a crash-safe way of replacing files. The data is
+// written to a temporary file in the target's directory, and the target is
+// replaced only after the temporary file was fully written and synced. Hence,
+// the target file always contains either the previous or the new content, but
+// never a partially written one.
+package atomicfile
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+// TempPrefix defines the name prefix of the temporary files. The files with
+// this prefix can be safely ignored when listing the target's directory.
+const TempPrefix = ".tmp-"
+
+// File wraps a temporary *os.File that replaces the target file on Commit. The
+// File must be either committed or aborted, otherwise the temporary file will
+// be left in the target's directory.
+type File struct {
+	*os.File
+
+	path string
+	done bool
+}
+
+// Create creates a new temporary file for the target path. The target file is
+// not modified until the File is committed.
+func Create(path string, perm os.FileMode) (*File, error) {
+	dir, name := filepath.Split(path)
+	if len(dir) == 0 {
+		dir = "."
+	}
+
+	tmp, err := os.CreateTemp(dir, TempPrefix+name+"-*")
+	if err != nil {
+		return nil, fmt.Errorf("atomicfile: create temp file: %w", err)
+	}
+
+	if err = tmp.Chmod(perm); err != nil {
+		_ = tmp.Close()
+		_ = os.Remove(tmp.Name())
+
+		return nil, fmt.Errorf("atomicfile: chmod: %w", err)
+	}
+
+	return &File{File: tmp, path: path}, nil
+}
+
+// Commit syncs and closes the temporary file and renames it to the target path.
+// If any of these steps fails, the temporary file is removed and the target
+// file remains unchanged.
+func (f *File) Commit() error {
+	if f.done {
+		return errors.New("atomicfile: file already closed")
+	}
+
+	f.done = true
+
+	err := f.Sync()
+	if closeErr := f.File.Close(); err == nil {
+		err = closeErr
+	}
+
+	if err == nil {
+		err = os.Rename(f.Name(), f.path)
+	}
+
+	if err != nil {
+		_ = os.Remove(f.Name())
+
+		return fmt.Errorf("atomicfile: commit: %w", err)
+	}
+
+	return nil
+}
+
+// Abort closes and removes the temporary file without modifying the target
+// file. It does nothing if the File was already committed or aborted; hence,
+// it's safe to defer the Abort call right after the File creation.
+func (f *File) Abort() {
+	if f.done {
+		return
+	}
+
+	f.done = true
+
+	_ = f.File.Close()
+	_ = os.Remove(f.Name())
+}
+
+// WriteFile atomically replaces the file at the provided path with the data.
+func WriteFile(path string, data []byte, perm os.FileMode) error {
+	f, err := Create(path, perm)
+	if err != nil {
+		return err
+	}
+
+	defer f.Abort()
+
+	if _, err = f.Write(data); err != nil {
+		return fmt.Errorf("atomicfile: write: %w", err)
+	}
+
+	return f.Commit()
+}
+
+// RemoveStale removes the temporary files within the directory that were not
+// modified for longer than the provided duration. Such files are left behind
+// by the processes that were terminated before committing or aborting a File.
+func RemoveStale(dir string, age time.Duration) error {
+	dirEntries, err := os.ReadDir(dir)
+	if err != nil {
+		return err
+	}
+
+	var errList []error
+
+	for _, de := range dirEntries {
+		if !strings.HasPrefix(de.Name(), TempPrefix) || !de.Type().IsRegular() {
+			continue
+		}
+
+		fi, infoErr := de.Info()
+		if infoErr != nil || time.Since(fi.ModTime()) < age {
+			continue
+		}
+
+		if err = os.Remove(filepath.Join(dir, de.Name())); err != nil {
+			errList = append(errList, err)
+		}
+	}
+
+	return errors.Join(errList...)
+}
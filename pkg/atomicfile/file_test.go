@@ -0,0 +1,81 @@
+package atomicfile_test
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/crumbyte/noxdir/pkg/atomicfile"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestFile_Commit(t *testing.T) {
+	target := filepath.Join(t.TempDir(), "target")
+
+	require.NoError(t, os.WriteFile(target, []byte("old"), 0600))
+
+	f, err := atomicfile.Create(target, 0600)
+	require.NoError(t, err)
+
+	_, err = f.WriteString("new")
+	require.NoError(t, err)
+
+	// the target must not be modified until commit.
+	requireContent(t, target, "old")
+
+	require.NoError(t, f.Commit())
+	require.Error(t, f.Commit())
+
+	requireContent(t, target, "new")
+	requireDirLen(t, filepath.Dir(target), 1)
+}
+
+func TestFile_Abort(t *testing.T) {
+	target := filepath.Join(t.TempDir(), "target")
+
+	require.NoError(t, atomicfile.WriteFile(target, []byte("old"), 0600))
+
+	f, err := atomicfile.Create(target, 0600)
+	require.NoError(t, err)
+
+	_, err = f.WriteString("new")
+	require.NoError(t, err)
+
+	f.Abort()
+	f.Abort()
+
+	requireContent(t, target, "old")
+	requireDirLen(t, filepath.Dir(target), 1)
+}
+
+func TestRemoveStale(t *testing.T) {
+	dir := t.TempDir()
+
+	f, err := atomicfile.Create(filepath.Join(dir, "target"), 0600)
+	require.NoError(t, err)
+	require.NoError(t, f.Close())
+
+	require.NoError(t, atomicfile.RemoveStale(dir, time.Hour))
+	requireDirLen(t, dir, 1)
+
+	require.NoError(t, atomicfile.RemoveStale(dir, 0))
+	requireDirLen(t, dir, 0)
+}
+
+func requireContent(t *testing.T, path, expected string) {
+	t.Helper()
+
+	content, err := os.ReadFile(path)
+	require.NoError(t, err)
+	require.Equal(t, expected, string(content))
+}
+
+func requireDirLen(t *testing.T, dir string, expected int) {
+	t.Helper()
+
+	dirEntries, err := os.ReadDir(dir)
+	require.NoError(t, err)
+	require.Len(t, dirEntries, expected)
+}
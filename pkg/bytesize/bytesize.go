@@ -0,0 +1,42 @@
+// Package bytesize formats the sizes in bytes using the binary units, so the
+// sizes are presented in the same way across the terminal views and exported
+// reports.
+package bytesize
+
+import (
+	"fmt"
+	"math"
+)
+
+// units contains the binary size units in ascending order.
+var units = []string{
+	"B", "KB", "MB", "GB", "TB", "PB", "EB",
+}
+
+// Split formats the size value rounded to one decimal place and returns it
+// along with the corresponding unit, e.g., "1.50" and "KB".
+func Split(size float64) (string, string) {
+	val := size
+
+	suffix := units[0]
+
+	if size > 0 {
+		e := math.Floor(math.Log(size) / math.Log(1024))
+		suffix = units[min(int(e), len(units)-1)]
+
+		val = math.Floor(size/math.Pow(1024, e)*10+0.5) / 10
+
+		if int(e) > len(units)-1 {
+			val = 1024 * float64(int(e)-(len(units)-1))
+		}
+	}
+
+	return fmt.Sprintf("%.2f", val), suffix
+}
+
+// Format formats the size value along with its unit, e.g., "1.50 KB".
+func Format(size int64) string {
+	val, unit := Split(float64(size))
+
+	return val + " " + unit
+}
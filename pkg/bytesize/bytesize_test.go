@@ -0,0 +1,17 @@
+package bytesize_test
+
+import (
+	"testing"
+
+	"github.com/crumbyte/noxdir/pkg/bytesize"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestFormat(t *testing.T) {
+	require.Equal(t, "0.00 B", bytesize.Format(0))
+	require.Equal(t, "512.00 B", bytesize.Format(512))
+	require.Equal(t, "1.50 KB", bytesize.Format(1536))
+	require.Equal(t, "9.80 KB", bytesize.Format(10000))
+	require.Equal(t, "1.00 GB", bytesize.Format(1<<30))
+}
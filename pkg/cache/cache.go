@@ -9,15 +9,44 @@ import (
 	"io"
 	"os"
 	"path/filepath"
+	"slices"
+	"strconv"
+	"sync"
+	"time"
 
-	"github.com/klauspost/compress/zstd"
+	"github.com/crumbyte/noxdir/pkg/atomicfile"
 )
 
-const cacheDir = "cache"
+const (
+	cacheDir = "cache"
 
-// ErrNoCache defines an error that may occur if the requested cache entry was
-// not found.
-var ErrNoCache = errors.New("cache entry not found")
+	// DefaultMaxSnapshots defines the default number of snapshots stored for a
+	// single cache key.
+	DefaultMaxSnapshots = 10
+
+	// staleTempAge defines the age of the temporary snapshot file after which
+	// it's considered abandoned by the terminated process.
+	staleTempAge = time.Hour
+
+	// layoutFile defines the name of the file containing the version of the
+	// cache directory layout.
+	layoutFile = ".layout"
+
+	// layoutVersion defines the current version of the cache directory layout.
+	// The version 2 stores the snapshots in the key directories instead of the
+	// single files named by the key hash.
+	layoutVersion = "2"
+)
+
+var (
+	// ErrNoCache defines an error that may occur if the requested cache entry
+	// was not found.
+	ErrNoCache = errors.New("cache entry not found")
+
+	// ErrInUse defines an error that may occur if the snapshot being removed
+	// is still open. See Cache.OpenFile.
+	ErrInUse = errors.New("cache snapshot is in use")
+)
 
 // Encoder defines a basic interface for the Encode implementations. The Cache
 // uses the Encoder instance when persisting the state.
@@ -46,30 +75,64 @@ type (
 // Option defines a type for providing configuration options for Cache instance.
 type Option func(*Cache)
 
-// WithCompress enables or disables compression of cache files. By default it's
-// enabled.
-func WithCompress() Option {
+// WithRetention sets the retention policy for the cache snapshots. The maxCount
+// limits the number of snapshots stored per key, and the maxAge discards all
+// snapshots older than the provided duration. Zero values disable the
+// corresponding limit. The most recent snapshot is never discarded.
+func WithRetention(maxCount int, maxAge time.Duration) Option {
 	return func(c *Cache) {
-		c.compressionEnabled = true
+		c.maxSnapshots, c.maxAge = maxCount, maxAge
 	}
 }
 
+// Snapshot describes a single cache snapshot stored for a specific key. Each
+// Cache.Set call creates a new snapshot, so the key can have a history of the
+// persisted states.
+type Snapshot struct {
+	// Created contains the time when the snapshot was persisted.
+	Created time.Time
+
+	// ID contains the unique snapshot identifier within the key.
+	ID string
+
+	// KeyHash contains the hash of the key the snapshot belongs to. Since the
+	// keys are not stored in plain form, the hash is the only way to group the
+	// snapshots by their keys.
+	KeyHash string
+
+	// Path contains the full path to the snapshot file.
+	Path string
+
+	// Size contains the size of the snapshot file on disk.
+	Size int64
+}
+
 // Cache provides a file cache API. It saves and restores an arbitrary data types
 // which can marshaled/unmarshalled as JSON into file cache. The cache entries
 // can be restored by the corresponding key. The cache files will be stored at
 // configured path or default DefaultCachePath will be used.
 type Cache struct {
-	ei                 NewEncoder
-	di                 NewDecoder
-	cachePath          string
-	compressionEnabled bool
+	ei           NewEncoder
+	di           NewDecoder
+	cachePath    string
+	maxAge       time.Duration
+	maxSnapshots int
+	pending      sync.WaitGroup
+	writeMx      sync.Mutex
+
+	// open contains the number of open files for each snapshot path, so the
+	// snapshots are not removed while they are being read.
+	open   map[string]int
+	openMx sync.Mutex
 }
 
 func NewCache(ne NewEncoder, nd NewDecoder, clearCache bool, appPath string, opts ...Option) (*Cache, error) {
 	c := &Cache{
-		ei:        ne,
-		di:        nd,
-		cachePath: filepath.Join(appPath, cacheDir),
+		ei:           ne,
+		di:           nd,
+		cachePath:    filepath.Join(appPath, cacheDir),
+		maxSnapshots: DefaultMaxSnapshots,
+		open:         make(map[string]int),
 	}
 
 	for _, opt := range opts {
@@ -83,91 +146,361 @@ func NewCache(ne NewEncoder, nd NewDecoder, clearCache bool, appPath string, opt
 	return c, nil
 }
 
-// Get retrieves a cache entry by its key and maps data to the provided target.
-// The target instance must be a pointer type and support JSON unmarshalling.
-// If the corresponding cache entry was not found the ErrNoCache error will be
-// returned.
+// Get retrieves the most recent cache snapshot by its key and maps data to the
+// provided target. The target instance must be a pointer type supported by the
+// configured Decoder. If the corresponding cache entry was not found the
+// ErrNoCache error will be returned.
 func (c *Cache) Get(key string, target any) error {
-	var (
-		r                io.Reader
-		compressedReader *zstd.Decoder
-	)
+	snapshots, err := c.Snapshots(key)
+	if err != nil {
+		return err
+	}
 
-	cacheFile, err := os.Open(filepath.Join(c.cachePath, c.keyHash(key)))
+	if len(snapshots) == 0 {
+		return ErrNoCache
+	}
+
+	return c.GetSnapshot(snapshots[0], target)
+}
+
+// GetSnapshot retrieves the specific cache snapshot and maps data to the
+// provided target.
+func (c *Cache) GetSnapshot(s Snapshot, target any) error {
+	r, err := c.Open(s)
+	if err != nil {
+		return err
+	}
+
+	defer func() {
+		_ = r.Close()
+	}()
+
+	return c.di(r).Decode(target)
+}
+
+// Open opens the snapshot file for reading the raw encoded data. It can be used
+// for reading only a part of the snapshot, e.g., its header, without decoding
+// the entire value. The returned reader must be closed by the caller.
+func (c *Cache) Open(s Snapshot) (io.ReadCloser, error) {
+	cacheFile, err := os.Open(s.Path)
 	if err != nil {
 		if errors.Is(err, os.ErrNotExist) {
-			return ErrNoCache
+			return nil, ErrNoCache
 		}
 
-		return err
+		return nil, err
 	}
 
-	r = bufio.NewReaderSize(cacheFile, 5<<20)
+	return &snapshotReader{
+		Reader: bufio.NewReaderSize(cacheFile, 5<<20),
+		file:   cacheFile,
+	}, nil
+}
 
-	defer func() {
-		_ = cacheFile.Close()
-	}()
+// OpenFile opens the snapshot file for reading. Unlike Open, the file is not
+// buffered; hence, it's suitable for the formats that support random access and
+// handle the compression on their own. The snapshot is not removed by the cache
+// until the file is closed, so the file can be read for as long as needed. The
+// returned file must be closed by the caller.
+func (c *Cache) OpenFile(s Snapshot) (*File, error) {
+	c.openMx.Lock()
+	defer c.openMx.Unlock()
 
-	if c.compressionEnabled {
-		compressedReader, err = zstd.NewReader(r)
-		if err != nil {
-			return err
+	f, err := os.Open(s.Path)
+	if err != nil {
+		if errors.Is(err, os.ErrNotExist) {
+			return nil, ErrNoCache
 		}
 
-		r = compressedReader
-		defer compressedReader.Close()
+		return nil, err
 	}
 
-	return c.di(r).Decode(target)
+	c.open[s.Path]++
+
+	return &File{File: f, release: func() { c.release(s.Path) }}, nil
+}
+
+// File represents the snapshot file opened by Cache.OpenFile.
+type File struct {
+	*os.File
+	release func()
+	once    sync.Once
+}
+
+// Close closes the file and allows the cache to remove the snapshot. It's safe
+// to call it multiple times.
+func (f *File) Close() error {
+	err := os.ErrClosed
+
+	f.once.Do(func() {
+		err = f.File.Close()
+		f.release()
+	})
+
+	return err
+}
+
+func (c *Cache) release(path string) {
+	c.openMx.Lock()
+	defer c.openMx.Unlock()
+
+	if c.open[path]--; c.open[path] <= 0 {
+		delete(c.open, path)
+	}
+}
+
+// removeFile removes the snapshot file unless it's open. See OpenFile.
+func (c *Cache) removeFile(path string) error {
+	c.openMx.Lock()
+	defer c.openMx.Unlock()
+
+	if c.open[path] > 0 {
+		return ErrInUse
+	}
+
+	return os.Remove(path)
+}
+
+// snapshotReader wraps the buffered snapshot file reader and closes the file on
+// Close.
+type snapshotReader struct {
+	io.Reader
+	file *os.File
 }
 
+func (sr *snapshotReader) Close() error {
+	return sr.file.Close()
+}
+
+// Set persists the provided value as a new snapshot for the key. After the
+// snapshot was successfully written, the retention policy will be applied to
+// the key's snapshots history.
+//
+// The snapshot is written to a temporary file first and renamed only after it
+// was completely written. Hence, the interrupted write never leaves a corrupted
+// snapshot.
 func (c *Cache) Set(key string, val any) error {
-	var (
-		w                io.Writer
-		compressedWriter *zstd.Encoder
-	)
+	c.writeMx.Lock()
+	defer c.writeMx.Unlock()
 
-	cacheFile, err := c.initEntryCache(key)
-	if err != nil {
+	if err := c.write(key, val); err != nil {
 		return err
 	}
 
-	bufferedWriter := bufio.NewWriterSize(cacheFile, 5<<20)
+	return c.applyRetention(key)
+}
 
-	defer func() {
-		_ = bufferedWriter.Flush()
-		_ = cacheFile.Close()
+// SetAsync persists the provided value in the background in the same way as
+// Set does. The returned channel receives the write result once the write is
+// finished and is closed afterward. The value must not be modified until the
+// write is finished.
+func (c *Cache) SetAsync(key string, val any) chan error {
+	result := make(chan error, 1)
+
+	c.pending.Add(1)
+
+	go func() {
+		defer func() {
+			close(result)
+			c.pending.Done()
+		}()
+
+		result <- c.Set(key, val)
 	}()
 
-	w = bufferedWriter
+	return result
+}
+
+// Wait returns a channel that will be closed when all writes started with
+// SetAsync are finished.
+func (c *Cache) Wait() chan struct{} {
+	done := make(chan struct{})
+
+	go func() {
+		c.pending.Wait()
+		close(done)
+	}()
+
+	return done
+}
+
+// Has checks whether the key has at least one snapshot.
+func (c *Cache) Has(key string) bool {
+	snapshots, err := c.Snapshots(key)
+
+	return err == nil && len(snapshots) != 0
+}
+
+// Snapshots returns a list of all snapshots stored for the key. The list is
+// sorted by the creation time, starting from the most recent snapshot.
+func (c *Cache) Snapshots(key string) ([]Snapshot, error) {
+	return c.readSnapshots(c.keyHash(key))
+}
+
+// List returns a list of all snapshots stored in the cache for all keys. The
+// list is sorted by the creation time, starting from the most recent snapshot.
+// The Snapshot.KeyHash can be used for grouping the snapshots by their keys.
+func (c *Cache) List() ([]Snapshot, error) {
+	dirEntries, err := os.ReadDir(c.cachePath)
+	if err != nil {
+		return nil, fmt.Errorf("read cache dir: %w", err)
+	}
+
+	var snapshots []Snapshot
 
-	if c.compressionEnabled {
-		compressedWriter, err = zstd.NewWriter(w)
-		if err != nil {
-			return err
+	for _, de := range dirEntries {
+		if !de.IsDir() {
+			continue
 		}
 
-		w = compressedWriter
+		keySnapshots, readErr := c.readSnapshots(de.Name())
+		if readErr != nil {
+			return nil, readErr
+		}
 
-		defer func() {
-			_ = compressedWriter.Close()
-		}()
+		snapshots = append(snapshots, keySnapshots...)
 	}
 
-	return c.ei(w).Encode(val)
+	slices.SortFunc(snapshots, func(a, b Snapshot) int {
+		return b.Created.Compare(a.Created)
+	})
+
+	return snapshots, nil
 }
 
-func (c *Cache) SetAsync(key string, val any) (chan struct{}, error) {
-	done := make(chan struct{})
-	defer close(done)
+// Remove removes the provided snapshots. The key's directory is removed as
+// well if it contains no more snapshots. The snapshots that are still open are
+// not removed, and the ErrInUse error is returned for them.
+func (c *Cache) Remove(snapshots ...Snapshot) error {
+	var errList []error
+
+	for _, s := range snapshots {
+		if err := c.removeFile(s.Path); err != nil {
+			errList = append(errList, fmt.Errorf("delete snapshot: %w", err))
 
-	return done, c.Set(key, val)
+			continue
+		}
+
+		// the error is ignored since the directory may still contain other
+		// snapshots.
+		_ = os.Remove(filepath.Dir(s.Path))
+	}
+
+	return errors.Join(errList...)
 }
 
-func (c *Cache) Has(key string) bool {
-	fi, err := os.Lstat(filepath.Join(c.cachePath, c.keyHash(key)))
+func (c *Cache) readSnapshots(keyHash string) ([]Snapshot, error) {
+	keyDir := filepath.Join(c.cachePath, keyHash)
+
+	dirEntries, err := os.ReadDir(keyDir)
+	if err != nil {
+		if errors.Is(err, os.ErrNotExist) {
+			return nil, nil
+		}
+
+		return nil, fmt.Errorf("read snapshots: %w", err)
+	}
+
+	snapshots := make([]Snapshot, 0, len(dirEntries))
+
+	for _, de := range dirEntries {
+		created, parseErr := strconv.ParseInt(de.Name(), 10, 64)
+		if parseErr != nil || !de.Type().IsRegular() {
+			continue
+		}
+
+		fi, infoErr := de.Info()
+		if infoErr != nil {
+			continue
+		}
+
+		snapshots = append(
+			snapshots,
+			Snapshot{
+				Created: time.Unix(0, created),
+				ID:      de.Name(),
+				KeyHash: keyHash,
+				Path:    filepath.Join(keyDir, de.Name()),
+				Size:    fi.Size(),
+			},
+		)
+	}
+
+	slices.SortFunc(snapshots, func(a, b Snapshot) int {
+		return b.Created.Compare(a.Created)
+	})
+
+	return snapshots, nil
+}
+
+// DeleteSnapshot deletes the key's snapshot by its identifier. If the snapshot
+// does not exist, the ErrNoCache error will be returned.
+func (c *Cache) DeleteSnapshot(key, id string) error {
+	snapshots, err := c.Snapshots(key)
+	if err != nil {
+		return err
+	}
+
+	idx := slices.IndexFunc(snapshots, func(s Snapshot) bool {
+		return s.ID == id
+	})
+
+	if idx == -1 {
+		return ErrNoCache
+	}
+
+	return c.Remove(snapshots[idx])
+}
+
+func (c *Cache) write(key string, val any) error {
+	cacheFile, err := c.initEntryCache(key)
+	if err != nil {
+		return err
+	}
+
+	defer cacheFile.Abort()
+
+	bufferedWriter := bufio.NewWriterSize(cacheFile, 5<<20)
+
+	if err = c.ei(bufferedWriter).Encode(val); err != nil {
+		return err
+	}
+
+	if err = bufferedWriter.Flush(); err != nil {
+		return err
+	}
+
+	return cacheFile.Commit()
+}
+
+// applyRetention discards the key's snapshots that do not meet the retention
+// policy. The most recent snapshot is always preserved, and the snapshots that
+// are still open are preserved until the next write. The abandoned temporary
+// files are removed as well.
+func (c *Cache) applyRetention(key string) error {
+	staleErr := atomicfile.RemoveStale(c.keyDir(key), staleTempAge)
+
+	snapshots, err := c.Snapshots(key)
+	if err != nil || len(snapshots) < 2 {
+		return errors.Join(staleErr, err)
+	}
+
+	errList := []error{staleErr}
+
+	for i, s := range snapshots[1:] {
+		tooMany := c.maxSnapshots > 0 && i+1 >= c.maxSnapshots
+		tooOld := c.maxAge > 0 && time.Since(s.Created) > c.maxAge
 
-	return err == nil && fi.Mode().IsRegular()
+		if !tooMany && !tooOld {
+			continue
+		}
+
+		if err = c.removeFile(s.Path); err != nil && !errors.Is(err, ErrInUse) {
+			errList = append(errList, err)
+		}
+	}
+
+	return errors.Join(errList...)
 }
 
 func (c *Cache) initCacheDir(clearCache bool) error {
@@ -181,18 +514,70 @@ func (c *Cache) initCacheDir(clearCache bool) error {
 		return fmt.Errorf("create cache dir: %w", err)
 	}
 
-	return nil
+	return c.removeLegacy()
 }
 
-func (c *Cache) initEntryCache(key string) (io.WriteCloser, error) {
-	cachePath := filepath.Join(c.cachePath, c.keyHash(key))
+// removeLegacy removes the single-file caches created by the previous versions.
+// Such files are stored directly in the cache directory, named by the key hash,
+// and keyed without the scan options, so they cannot be used anymore. The files
+// are removed only once, and the cache directory is marked with the current
+// layout version afterward. Other files in the cache directory are preserved.
+func (c *Cache) removeLegacy() error {
+	layoutPath := filepath.Join(c.cachePath, layoutFile)
 
-	cacheFile, err := os.Create(cachePath)
+	if version, err := os.ReadFile(layoutPath); err == nil && string(version) == layoutVersion {
+		return nil
+	}
+
+	dirEntries, err := os.ReadDir(c.cachePath)
 	if err != nil {
-		return nil, err
+		return fmt.Errorf("read cache dir: %w", err)
 	}
 
-	return cacheFile, nil
+	var errList []error
+
+	for _, de := range dirEntries {
+		if !de.Type().IsRegular() || !isKeyHash(de.Name()) {
+			continue
+		}
+
+		if err = os.Remove(filepath.Join(c.cachePath, de.Name())); err != nil {
+			errList = append(errList, err)
+		}
+	}
+
+	if len(errList) == 0 {
+		errList = append(errList, atomicfile.WriteFile(layoutPath, []byte(layoutVersion), 0640))
+	}
+
+	return errors.Join(errList...)
+}
+
+// isKeyHash checks whether the name is a hex-encoded key hash. See keyHash.
+func isKeyHash(name string) bool {
+	if len(name) != hex.EncodedLen(sha256.Size) {
+		return false
+	}
+
+	_, err := hex.DecodeString(name)
+
+	return err == nil
+}
+
+func (c *Cache) initEntryCache(key string) (*atomicfile.File, error) {
+	keyDir := c.keyDir(key)
+
+	if err := os.MkdirAll(keyDir, 0750); err != nil {
+		return nil, fmt.Errorf("create snapshots dir: %w", err)
+	}
+
+	snapshotID := strconv.FormatInt(time.Now().UnixNano(), 10)
+
+	return atomicfile.Create(filepath.Join(keyDir, snapshotID), 0640)
+}
+
+func (c *Cache) keyDir(key string) string {
+	return filepath.Join(c.cachePath, c.keyHash(key))
 }
 
 func (c *Cache) keyHash(key string) string {
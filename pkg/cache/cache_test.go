@@ -0,0 +1,160 @@
+package cache_test
+
+import (
+	"encoding/json"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/crumbyte/noxdir/pkg/cache"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestCache_Snapshots(t *testing.T) {
+	c := newJSONCache(t, cache.WithRetention(2, 0))
+
+	const key = "key"
+
+	require.False(t, c.Has(key))
+	require.ErrorIs(t, c.Get(key, new(int)), cache.ErrNoCache)
+
+	for i := range 3 {
+		require.NoError(t, c.Set(key, i))
+	}
+
+	snapshots, err := c.Snapshots(key)
+	require.NoError(t, err)
+	require.Len(t, snapshots, 2)
+
+	var latest, previous int
+
+	require.NoError(t, c.Get(key, &latest))
+	require.Equal(t, 2, latest)
+
+	require.NoError(t, c.GetSnapshot(snapshots[1], &previous))
+	require.Equal(t, 1, previous)
+
+	require.NoError(t, c.DeleteSnapshot(key, snapshots[0].ID))
+
+	require.NoError(t, c.Get(key, &latest))
+	require.Equal(t, 1, latest)
+}
+
+func TestCache_SetAsync(t *testing.T) {
+	c := newJSONCache(t)
+
+	const key = "key"
+
+	for i := range 3 {
+		c.SetAsync(key, i)
+	}
+
+	<-c.Wait()
+
+	snapshots, err := c.Snapshots(key)
+	require.NoError(t, err)
+	require.Len(t, snapshots, 3)
+
+	require.NoError(t, <-c.SetAsync(key, 3))
+
+	var latest int
+
+	require.NoError(t, c.Get(key, &latest))
+	require.Equal(t, 3, latest)
+
+	// failed writes must not leave any files behind.
+	require.Error(t, <-c.SetAsync(key, func() {}))
+
+	snapshots, err = c.Snapshots(key)
+	require.NoError(t, err)
+	require.Len(t, snapshots, 4)
+
+	dirEntries, err := os.ReadDir(filepath.Dir(snapshots[0].Path))
+	require.NoError(t, err)
+	require.Len(t, dirEntries, 4)
+}
+
+func TestCache_OpenFile(t *testing.T) {
+	c := newJSONCache(t, cache.WithRetention(1, 0))
+
+	const key = "key"
+
+	require.NoError(t, c.Set(key, 1))
+
+	snapshots, err := c.Snapshots(key)
+	require.NoError(t, err)
+	require.Len(t, snapshots, 1)
+
+	f, err := c.OpenFile(snapshots[0])
+	require.NoError(t, err)
+
+	// the open snapshot is neither discarded by the retention policy nor
+	// removed explicitly.
+	require.NoError(t, c.Set(key, 2))
+	require.ErrorIs(t, c.Remove(snapshots[0]), cache.ErrInUse)
+	require.FileExists(t, snapshots[0].Path)
+
+	require.NoError(t, f.Close())
+	require.ErrorIs(t, f.Close(), os.ErrClosed)
+
+	require.NoError(t, c.Set(key, 3))
+
+	snapshots, err = c.Snapshots(key)
+	require.NoError(t, err)
+	require.Len(t, snapshots, 1)
+
+	_, err = c.OpenFile(cache.Snapshot{Path: filepath.Join(t.TempDir(), "unknown")})
+	require.ErrorIs(t, err, cache.ErrNoCache)
+}
+
+func TestNewCache_RemoveLegacy(t *testing.T) {
+	appPath := t.TempDir()
+	cachePath := filepath.Join(appPath, "cache")
+
+	legacy := filepath.Join(cachePath, strings.Repeat("ab", 32))
+	other := filepath.Join(cachePath, "notes.txt")
+
+	require.NoError(t, os.MkdirAll(cachePath, 0750))
+	require.NoError(t, os.WriteFile(legacy, nil, 0600))
+	require.NoError(t, os.WriteFile(other, nil, 0600))
+
+	newCache := func() {
+		_, err := cache.NewCache(
+			func(w io.Writer) cache.Encoder { return json.NewEncoder(w) },
+			func(r io.Reader) cache.Decoder { return json.NewDecoder(r) },
+			false,
+			appPath,
+		)
+		require.NoError(t, err)
+	}
+
+	newCache()
+
+	require.NoFileExists(t, legacy)
+	require.FileExists(t, other)
+
+	// the legacy files are removed only once.
+	require.NoError(t, os.WriteFile(legacy, nil, 0600))
+
+	newCache()
+
+	require.FileExists(t, legacy)
+}
+
+func newJSONCache(t *testing.T, opts ...cache.Option) *cache.Cache {
+	t.Helper()
+
+	c, err := cache.NewCache(
+		func(w io.Writer) cache.Encoder { return json.NewEncoder(w) },
+		func(r io.Reader) cache.Decoder { return json.NewDecoder(r) },
+		false,
+		t.TempDir(),
+		opts...,
+	)
+	require.NoError(t, err)
+
+	return c
+}
@@ -0,0 +1,56 @@
+// Package filetype resolves the file type category by the file extension. The
+// categories are shared by all views presenting the file types, e.g., the entry
+// icons and the treemap image colors, so the same file always belongs to the
+// same category.
+package filetype
+
+import "strings"
+
+// Category defines the file type category.
+type Category string
+
+const (
+	Code       Category = "code"
+	Image      Category = "image"
+	Video      Category = "video"
+	Audio      Category = "audio"
+	Archive    Category = "archive"
+	Document   Category = "document"
+	Config     Category = "config"
+	Executable Category = "executable"
+	Other      Category = "other"
+)
+
+// Categories contains all categories in the presentation order.
+var Categories = []Category{
+	Code, Image, Video, Audio, Archive, Document, Config, Executable, Other,
+}
+
+// Of resolves the file type category based on the file extension. The
+// extension is case-insensitive and must not contain the leading dot.
+//
+//nolint:cyclop // speed and simplicity over another map resolver
+func Of(ext string) Category {
+	switch strings.ToLower(ext) {
+	case "go", "py", "js", "ts", "java", "cpp", "c", "h", "cs", "rb", "rs",
+		"sh", "php", "html", "css", "swift", "kt":
+		return Code
+	case "jpg", "jpeg", "png", "gif", "bmp", "webp", "tiff", "svg", "heic":
+		return Image
+	case "mp4", "mkv", "avi", "mov", "webm", "m4v", "wmv", "flv":
+		return Video
+	case "mp3", "wav", "flac", "ogg", "aac", "m4a":
+		return Audio
+	case "zip", "rar", "7z", "tar", "gz", "bz2", "xz", "zst", "iso":
+		return Archive
+	case "doc", "docx", "xls", "xlsx", "ppt", "pptx", "pdf", "md", "txt",
+		"log", "odt", "rtf":
+		return Document
+	case "json", "csv", "xml", "env", "yml", "yaml", "ini", "toml":
+		return Config
+	case "exe", "bin", "dll", "app", "so", "dylib", "jar":
+		return Executable
+	default:
+		return Other
+	}
+}
@@ -0,0 +1,16 @@
+package filetype_test
+
+import (
+	"testing"
+
+	"github.com/crumbyte/noxdir/pkg/filetype"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestOf(t *testing.T) {
+	require.Equal(t, filetype.Code, filetype.Of("go"))
+	require.Equal(t, filetype.Video, filetype.Of("MKV"))
+	require.Equal(t, filetype.Archive, filetype.Of("zst"))
+	require.Equal(t, filetype.Other, filetype.Of("unknown"))
+}
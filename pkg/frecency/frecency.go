@@ -0,0 +1,144 @@
+// Package frecency ranks the keys, e.g., the directory paths, by the frequency
+// and the recency of their visits. The visits are stored in a JSON file, so the
+// ranking is preserved across the sessions.
+package frecency
+
+import (
+	"cmp"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"maps"
+	"os"
+	"slices"
+	"time"
+
+	"github.com/crumbyte/noxdir/pkg/atomicfile"
+)
+
+// MaxEntries limits the number of stored keys. The lowest-ranked keys are
+// removed on saving once the limit is exceeded.
+const MaxEntries = 1000
+
+// Entry contains the visits statistics of a single key.
+type Entry struct {
+	Visits    int   `json:"visits"`
+	LastVisit int64 `json:"lastVisit"`
+}
+
+// Store keeps the visits statistics of the keys. The store is not safe for
+// concurrent use.
+type Store struct {
+	entries map[string]Entry
+	path    string
+	dirty   bool
+}
+
+// Load loads the store from the file. The missing file results in an empty
+// store. If the file cannot be parsed, an empty store is returned along with
+// the error, so the caller can decide whether to continue without the history.
+//
+// An empty path creates an in-memory store that is never saved.
+func Load(path string) (*Store, error) {
+	s := &Store{entries: make(map[string]Entry), path: path}
+
+	if len(path) == 0 {
+		return s, nil
+	}
+
+	data, err := os.ReadFile(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return s, nil
+	}
+
+	if err != nil {
+		return s, fmt.Errorf("frecency: read: %w", err)
+	}
+
+	if err = json.Unmarshal(data, &s.entries); err != nil {
+		s.entries = make(map[string]Entry)
+
+		return s, fmt.Errorf("frecency: parse: %s: %w", path, err)
+	}
+
+	return s, nil
+}
+
+// Visit registers a visit of the key at the provided time.
+func (s *Store) Visit(key string, t time.Time) {
+	e := s.entries[key]
+
+	e.Visits++
+	e.LastVisit = t.Unix()
+
+	s.entries[key], s.dirty = e, true
+}
+
+// Score returns the rank of the key. The number of visits is weighted by the
+// time passed since the last visit, so the frequently visited keys that were
+// not visited for a long time are ranked lower than the recent ones. The
+// unknown keys have a zero score.
+func (s *Store) Score(key string, now time.Time) float64 {
+	e, ok := s.entries[key]
+	if !ok {
+		return 0
+	}
+
+	age := now.Sub(time.Unix(e.LastVisit, 0))
+
+	var weight float64
+
+	switch {
+	case age < time.Hour:
+		weight = 4
+	case age < time.Hour*24:
+		weight = 2
+	case age < time.Hour*24*7:
+		weight = 0.5
+	default:
+		weight = 0.25
+	}
+
+	return float64(e.Visits) * weight
+}
+
+// Top returns up to n keys with the highest scores, starting from the highest
+// one.
+func (s *Store) Top(n int, now time.Time) []string {
+	keys := slices.SortedStableFunc(maps.Keys(s.entries), func(a, b string) int {
+		return cmp.Or(
+			cmp.Compare(s.Score(b, now), s.Score(a, now)),
+			cmp.Compare(a, b),
+		)
+	})
+
+	return keys[:min(n, len(keys))]
+}
+
+// Save atomically writes the store to the file if there were any visits since
+// the last save. The lowest-ranked keys exceeding the MaxEntries limit are
+// removed before saving.
+func (s *Store) Save() error {
+	if !s.dirty || len(s.path) == 0 {
+		return nil
+	}
+
+	if len(s.entries) > MaxEntries {
+		for _, key := range s.Top(len(s.entries), time.Now())[MaxEntries:] {
+			delete(s.entries, key)
+		}
+	}
+
+	data, err := json.Marshal(s.entries)
+	if err != nil {
+		return fmt.Errorf("frecency: encode: %w", err)
+	}
+
+	if err = atomicfile.WriteFile(s.path, data, 0600); err != nil {
+		return fmt.Errorf("frecency: %w", err)
+	}
+
+	s.dirty = false
+
+	return nil
+}
@@ -0,0 +1,70 @@
+package frecency_test
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/crumbyte/noxdir/pkg/frecency"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestStore(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "visits.json")
+	now := time.Now()
+
+	s, err := frecency.Load(path)
+	require.NoError(t, err)
+	require.Empty(t, s.Top(10, now))
+
+	// frequent, but old visits are ranked lower than the recent one.
+	for range 3 {
+		s.Visit("/old", now.Add(-time.Hour*24*30))
+	}
+
+	s.Visit("/recent", now)
+	s.Visit("/day", now.Add(-time.Hour*2))
+
+	require.Equal(t, []string{"/recent", "/day", "/old"}, s.Top(10, now))
+	require.Equal(t, []string{"/recent"}, s.Top(1, now))
+	require.InDelta(t, 0.75, s.Score("/old", now), 0.001)
+	require.Zero(t, s.Score("/unknown", now))
+
+	require.NoError(t, s.Save())
+
+	restored, err := frecency.Load(path)
+	require.NoError(t, err)
+	require.Equal(t, s.Top(10, now), restored.Top(10, now))
+
+	require.NoError(t, os.WriteFile(path, []byte("{"), 0600))
+
+	broken, err := frecency.Load(path)
+	require.Error(t, err)
+	require.Empty(t, broken.Top(10, now))
+}
+
+func TestStore_SaveLimit(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "visits.json")
+	now := time.Now()
+
+	s, err := frecency.Load(path)
+	require.NoError(t, err)
+
+	for i := range frecency.MaxEntries + 10 {
+		s.Visit(filepath.Join("/dir", string(rune('a'+i%26)), time.Duration(i).String()), now)
+	}
+
+	s.Visit("/top", now)
+	s.Visit("/top", now)
+
+	require.NoError(t, s.Save())
+
+	restored, err := frecency.Load(path)
+	require.NoError(t, err)
+
+	top := restored.Top(frecency.MaxEntries*2, now)
+	require.Len(t, top, frecency.MaxEntries)
+	require.Equal(t, "/top", top[0])
+}
@@ -0,0 +1,72 @@
+// Package fuzzy implements the fuzzy matching of a text against a pattern. The
+// text matches if it contains all the pattern characters in the same order, but
+// not necessarily adjacent to each other, e.g., "dldoc" matches "Downloads/docs".
+package fuzzy
+
+import (
+	"unicode"
+	"unicode/utf8"
+)
+
+const (
+	matchScore       = 1
+	consecutiveBonus = 5
+	boundaryBonus    = 4
+	maxGapPenalty    = 3
+)
+
+// Match reports whether the text matches the pattern and returns the match
+// score. The higher score means the better match. The matching is
+// case-insensitive.
+//
+// The characters are matched starting from the end of the text, so for the
+// paths, the matches within the last path segments are preferred. The adjacent
+// matches and the matches at the word boundaries, e.g., right after the path
+// separator, get the bonus, while the gaps between the matches and the text
+// after the last match are penalized.
+func Match(pattern, text string) (int, bool) {
+	p := []rune(pattern)
+	if len(p) == 0 {
+		return 0, true
+	}
+
+	score, pi, prevMatch := 0, len(p)-1, -1
+
+	for ti := len(text); ti > 0 && pi >= 0; {
+		r, size := utf8.DecodeLastRuneInString(text[:ti])
+		ti -= size
+
+		if unicode.ToLower(r) != unicode.ToLower(p[pi]) {
+			continue
+		}
+
+		score += matchScore
+
+		switch {
+		case prevMatch == -1:
+			score -= min(utf8.RuneCountInString(text[ti+size:]), maxGapPenalty)
+		case prevMatch == ti+size:
+			score += consecutiveBonus
+		default:
+			score -= min(utf8.RuneCountInString(text[ti+size:prevMatch]), maxGapPenalty)
+		}
+
+		if ti == 0 || isBoundary(text[ti-1]) {
+			score += boundaryBonus
+		}
+
+		prevMatch = ti
+		pi--
+	}
+
+	return score, pi < 0
+}
+
+func isBoundary(b byte) bool {
+	switch b {
+	case '/', '\\', '-', '_', '.', ' ':
+		return true
+	default:
+		return false
+	}
+}
@@ -0,0 +1,39 @@
+package fuzzy_test
+
+import (
+	"testing"
+
+	"github.com/crumbyte/noxdir/pkg/fuzzy"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestMatch(t *testing.T) {
+	tableData := []struct {
+		pattern string
+		text    string
+		matched bool
+	}{
+		{pattern: "", text: "/home/user", matched: true},
+		{pattern: "dldoc", text: "/home/user/Downloads/docs", matched: true},
+		{pattern: "DOCS", text: "/home/user/Downloads/docs", matched: true},
+		{pattern: "ßt", text: "/home/straße/test", matched: true},
+		{pattern: "docsx", text: "/home/user/Downloads/docs", matched: false},
+		{pattern: "sdoc", text: "docs", matched: false},
+	}
+
+	for _, td := range tableData {
+		_, matched := fuzzy.Match(td.pattern, td.text)
+
+		require.Equal(t, td.matched, matched, td.pattern)
+	}
+
+	better, _ := fuzzy.Match("proj", "/home/user/projects")
+	worse, _ := fuzzy.Match("proj", "/home/user/pr/old/j")
+	require.Greater(t, better, worse)
+
+	// the last path segment is preferred.
+	better, _ = fuzzy.Match("src", "/home/user/go/src")
+	worse, _ = fuzzy.Match("src", "/home/user/src/go")
+	require.Greater(t, better, worse)
+}
@@ -0,0 +1,132 @@
+// Package treemap implements the squarified treemap layout. The layout splits
+// a rectangle into smaller rectangles with areas proportional to the provided
+// values, keeping their aspect ratios as close to a square as possible.
+//
+// See: Bruls, Huizing, van Wijk, "Squarified Treemaps".
+package treemap
+
+import (
+	"cmp"
+	"math"
+	"slices"
+)
+
+// Rect defines a rectangle with the top-left corner at X, Y.
+type Rect struct {
+	X float64
+	Y float64
+	W float64
+	H float64
+}
+
+// Area returns the area of the rectangle.
+func (r Rect) Area() float64 {
+	return r.W * r.H
+}
+
+// Squarify lays out the values within the provided rectangle. The result has
+// the same length and order as the values. The values don't have to be sorted,
+// and the non-positive values get an empty rectangle.
+func Squarify(values []float64, r Rect) []Rect {
+	rects := make([]Rect, len(values))
+
+	idx := make([]int, 0, len(values))
+	total := 0.0
+
+	for i, v := range values {
+		if v > 0 {
+			idx = append(idx, i)
+			total += v
+		}
+	}
+
+	if total == 0 || r.Area() <= 0 {
+		return rects
+	}
+
+	slices.SortStableFunc(idx, func(a, b int) int {
+		return cmp.Compare(values[b], values[a])
+	})
+
+	// scale the values to the areas, so they fill the entire rectangle.
+	areas := make([]float64, len(idx))
+	scale := r.Area() / total
+
+	for i, vi := range idx {
+		areas[i] = values[vi] * scale
+	}
+
+	for start := 0; start < len(areas); {
+		side := min(r.W, r.H)
+		end := start + 1
+
+		for end < len(areas) &&
+			worst(areas[start:end+1], side) <= worst(areas[start:end], side) {
+			end++
+		}
+
+		var rowRects []Rect
+
+		rowRects, r = layoutRow(areas[start:end], r)
+
+		for i, rr := range rowRects {
+			rects[idx[start+i]] = rr
+		}
+
+		start = end
+	}
+
+	return rects
+}
+
+// worst returns the highest aspect ratio among the row rectangles if the row is
+// placed along the side with the provided length.
+func worst(row []float64, side float64) float64 {
+	sum, rowMax, rowMin := 0.0, 0.0, math.MaxFloat64
+
+	for _, a := range row {
+		sum += a
+		rowMax = max(rowMax, a)
+		rowMin = min(rowMin, a)
+	}
+
+	sideSq, sumSq := side*side, sum*sum
+
+	return max(sideSq*rowMax/sumSq, sumSq/(sideSq*rowMin))
+}
+
+// layoutRow places the row along the shorter side of the rectangle and returns
+// the row rectangles along with the remaining free rectangle.
+func layoutRow(row []float64, r Rect) ([]Rect, Rect) {
+	sum := 0.0
+
+	for _, a := range row {
+		sum += a
+	}
+
+	rects := make([]Rect, 0, len(row))
+
+	if r.W >= r.H {
+		width := sum / r.H
+		y := r.Y
+
+		for _, a := range row {
+			h := a / width
+			rects = append(rects, Rect{X: r.X, Y: y, W: width, H: h})
+			y += h
+		}
+
+		return rects, Rect{X: r.X + width, Y: r.Y, W: max(r.W-width, 0), H: r.H}
+	}
+
+	height := sum / r.W
+	x := r.X
+
+	for _, a := range row {
+		w := a / height
+		rects = append(rects, Rect{X: x, Y: r.Y, W: w, H: height})
+		x += w
+	}
+
+	return rects, Rect{X: r.X, Y: r.Y + height, W: r.W, H: max(r.H-height, 0)}
+}
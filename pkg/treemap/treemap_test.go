@@ -0,0 +1,56 @@
+package treemap_test
+
+import (
+	"testing"
+
+	"github.com/crumbyte/noxdir/pkg/treemap"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestSquarify(t *testing.T) {
+	bounds := treemap.Rect{W: 6, H: 4}
+
+	// the values from the original paper.
+	values := []float64{6, 6, 4, 3, 2, 2, 1}
+	rects := treemap.Squarify(values, bounds)
+
+	require.Len(t, rects, len(values))
+
+	for i, r := range rects {
+		require.InDelta(t, values[i], r.Area(), 1e-9)
+		require.GreaterOrEqual(t, r.X, bounds.X-1e-9)
+		require.GreaterOrEqual(t, r.Y, bounds.Y-1e-9)
+		require.LessOrEqual(t, r.X+r.W, bounds.X+bounds.W+1e-9)
+		require.LessOrEqual(t, r.Y+r.H, bounds.Y+bounds.H+1e-9)
+
+		for j := range i {
+			require.False(t, overlap(r, rects[j]), "%v overlaps %v", r, rects[j])
+		}
+	}
+
+	// the first two values form the first column.
+	require.InDelta(t, 3, rects[0].W, 1e-9)
+	require.InDelta(t, 2, rects[0].H, 1e-9)
+	require.InDelta(t, 0, rects[1].X, 1e-9)
+	require.InDelta(t, 2, rects[1].Y, 1e-9)
+}
+
+func TestSquarify_Unordered(t *testing.T) {
+	rects := treemap.Squarify([]float64{1, 0, 3, -1}, treemap.Rect{X: 1, Y: 1, W: 2, H: 2})
+
+	require.InDelta(t, 1, rects[0].Area(), 1e-9)
+	require.Zero(t, rects[1])
+	require.InDelta(t, 3, rects[2].Area(), 1e-9)
+	require.Zero(t, rects[3])
+
+	require.Equal(t, []treemap.Rect{{}}, treemap.Squarify([]float64{0}, treemap.Rect{W: 1, H: 1}))
+	require.Equal(t, []treemap.Rect{{}}, treemap.Squarify([]float64{1}, treemap.Rect{}))
+}
+
+func overlap(a, b treemap.Rect) bool {
+	const eps = 1e-9
+
+	return a.X+eps < b.X+b.W && b.X+eps < a.X+a.W &&
+		a.Y+eps < b.Y+b.H && b.Y+eps < a.Y+a.H
+}
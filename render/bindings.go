@@ -20,6 +20,32 @@ type DriveKeyMap struct {
 	SortKeys  key.Binding
 }
 
+// TreemapKeyMap contains the bindings available in the treemap mode. The tiles
+// are selected with the directional keys instead of the table cursor.
+type TreemapKeyMap struct {
+	Up    key.Binding
+	Down  key.Binding
+	Left  key.Binding
+	Right key.Binding
+	Open  key.Binding
+	Back  key.Binding
+}
+
+// FinderKeyMap contains the bindings available in the search and go-to modes.
+// Since the printable keys are used for typing the pattern, only the
+// non-printable keys are bound.
+type FinderKeyMap struct {
+	Up       key.Binding
+	Down     key.Binding
+	PageUp   key.Binding
+	PageDown key.Binding
+	Mark     key.Binding
+	Complete key.Binding
+	Open     key.Binding
+	Delete   key.Binding
+	Close    key.Binding
+}
+
 type DirsKeyMap struct {
 	LevelUp         key.Binding
 	LevelDown       key.Binding
@@ -29,9 +55,22 @@ type DirsKeyMap struct {
 	FilesOnly       key.Binding
 	DirsOnly        key.Binding
 	NameFilter      key.Binding
+	SizeFilter      key.Binding
+	AgeFilter       key.Binding
+	Query           key.Binding
 	ToggleSelectAll key.Binding
 	Chart           key.Binding
 	Diff            key.Binding
+	Compare         key.Binding
+	Trends          key.Binding
+	TrendsWindow    key.Binding
+	Treemap         key.Binding
+	TreeView        key.Binding
+	Expand          key.Binding
+	Search          key.Binding
+	GoTo            key.Binding
+	Bookmark        key.Binding
+	Breadcrumbs     key.Binding
 	Command         key.Binding
 	SortKeys        key.Binding
 	ToggleSelection key.Binding
@@ -42,11 +81,15 @@ type KeyMap struct {
 	NavigationKeyMap table.KeyMap
 	Drive            DriveKeyMap
 	Dirs             DirsKeyMap
+	Treemap          TreemapKeyMap
+	Finder           FinderKeyMap
 	Explore          key.Binding
 	Quit             key.Binding
 	Refresh          key.Binding
 	Help             key.Binding
 	Config           key.Binding
+	Bookmarks        key.Binding
+	PathPrompt       key.Binding
 	style            *Style
 }
 
@@ -70,7 +113,7 @@ func (km *KeyMap) DriveBindings() [][]key.Binding {
 		km.NavigateBindings(),
 		[][]key.Binding{
 			{km.Drive.SortKeys, km.Drive.LevelDown, km.Explore, km.Quit},
-			{km.Refresh, km.Config},
+			{km.Refresh, km.Config, km.Bookmarks, km.PathPrompt},
 		}...,
 	)
 }
@@ -81,8 +124,13 @@ func (km *KeyMap) DirBindings() [][]key.Binding {
 		[][]key.Binding{
 			{km.Dirs.LevelDown, km.Dirs.LevelUp, km.Explore, km.Dirs.ToDrives},
 			{km.Dirs.TopFiles, km.Dirs.TopDirs, km.Dirs.NameFilter, km.Dirs.Chart},
-			{km.Dirs.ToggleSelectAll, km.Dirs.DirsOnly, km.Dirs.FilesOnly, km.Refresh},
+			{km.Dirs.ToggleSelectAll, km.Dirs.DirsOnly, km.Dirs.FilesOnly},
 			{km.Dirs.Diff, km.Config, km.Refresh, km.Dirs.Delete},
+			{km.Dirs.Trends, km.Dirs.TrendsWindow, km.Dirs.Treemap},
+			{km.Dirs.TreeView, km.Dirs.Expand, km.Dirs.Compare},
+			{km.Dirs.Search, km.Dirs.GoTo, km.Dirs.Bookmark, km.Bookmarks},
+			{km.Dirs.SizeFilter, km.Dirs.AgeFilter, km.Dirs.Query},
+			{km.PathPrompt, km.Dirs.Breadcrumbs},
 			{km.Dirs.Command, km.Dirs.SortKeys, km.Dirs.ToggleSelection, km.Quit},
 		}...,
 	)
@@ -209,11 +257,32 @@ func DefaultKeyMap(s *Style) KeyMap {
 					s.Help().Render(" - toggle name filter"),
 				),
 			),
+			SizeFilter: key.NewBinding(
+				key.WithKeys("ctrl+s"),
+				key.WithHelp(
+					s.BindKey().Render("ctrl+s"),
+					s.Help().Render(" - toggle size filter"),
+				),
+			),
+			AgeFilter: key.NewBinding(
+				key.WithKeys("ctrl+o"),
+				key.WithHelp(
+					s.BindKey().Render("ctrl+o"),
+					s.Help().Render(" - toggle age filter"),
+				),
+			),
+			Query: key.NewBinding(
+				key.WithKeys("ctrl+x"),
+				key.WithHelp(
+					s.BindKey().Render("ctrl+x"),
+					s.Help().Render(" - toggle query filter"),
+				),
+			),
 			Chart: key.NewBinding(
 				key.WithKeys("ctrl+w"),
 				key.WithHelp(
 					s.BindKey().Render("ctrl+w"),
-					s.Help().Render(" - usage chart"),
+					s.Help().Render(" - usage chart/sunburst"),
 				),
 			),
 			ToggleSelectAll: key.NewBinding(
@@ -230,6 +299,79 @@ func DefaultKeyMap(s *Style) KeyMap {
 					s.Help().Render(" - toggle diff"),
 				),
 			),
+			Compare: key.NewBinding(
+				key.WithKeys("="),
+				key.WithHelp(
+					s.BindKey().Render("="),
+					s.Help().Render(" - compare directories"),
+				),
+			),
+			Trends: key.NewBinding(
+				key.WithKeys("T"),
+				key.WithHelp(
+					s.BindKey().Render("T"),
+					s.Help().Render(" - toggle trends"),
+				),
+			),
+			TrendsWindow: key.NewBinding(
+				key.WithKeys("w"),
+				key.WithHelp(
+					s.BindKey().Render("w"),
+					s.Help().Render(" - change trends window"),
+				),
+			),
+			Treemap: key.NewBinding(
+				key.WithKeys("ctrl+t"),
+				key.WithHelp(
+					s.BindKey().Render("ctrl+t"),
+					s.Help().Render(" - toggle treemap"),
+				),
+			),
+			TreeView: key.NewBinding(
+				key.WithKeys("t"),
+				key.WithHelp(
+					s.BindKey().Render("t"),
+					s.Help().Render(" - toggle tree view"),
+				),
+			),
+			Expand: key.NewBinding(
+				key.WithKeys("tab"),
+				key.WithHelp(
+					s.BindKey().Render("tab"),
+					s.Help().Render(" - expand/collapse"),
+				),
+			),
+			Search: key.NewBinding(
+				key.WithKeys("ctrl+r"),
+				key.WithHelp(
+					s.BindKey().Render("ctrl+r"),
+					s.Help().Render(" - recursive search"),
+				),
+			),
+			GoTo: key.NewBinding(
+				key.WithKeys("ctrl+g"),
+				key.WithHelp(
+					s.BindKey().Render("ctrl+g"),
+					s.Help().Render(" - go to directory"),
+				),
+			),
+			Bookmark: key.NewBinding(
+				key.WithKeys("m"),
+				key.WithHelp(
+					s.BindKey().Render("m"),
+					s.Help().Render(" - toggle bookmark"),
+				),
+			),
+			Breadcrumbs: key.NewBinding(
+				key.WithKeys(
+					"alt+1", "alt+2", "alt+3", "alt+4", "alt+5",
+					"alt+6", "alt+7", "alt+8", "alt+9",
+				),
+				key.WithHelp(
+					s.BindKey().Render("alt+1…9"),
+					s.Help().Render(" - jump to ancestor"),
+				),
+			),
 			Command: key.NewBinding(
 				key.WithKeys(":"),
 				key.WithHelp(
@@ -245,6 +387,46 @@ func DefaultKeyMap(s *Style) KeyMap {
 				),
 			),
 		},
+		Treemap: TreemapKeyMap{
+			Up:    key.NewBinding(key.WithKeys("up", "k")),
+			Down:  key.NewBinding(key.WithKeys("down", "j")),
+			Left:  key.NewBinding(key.WithKeys("left", "h")),
+			Right: key.NewBinding(key.WithKeys("right", "l")),
+			Open:  key.NewBinding(key.WithKeys("enter")),
+			Back:  key.NewBinding(key.WithKeys("backspace")),
+		},
+		Finder: FinderKeyMap{
+			Up:       key.NewBinding(key.WithKeys("up")),
+			Down:     key.NewBinding(key.WithKeys("down")),
+			PageUp:   key.NewBinding(key.WithKeys("pgup")),
+			PageDown: key.NewBinding(key.WithKeys("pgdown")),
+			Mark: key.NewBinding(
+				key.WithKeys("tab"),
+				key.WithHelp(s.BindKey().Render("tab"), s.Help().Render(" - mark")),
+			),
+			Complete: key.NewBinding(
+				key.WithKeys("tab"),
+				key.WithHelp(s.BindKey().Render("tab"), s.Help().Render(" - complete")),
+			),
+			Open: key.NewBinding(
+				key.WithKeys("enter"),
+				key.WithHelp(
+					s.BindKey().Render("enter"),
+					s.Help().Render(" - go to directory"),
+				),
+			),
+			Delete: key.NewBinding(
+				key.WithKeys("ctrl+d"),
+				key.WithHelp(
+					s.BindKey().Render("ctrl+d"),
+					s.Help().Render(" - delete"),
+				),
+			),
+			Close: key.NewBinding(
+				key.WithKeys("esc"),
+				key.WithHelp(s.BindKey().Render("esc"), s.Help().Render(" - close")),
+			),
+		},
 		Explore: key.NewBinding(
 			key.WithKeys("e"),
 			key.WithHelp(
@@ -280,6 +462,20 @@ func DefaultKeyMap(s *Style) KeyMap {
 				s.Help().Render(" - open config"),
 			),
 		),
+		Bookmarks: key.NewBinding(
+			key.WithKeys("'"),
+			key.WithHelp(
+				s.BindKey().Render("'"),
+				s.Help().Render(" - bookmarks"),
+			),
+		),
+		PathPrompt: key.NewBinding(
+			key.WithKeys("ctrl+l"),
+			key.WithHelp(
+				s.BindKey().Render("ctrl+l"),
+				s.Help().Render(" - type a path"),
+			),
+		),
 	}
 }
 
@@ -296,6 +492,8 @@ func InitKeyMap(b *config.Bindings, s *Style) {
 		Bindings.Quit = Bindings.override(Bindings.Quit, b.Quit)
 		Bindings.Help = Bindings.override(Bindings.Help, b.Help)
 		Bindings.Explore = Bindings.override(Bindings.Explore, b.Explore)
+		Bindings.Bookmarks = Bindings.override(Bindings.Bookmarks, b.Bookmarks)
+		Bindings.PathPrompt = Bindings.override(Bindings.PathPrompt, b.PathPrompt)
 
 		Bindings.Drive.LevelDown = Bindings.override(
 			Bindings.Drive.LevelDown, b.DriveBindings.LevelDown,
@@ -325,12 +523,51 @@ func InitKeyMap(b *config.Bindings, s *Style) {
 		Bindings.Dirs.NameFilter = Bindings.override(
 			Bindings.Dirs.NameFilter, b.DirBindings.NameFilter,
 		)
+		Bindings.Dirs.SizeFilter = Bindings.override(
+			Bindings.Dirs.SizeFilter, b.DirBindings.SizeFilter,
+		)
+		Bindings.Dirs.AgeFilter = Bindings.override(
+			Bindings.Dirs.AgeFilter, b.DirBindings.AgeFilter,
+		)
+		Bindings.Dirs.Query = Bindings.override(
+			Bindings.Dirs.Query, b.DirBindings.Query,
+		)
 		Bindings.Dirs.ToggleSelectAll = Bindings.override(
 			Bindings.Dirs.ToggleSelectAll, b.DirBindings.ToggleSelectAll,
 		)
 		Bindings.Dirs.Diff = Bindings.override(
 			Bindings.Dirs.Diff, b.DirBindings.Diff,
 		)
+		Bindings.Dirs.Compare = Bindings.override(
+			Bindings.Dirs.Compare, b.DirBindings.Compare,
+		)
+		Bindings.Dirs.Trends = Bindings.override(
+			Bindings.Dirs.Trends, b.DirBindings.Trends,
+		)
+		Bindings.Dirs.TrendsWindow = Bindings.override(
+			Bindings.Dirs.TrendsWindow, b.DirBindings.TrendsWindow,
+		)
+		Bindings.Dirs.Treemap = Bindings.override(
+			Bindings.Dirs.Treemap, b.DirBindings.Treemap,
+		)
+		Bindings.Dirs.TreeView = Bindings.override(
+			Bindings.Dirs.TreeView, b.DirBindings.TreeView,
+		)
+		Bindings.Dirs.Expand = Bindings.override(
+			Bindings.Dirs.Expand, b.DirBindings.Expand,
+		)
+		Bindings.Dirs.Search = Bindings.override(
+			Bindings.Dirs.Search, b.DirBindings.Search,
+		)
+		Bindings.Dirs.GoTo = Bindings.override(
+			Bindings.Dirs.GoTo, b.DirBindings.GoTo,
+		)
+		Bindings.Dirs.Bookmark = Bindings.override(
+			Bindings.Dirs.Bookmark, b.DirBindings.Bookmark,
+		)
+		Bindings.Dirs.Breadcrumbs = Bindings.override(
+			Bindings.Dirs.Breadcrumbs, b.DirBindings.Breadcrumbs,
+		)
 		Bindings.Dirs.Chart = Bindings.override(
 			Bindings.Dirs.Chart, b.DirBindings.Chart,
 		)
@@ -0,0 +1,172 @@
+package render
+
+import (
+	"github.com/crumbyte/noxdir/render/table"
+
+	tea "charm.land/bubbletea/v2"
+	"charm.land/lipgloss/v2"
+	"github.com/charmbracelet/bubbles/key"
+)
+
+const bookmarksRatio = 0.6
+
+// BookmarksModel shows the bookmarked directories. The bookmarks that belong to
+// the current tree are shown with their sizes, while the other ones require a
+// scan before opening.
+type BookmarksModel struct {
+	nav     *Navigation
+	table   *table.Model
+	columns []table.Column
+	err     error
+	width   int
+	height  int
+	active  bool
+}
+
+func NewBookmarksModel(n *Navigation) *BookmarksModel {
+	return &BookmarksModel{
+		nav:   n,
+		table: buildTable(),
+		columns: []table.Column{
+			{Title: ""},
+			{Title: ""},
+			{Title: "Bookmark"},
+			{Title: "Size"},
+		},
+	}
+}
+
+func (bm *BookmarksModel) Init() tea.Cmd {
+	return nil
+}
+
+func (bm *BookmarksModel) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
+	switch msg := msg.(type) {
+	case tea.WindowSizeMsg:
+		bm.width = int(float64(msg.Width) * bookmarksRatio)
+		bm.height = int(float64(msg.Height) * bookmarksRatio)
+
+		bm.table.SetWidth(bm.width)
+		bm.updateTableData()
+	case tea.KeyPressMsg:
+		switch {
+		case key.Matches(msg, Bindings.Finder.Up):
+			bm.table.MoveUp(1)
+		case key.Matches(msg, Bindings.Finder.Down):
+			bm.table.MoveDown(1)
+		case key.Matches(msg, Bindings.Finder.Delete):
+			if selected := bm.Selected(); len(selected) != 0 {
+				bm.err = bm.nav.ToggleBookmark(selected)
+				bm.updateTableData()
+			}
+		}
+	}
+
+	return bm, nil
+}
+
+func (bm *BookmarksModel) View() tea.View {
+	messageStyle := lipgloss.NewStyle().
+		Align(lipgloss.Center).
+		Width(bm.width).
+		Bold(true)
+
+	var content string
+
+	switch {
+	case bm.err != nil:
+		content = messageStyle.Render(bm.err.Error())
+	case len(bm.table.Rows()) == 0:
+		content = messageStyle.Render(
+			"No bookmarks yet. Press " + Bindings.Dirs.Bookmark.Help().Key +
+				" to bookmark the current directory.",
+		)
+	default:
+		content = bm.table.View().Content
+	}
+
+	help := lipgloss.NewStyle().Width(bm.width).Align(lipgloss.Center).Render(
+		bm.table.Help.ShortHelpView([]key.Binding{
+			Bindings.Finder.Open,
+			Bindings.Finder.Delete,
+			Bindings.Finder.Close,
+		}),
+	)
+
+	bm.table.SetHeight(bm.height - lipgloss.Height(help))
+
+	return tea.NewView(
+		style.DialogBox().Render(
+			lipgloss.NewStyle().Padding(0, 1, 0, 1).Render(
+				lipgloss.JoinVertical(lipgloss.Top, content, help),
+			),
+		),
+	)
+}
+
+// Open shows the bookmarks list.
+func (bm *BookmarksModel) Open() {
+	bm.active, bm.err = true, nil
+
+	bm.updateTableData()
+	bm.table.SetCursor(0)
+}
+
+// Close hides the bookmarks list.
+func (bm *BookmarksModel) Close() {
+	bm.active = false
+}
+
+// Active tells whether the bookmarks list is shown.
+func (bm *BookmarksModel) Active() bool {
+	return bm.active
+}
+
+// SetError shows the error instead of the bookmarks list until it is reopened.
+func (bm *BookmarksModel) SetError(err error) {
+	bm.err = err
+}
+
+// Selected returns the path of the selected bookmark or an empty string if
+// there are no bookmarks.
+func (bm *BookmarksModel) Selected() string {
+	sr := bm.table.SelectedRow()
+	if sr == nil {
+		return ""
+	}
+
+	return sr.Cols[0]
+}
+
+func (bm *BookmarksModel) updateTableData() {
+	iconWidth, sizeWidth := 5, 15
+
+	bm.columns[0].Width = 0
+	bm.columns[1].Width = iconWidth
+	bm.columns[2].Width = max(bm.width-iconWidth-sizeWidth, 0)
+	bm.columns[3].Width = sizeWidth
+
+	bm.table.SetColumns(bm.columns)
+
+	rows := make([]table.Row, 0, len(bm.nav.Bookmarks()))
+
+	for _, path := range bm.nav.Bookmarks() {
+		size := Faint("not scanned")
+
+		if e := bm.nav.tree.Find(path); e != nil && !bm.nav.OnDrives() {
+			size = FmtSizeColor(e.Size, entrySizeWidth)
+		}
+
+		rows = append(rows, table.Row{
+			Cols: []string{
+				path,
+				"",
+				PrefixWrapString(path, bm.columns[2].Width-2),
+				size,
+			},
+		})
+	}
+
+	bm.table.SetRows(rows)
+	bm.table.SetCursor(bm.table.Cursor())
+}
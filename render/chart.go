@@ -3,6 +3,7 @@ package render
 import (
 	"image/color"
 	"math"
+	"path/filepath"
 	"sort"
 	"strings"
 
@@ -16,8 +17,19 @@ const (
 
 	// chartLabelWidth limits the width of the sector's label.
 	chartLabelWidth = 50
+
+	// SunburstDepth defines the number of the sunburst chart rings.
+	SunburstDepth = 3
+
+	// sunburstLegendSize limits the number of grandchildren in the sunburst
+	// chart legend.
+	sunburstLegendSize = 10
 )
 
+// sunburstGlyphs contains the glyphs used for drawing each sunburst ring,
+// starting from the innermost one.
+var sunburstGlyphs = []string{"ø", "▓", "░"}
+
 var defaultChartColors = []color.Color{
 	lipgloss.Color("#ffbe0b"),
 	lipgloss.Color("#fb5607"),
@@ -36,6 +48,10 @@ var defaultChartColors = []color.Color{
 type SectorInfo struct {
 	Label string
 	Size  int64
+
+	// Children contains the nested sectors that are drawn as the outer rings
+	// of the sunburst chart. The regular chart ignores them.
+	Children []SectorInfo
 }
 
 type chartSector struct {
@@ -45,6 +61,8 @@ type chartSector struct {
 	usage      float64
 	startAngle float64
 	endAngle   float64
+	children   []chartSector
+	merged     bool
 }
 
 // Chart represents a chart renderer instance. It contains the initial chart
@@ -89,10 +107,7 @@ func (c *Chart) Render(totalSize int64, raw []SectorInfo) string {
 
 	for y := range c.height {
 		for x := range c.width / 2 {
-			dx := float64(x - centerX)
-			dy := float64(y-centerY) * c.aspectRatioFix
-
-			dist := math.Sqrt(dx*dx + dy*dy)
+			dist, angle := c.polar(x-centerX, y-centerY)
 
 			if dist > float64(c.radius) {
 				sb.WriteByte(' ')
@@ -100,11 +115,6 @@ func (c *Chart) Render(totalSize int64, raw []SectorInfo) string {
 				continue
 			}
 
-			angle := math.Atan2(dy, dx)
-			if angle < 0 {
-				angle += 2 * math.Pi
-			}
-
 			for _, s := range sectors {
 				if angle >= s.startAngle && angle < s.endAngle {
 					sb.WriteString(
@@ -124,13 +134,101 @@ func (c *Chart) Render(totalSize int64, raw []SectorInfo) string {
 	)
 }
 
+// RenderSunburst renders a multi-level chart window. The inner disc contains
+// the top level sectors, and each next ring contains the nested sectors placed
+// within their parent's angles. The legend lists the largest sectors of the
+// second level, i.e., the grandchildren of the current directory.
+func (c *Chart) RenderSunburst(totalSize int64, raw []SectorInfo) string {
+	sb := strings.Builder{}
+
+	sectors := c.prepareSectors(totalSize, raw)
+	ringWidth := float64(c.radius) / SunburstDepth
+
+	centerX, centerY := c.width/2/2, c.height/2
+
+	for y := range c.height {
+		for x := range c.width / 2 {
+			dist, angle := c.polar(x-centerX, y-centerY)
+
+			if dist > float64(c.radius) {
+				sb.WriteByte(' ')
+
+				continue
+			}
+
+			level := min(int(dist/ringWidth), SunburstDepth-1)
+
+			s, idx := sectorAt(sectors, angle, level)
+			if s == nil {
+				sb.WriteByte(' ')
+
+				continue
+			}
+
+			// the neighbor sectors within the outer rings share the same color,
+			// so every second one is darkened.
+			sectorColor := s.color
+			if level > 0 && idx%2 == 1 {
+				sectorColor = lipgloss.Darken(sectorColor, 0.35)
+			}
+
+			sb.WriteString(
+				lipgloss.NewStyle().
+					Foreground(sectorColor).
+					Render(sunburstGlyphs[level]),
+			)
+		}
+
+		sb.WriteByte('\n')
+	}
+
+	return lipgloss.JoinHorizontal(
+		lipgloss.Center,
+		sb.String(),
+		legend(sunburstLegend(sectors), c.width/2),
+	)
+}
+
+// polar converts the offset from the chart center to the polar coordinates
+// taking into account the aspect ratio fix. The angle is always positive.
+func (c *Chart) polar(x, y int) (float64, float64) {
+	dx := float64(x)
+	dy := float64(y) * c.aspectRatioFix
+
+	angle := math.Atan2(dy, dx)
+	if angle < 0 {
+		angle += 2 * math.Pi
+	}
+
+	return math.Sqrt(dx*dx + dy*dy), angle
+}
+
 func (c *Chart) prepareSectors(totalSize int64, si []SectorInfo) []chartSector {
+	sectors := mergeSectors(totalSize, si)
+
+	for i := range sectors {
+		paintSectors(&sectors[i], c.colors[i])
+	}
+
+	placeSectors(sectors, 0, 2*math.Pi)
+
+	return sectors
+}
+
+// mergeSectors converts the raw sectors info to the chart sectors sorted by
+// size. All sectors that exceed the maxSectors limit are merged into a single
+// "Others" sector. The nested sectors are converted recursively, and their
+// usage is relative to the parent sector.
+func mergeSectors(totalSize int64, si []SectorInfo) []chartSector {
 	sectors := make([]chartSector, 0, len(si))
 
-	others := chartSector{label: "Others"}
+	others := chartSector{label: "Others", merged: true}
 
 	for i, s := range si {
-		usage := float64(s.Size) / float64(totalSize)
+		usage := 0.0
+		if totalSize > 0 {
+			usage = float64(s.Size) / float64(totalSize)
+		}
 
 		if i >= maxSectors {
 			others.size += s.Size
@@ -141,14 +239,15 @@ func (c *Chart) prepareSectors(totalSize int64, si []SectorInfo) []chartSector {
 		sectors = append(
 			sectors,
 			chartSector{
-				label: WrapString(s.Label, chartLabelWidth),
-				size:  s.Size,
-				usage: usage,
+				label:    WrapString(s.Label, chartLabelWidth),
+				size:     s.Size,
+				usage:    usage,
+				children: mergeSectors(s.Size, s.Children),
 			},
 		)
 	}
 
-	if others.size > 0 {
+	if others.size > 0 && totalSize > 0 {
 		others.usage = float64(others.size) / float64(totalSize)
 		sectors = append(sectors, others)
 	}
@@ -157,17 +256,34 @@ func (c *Chart) prepareSectors(totalSize int64, si []SectorInfo) []chartSector {
 		return sectors[i].size > sectors[j].size
 	})
 
-	start := 0.0
+	return sectors
+}
+
+// placeSectors sets the sectors' angles within the provided range according to
+// their usage. The nested sectors are placed within their parent's range.
+func placeSectors(sectors []chartSector, start, end float64) {
+	span := end - start
 
 	for i := range sectors {
-		sectors[i].color = c.colors[i]
 		sectors[i].startAngle = start
-		sectors[i].endAngle = start + sectors[i].usage*2*math.Pi
+		sectors[i].endAngle = start + sectors[i].usage*span
+
+		placeSectors(
+			sectors[i].children, sectors[i].startAngle, sectors[i].endAngle,
+		)
 
 		start = sectors[i].endAngle
 	}
+}
 
-	return sectors
+// paintSectors sets the color of the sector and all its nested sectors, so the
+// sunburst rings keep the color of the top level sector.
+func paintSectors(s *chartSector, c color.Color) {
+	s.color = c
+
+	for i := range s.children {
+		paintSectors(&s.children[i], c)
+	}
 }
 
 func legend(sectors []chartSector, width int) string {
@@ -194,3 +310,48 @@ func legend(sectors []chartSector, width int) string {
 
 	return lipgloss.JoinVertical(lipgloss.Left, l...)
 }
+
+// sectorAt returns the sector at the provided nesting level that contains the
+// angle, along with its index among the siblings. It returns nil if there is
+// no such sector, e.g., the parent sector has no nested sectors.
+func sectorAt(sectors []chartSector, angle float64, level int) (*chartSector, int) {
+	for i := range sectors {
+		s := &sectors[i]
+
+		if angle < s.startAngle || angle >= s.endAngle {
+			continue
+		}
+
+		if level == 0 {
+			return s, i
+		}
+
+		return sectorAt(s.children, angle, level-1)
+	}
+
+	return nil, 0
+}
+
+// sunburstLegend returns the largest second level sectors labeled with the
+// parent sector's name. The merged sectors are skipped, since they don't point
+// to a specific entry.
+func sunburstLegend(sectors []chartSector) []chartSector {
+	var items []chartSector
+
+	for _, parent := range sectors {
+		for _, s := range parent.children {
+			if s.merged {
+				continue
+			}
+
+			s.label = filepath.Join(parent.label, s.label)
+			items = append(items, s)
+		}
+	}
+
+	sort.SliceStable(items, func(i, j int) bool {
+		return items[i].size > items[j].size
+	})
+
+	return items[:min(len(items), sunburstLegendSize)]
+}
@@ -0,0 +1,55 @@
+package render_test
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/crumbyte/noxdir/render"
+
+	"github.com/charmbracelet/x/ansi"
+	"github.com/stretchr/testify/require"
+)
+
+func TestChart_RenderSunburst(t *testing.T) {
+	sectors := []render.SectorInfo{
+		{
+			Label: "src",
+			Size:  600,
+			Children: []render.SectorInfo{
+				{Label: "vendor", Size: 400, Children: []render.SectorInfo{
+					{Label: "lib", Size: 300},
+				}},
+				{Label: "main.go", Size: 200},
+			},
+		},
+		{
+			Label: "docs",
+			Size:  300,
+			Children: []render.SectorInfo{
+				{Label: "img", Size: 250},
+			},
+		},
+		{Label: "README.md", Size: 100},
+	}
+
+	c := render.NewChart(120, 30, 12, 2.2, nil)
+
+	view := ansi.Strip(c.RenderSunburst(1000, sectors))
+
+	// the legend lists the grandchildren sorted by size.
+	vendor := strings.Index(view, "src/vendor")
+	img := strings.Index(view, "docs/img")
+	mainGo := strings.Index(view, "src/main.go")
+
+	require.NotEqual(t, -1, vendor)
+	require.Less(t, vendor, img)
+	require.Less(t, img, mainGo)
+
+	require.NotContains(t, view, "README.md")
+	require.NotContains(t, view, "lib")
+
+	// each ring uses its own glyph.
+	for _, glyph := range []string{"ø", "▓", "░"} {
+		require.Contains(t, view, glyph)
+	}
+}
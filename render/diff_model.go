@@ -1,11 +1,14 @@
 package render
 
 import (
+	"path/filepath"
 	"runtime"
+	"slices"
 	"strconv"
 	"time"
 
 	"github.com/crumbyte/noxdir/drive"
+	"github.com/crumbyte/noxdir/pkg/cache"
 	"github.com/crumbyte/noxdir/render/table"
 	"github.com/crumbyte/noxdir/structure"
 
@@ -14,11 +17,27 @@ import (
 	"github.com/charmbracelet/bubbles/key"
 )
 
+// snapshotTimeFormat defines the layout for rendering the cache snapshot
+// creation time.
+const snapshotTimeFormat = "02 Jan 2006 15:04:05"
+
 type (
 	UpdateDiffState  struct{}
 	DiffScanFinished struct{}
 )
 
+// diffRow represents a single row of the diff table. The delta is a signed size
+// difference used for sorting the rows, and the size is the entry's size in the
+// most recent state where it exists. The path is the entry path shown in the
+// table.
+type diffRow struct {
+	entry  *structure.Entry
+	path   string
+	marker string
+	size   int64
+	delta  int64
+}
+
 type DiffModel struct {
 	nav          *Navigation
 	table        *table.Model
@@ -26,24 +45,32 @@ type DiffModel struct {
 	pg           *PG
 	targetTree   *structure.Tree
 	diff         *structure.Diff
+	snapshots    []cache.Snapshot
+	snapshot     *cache.Snapshot
+	snapshotsTbl *table.Model
+	left         string
+	right        string
 	columns      []table.Column
 	lastError    error
 	height       int
 	width        int
 	ready        bool
+	picking      bool
 }
 
 func NewDiffModel(n *Navigation) *DiffModel {
 	return &DiffModel{
-		nav:   n,
-		table: buildTable(),
-		pg:    &style.CS().ScanProgressBar,
+		nav:          n,
+		table:        buildTable(),
+		snapshotsTbl: buildTable(),
+		pg:           &style.CS().ScanProgressBar,
 		columns: []table.Column{
 			{Title: ""},
 			{Title: ""},
 			{Title: ""},
 			{Title: "Entry Path"},
 			{Title: "Size"},
+			{Title: "Delta"},
 		},
 	}
 }
@@ -62,6 +89,7 @@ func (dm *DiffModel) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 		dm.table.SetHeight(dm.height)
 
 		dm.updateTableData()
+		dm.updateSnapshotsTable()
 
 		return dm, nil
 	case UpdateDiffState:
@@ -77,8 +105,17 @@ func (dm *DiffModel) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 		runtime.GC()
 		dm.updateTableData()
 	case tea.KeyPressMsg:
-		if key.Matches(msg, Bindings.Explore) {
+		if dm.picking {
+			return dm, dm.handleSnapshotKeys(msg)
+		}
+
+		switch {
+		case key.Matches(msg, Bindings.Explore):
 			dm.handleExploreKey()
+		case key.Matches(msg, Bindings.Dirs.LevelUp) && len(dm.snapshots) > 1:
+			dm.picking = true
+
+			return dm, nil
 		}
 	}
 
@@ -98,13 +135,52 @@ func (dm *DiffModel) View() tea.View {
 		Width(dm.width).
 		Bold(true)
 
-	if !dm.ready && dm.lastError == nil {
+	if dm.picking {
+		return dm.viewSnapshots(messageStyle)
+	}
+
+	comparing := dm.comparing()
+
+	if !dm.ready && dm.lastError == nil && comparing {
+		rows = append(
+			rows,
+			messageStyle.Render("Scanning the directories: "+dm.left+", "+dm.right),
+		)
+	}
+
+	if !dm.ready && dm.lastError == nil && !comparing {
 		rows = append(
 			rows,
 			messageStyle.Render("Scanning the delta for: "+dm.nav.Entry().Path),
 		)
 	}
 
+	if dm.ready && comparing && dm.lastError == nil {
+		rows = append(
+			rows,
+			messageStyle.Faint(true).Render(
+				"Compared "+dm.left+" (---) with "+dm.right+" (+++)",
+			),
+		)
+	}
+
+	if dm.ready && dm.snapshot == nil && !comparing && dm.lastError == nil {
+		rows = append(
+			rows,
+			messageStyle.Render("No cached snapshots found for: "+dm.nav.Entry().Path),
+		)
+	}
+
+	if dm.ready && dm.snapshot != nil {
+		rows = append(
+			rows,
+			messageStyle.Faint(true).Render(
+				"Compared with the snapshot from "+
+					dm.snapshot.Created.Format(snapshotTimeFormat),
+			),
+		)
+	}
+
 	if dm.ready && hasDiff {
 		total := dm.viewStats()
 		dm.table.SetHeight(dm.height - lipgloss.Height(total))
@@ -112,13 +188,17 @@ func (dm *DiffModel) View() tea.View {
 		rows = append(rows, dm.table.View().Content, total)
 	}
 
-	if dm.ready && !hasDiff {
+	if dm.ready && dm.snapshot != nil && !hasDiff {
 		rows = append(
 			rows,
 			messageStyle.Render("No delta found for: "+dm.nav.Entry().Path),
 		)
 	}
 
+	if dm.ready && comparing && dm.lastError == nil && !hasDiff {
+		rows = append(rows, messageStyle.Render("No differences found"))
+	}
+
 	if dm.lastError != nil {
 		rows = append(
 			rows,
@@ -137,19 +217,84 @@ func (dm *DiffModel) View() tea.View {
 	)
 }
 
+// Run starts the diff calculation for the current active entry. If there are
+// multiple cache snapshots available, the user will be asked to pick the one to
+// compare with first. Otherwise, the only available snapshot will be used.
 func (dm *DiffModel) Run(width, height int) {
-	dm.height = int(float64(height) * 0.7)
-	dm.width = int(float64(width) * 0.7)
+	dm.resize(width, height)
 
-	dm.table.SetWidth(dm.width)
-	dm.table.SetHeight(dm.height)
+	dm.Close()
+
+	dm.left, dm.right = "", ""
+	dm.snapshots, dm.lastError = dm.nav.Snapshots()
+	dm.picking = len(dm.snapshots) > 1
+
+	if dm.picking {
+		dm.updateSnapshotsTable()
 
-	dm.diff, dm.targetTree = nil, nil
+		return
+	}
+
+	if len(dm.snapshots) == 0 {
+		dm.ready = true
+
+		return
+	}
+
+	dm.runDiff(dm.snapshots[0])
+}
+
+// Compare starts the comparison of two arbitrary directories. Both directories
+// are scanned, and their entries are matched by the relative paths. The entries
+// existing only in the left directory are shown as removed, the entries
+// existing only in the right directory are shown as added, and the entries with
+// different sizes are shown as changed.
+func (dm *DiffModel) Compare(left, right string, width, height int) {
+	dm.resize(width, height)
+
+	dm.Close()
+
+	dm.left, dm.right = left, right
+	dm.lastError = nil
+	dm.snapshots, dm.picking, dm.ready = nil, false, false
+
+	dm.table.SetRows(nil)
+
+	dm.await(func() {
+		_, dm.targetTree, dm.diff, dm.lastError = dm.nav.Compare(left, right)
+	})
+}
+
+// Close drops the calculated diff and closes the snapshot tree it was
+// calculated with.
+func (dm *DiffModel) Close() {
+	if dm.targetTree != nil {
+		_ = dm.targetTree.Close()
+	}
+
+	dm.diff, dm.targetTree, dm.snapshot = nil, nil, nil
+}
+
+func (dm *DiffModel) runDiff(s cache.Snapshot) {
+	dm.Close()
+
+	dm.lastError = nil
+	dm.snapshot, dm.picking, dm.ready = &s, false, false
 
+	dm.table.SetRows(nil)
+
+	dm.await(func() {
+		dm.targetTree, dm.diff, dm.lastError = dm.nav.Diff(s)
+	})
+}
+
+// await runs the diff calculation in the background and sends the state
+// updates until it's done.
+func (dm *DiffModel) await(calculate func()) {
 	done := make(chan struct{})
 
 	go func() {
-		dm.targetTree, dm.diff, dm.lastError = dm.nav.Diff()
+		calculate()
 
 		close(done)
 	}()
@@ -179,6 +324,85 @@ func (dm *DiffModel) Run(width, height int) {
 	}()
 }
 
+// comparing tells whether two arbitrary directories are compared rather than
+// the current entry and its snapshot.
+func (dm *DiffModel) comparing() bool {
+	return len(dm.right) != 0
+}
+
+func (dm *DiffModel) resize(width, height int) {
+	dm.height = int(float64(height) * 0.7)
+	dm.width = int(float64(width) * 0.7)
+
+	dm.table.SetWidth(dm.width)
+	dm.table.SetHeight(dm.height)
+}
+
+func (dm *DiffModel) handleSnapshotKeys(msg tea.KeyPressMsg) tea.Cmd {
+	if key.Matches(msg, Bindings.Dirs.LevelDown) {
+		cursor := dm.snapshotsTbl.Cursor()
+
+		if cursor >= 0 && cursor < len(dm.snapshots) {
+			dm.runDiff(dm.snapshots[cursor])
+		}
+
+		return nil
+	}
+
+	t, _ := dm.snapshotsTbl.Update(msg)
+	dm.snapshotsTbl = &t
+
+	return nil
+}
+
+func (dm *DiffModel) updateSnapshotsTable() {
+	if len(dm.snapshots) == 0 {
+		return
+	}
+
+	colWidth := dm.width / 3
+
+	dm.snapshotsTbl.SetColumns([]table.Column{
+		{Title: "Snapshot", Width: colWidth},
+		{Title: "Age", Width: colWidth},
+		{Title: "Size on disk", Width: dm.width - colWidth*2},
+	})
+
+	rows := make([]table.Row, 0, len(dm.snapshots))
+
+	for _, s := range dm.snapshots {
+		rows = append(
+			rows,
+			table.Row{
+				Cols: []string{
+					s.Created.Format(snapshotTimeFormat),
+					Faint(time.Since(s.Created).Truncate(time.Second).String()),
+					FmtSize(s.Size, entrySizeWidth),
+				},
+			},
+		)
+	}
+
+	dm.snapshotsTbl.SetRows(rows)
+	dm.snapshotsTbl.SetHeight(min(len(rows)+1, dm.height-2))
+}
+
+func (dm *DiffModel) viewSnapshots(messageStyle lipgloss.Style) tea.View {
+	return tea.NewView(
+		style.DialogBox().Render(
+			lipgloss.NewStyle().Padding(0, 1, 0, 1).Render(
+				lipgloss.JoinVertical(
+					lipgloss.Top,
+					messageStyle.Render(
+						"Select the snapshot to compare with: "+dm.nav.Entry().Path,
+					),
+					dm.snapshotsTbl.View().Content,
+				),
+			),
+		),
+	)
+}
+
 func (dm *DiffModel) handleExploreKey() bool {
 	sr := dm.table.SelectedRow()
 	if sr != nil && len(sr.Cols) < 2 {
@@ -193,23 +417,17 @@ func (dm *DiffModel) updateTableData() {
 		return
 	}
 
-	removedIcon := lipgloss.NewStyle().
-		Foreground(lipgloss.Color(style.CS().DiffAddedMarker)).
-		Render("---  ")
-
-	addedIcon := lipgloss.NewStyle().
-		Foreground(lipgloss.Color(style.CS().DiffRemovedMarker)).
-		Render("+++  ")
-
 	iconWidth := 5
 	signWidth := 5
-	nameWidth := dm.width - iconWidth - signWidth - 20
+	sizeWidth := 15
+	nameWidth := dm.width - iconWidth - signWidth - sizeWidth*2
 
 	dm.columns[0].Width = signWidth
 	dm.columns[1].Width = iconWidth
 	dm.columns[2].Width = 0
 	dm.columns[3].Width = nameWidth
-	dm.columns[4].Width = 20
+	dm.columns[4].Width = sizeWidth
+	dm.columns[5].Width = sizeWidth
 
 	dm.table.SetColumns(dm.columns)
 
@@ -217,43 +435,116 @@ func (dm *DiffModel) updateTableData() {
 		return
 	}
 
-	rows := make([]table.Row, 0, len(dm.nav.Entry().Child))
-	dm.nav.Entry().SortedChild(structure.SortSize, true)
+	diffRows := dm.diffRows()
+	rows := make([]table.Row, 0, len(diffRows))
 
-	for _, child := range dm.diff.Added {
+	for _, dr := range diffRows {
 		rows = append(
 			rows,
 			table.Row{
 				Cols: []string{
-					addedIcon,
-					EntryIcon(child),
-					child.Path,
-					WrapString(child.Path, nameWidth),
-					FmtSize(child.Size, entrySizeWidth),
+					dr.marker,
+					EntryIcon(dr.entry),
+					dr.entry.Path,
+					WrapString(dr.path, nameWidth),
+					FmtSize(dr.size, entrySizeWidth),
+					FmtSignedSize(dr.delta, entrySizeWidth),
 				},
 			},
 		)
 	}
 
+	dm.table.SetRows(rows)
+	dm.table.SetCursor(0)
+
+	dm.lastRootPath = dm.targetTree.Root().Path
+}
+
+// diffRows combines the added, removed, and changed entries into a single list
+// of rows sorted by the absolute size delta, so the biggest changes are always
+// shown first regardless of their type.
+func (dm *DiffModel) diffRows() []diffRow {
+	markerStyle := func(c string) lipgloss.Style {
+		return lipgloss.NewStyle().Foreground(lipgloss.Color(c))
+	}
+
+	removedIcon := markerStyle(style.CS().DiffAddedMarker).Render("---  ")
+	addedIcon := markerStyle(style.CS().DiffRemovedMarker).Render("+++  ")
+	shrunkIcon := markerStyle(style.CS().DiffAddedMarker).Render(" ▼   ")
+	grownIcon := markerStyle(style.CS().DiffRemovedMarker).Render(" ▲   ")
+
+	rows := make(
+		[]diffRow,
+		0,
+		len(dm.diff.Added)+len(dm.diff.Removed)+len(dm.diff.Changed),
+	)
+
+	for _, child := range dm.diff.Added {
+		rows = append(
+			rows,
+			diffRow{
+				entry:  child,
+				path:   dm.displayPath(dm.right, child),
+				marker: addedIcon,
+				size:   child.Size,
+				delta:  child.Size,
+			},
+		)
+	}
+
 	for _, child := range dm.diff.Removed {
 		rows = append(
 			rows,
-			table.Row{
-				Cols: []string{
-					removedIcon,
-					EntryIcon(child),
-					child.Path,
-					WrapString(child.Path, nameWidth),
-					FmtSize(child.Size, entrySizeWidth),
-				},
+			diffRow{
+				entry:  child,
+				path:   dm.displayPath(dm.left, child),
+				marker: removedIcon,
+				size:   child.Size,
+				delta:  -child.Size,
 			},
 		)
 	}
 
-	dm.table.SetRows(rows)
-	dm.table.SetCursor(0)
+	for _, change := range dm.diff.Changed {
+		marker := grownIcon
 
-	dm.lastRootPath = dm.targetTree.Root().Path
+		if change.Delta() < 0 {
+			marker = shrunkIcon
+		}
+
+		rows = append(
+			rows,
+			diffRow{
+				entry:  change.New,
+				path:   dm.displayPath(dm.right, change.New),
+				marker: marker,
+				size:   change.New.Size,
+				delta:  change.Delta(),
+			},
+		)
+	}
+
+	slices.SortStableFunc(rows, func(a, b diffRow) int {
+		return structure.CompareDelta(a.delta, b.delta)
+	})
+
+	return rows
+}
+
+// displayPath returns the entry path shown in the table. While comparing the
+// directories, the path is relative to the compared directory containing the
+// entry.
+func (dm *DiffModel) displayPath(root string, e *structure.Entry) string {
+	if !dm.comparing() {
+		return e.Path
+	}
+
+	rel, err := filepath.Rel(root, e.Path)
+	if err != nil {
+		return e.Path
+	}
+
+	return rel
 }
 
 func (dm *DiffModel) viewStats() string {
@@ -263,14 +554,23 @@ func (dm *DiffModel) viewStats() string {
 
 	addedDirs, addedFiles, addedSize := structure.DiffStats(dm.diff.Added)
 	remDirs, remFiles, remSize := structure.DiffStats(dm.diff.Removed)
+	grown, shrunk := structure.ChangeStats(dm.diff.Changed)
 
 	statStyle := lipgloss.NewStyle().Bold(true).Underline(true)
 	addedStat := statStyle.Foreground(lipgloss.Color("#FF303E"))
 	removedStat := statStyle.Foreground(lipgloss.Color("#06923E"))
 
+	addedLabel, removedLabel := "ADDED: ", "REMOVED: "
+	grownLabel, shrunkLabel := "CHANGED: grown - ", ", shrunk - "
+
+	if dm.comparing() {
+		addedLabel, removedLabel = "ONLY IN RIGHT: ", "ONLY IN LEFT: "
+		grownLabel, shrunkLabel = "SIZE MISMATCH: larger in right - ", ", smaller in right - "
+	}
+
 	addedStats := lipgloss.JoinHorizontal(
 		lipgloss.Center,
-		"ADDED: ",
+		addedLabel,
 		addedStat.Render(FmtSize(addedSize, 0)),
 		", directories - ",
 		addedStat.Render(strconv.FormatUint(addedDirs, 10)),
@@ -280,7 +580,7 @@ func (dm *DiffModel) viewStats() string {
 
 	removedStats := lipgloss.JoinHorizontal(
 		lipgloss.Center,
-		"REMOVED: ",
+		removedLabel,
 		removedStat.Render(FmtSize(remSize, 0)),
 		", directories - ",
 		removedStat.Render(strconv.FormatUint(remDirs, 10)),
@@ -288,11 +588,27 @@ func (dm *DiffModel) viewStats() string {
 		removedStat.Render(strconv.FormatUint(remFiles, 10)),
 	)
 
+	changedStats := lipgloss.JoinHorizontal(
+		lipgloss.Center,
+		grownLabel,
+		addedStat.Render(FmtSignedSize(grown, 0)),
+		shrunkLabel,
+		removedStat.Render(FmtSignedSize(-shrunk, 0)),
+	)
+
 	return lipgloss.NewStyle().Width(dm.width).
 		MarginTop(0).
 		BorderStyle(lipgloss.NormalBorder()).
 		BorderForeground(lipgloss.Color("240")).
 		BorderTop(true).
 		Align(lipgloss.Center).
-		Render(lipgloss.JoinHorizontal(lipgloss.Center, addedStats, " | ", removedStats))
+		Render(
+			lipgloss.JoinVertical(
+				lipgloss.Center,
+				lipgloss.JoinHorizontal(
+					lipgloss.Center, addedStats, " | ", removedStats,
+				),
+				changedStats,
+			),
+		)
 }
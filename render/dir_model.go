@@ -1,13 +1,18 @@
 package render
 
 import (
+	"cmp"
 	"fmt"
+	"os"
+	"path/filepath"
 	"runtime"
 	"slices"
 	"strconv"
+	"strings"
 	"time"
 
 	"github.com/crumbyte/noxdir/command"
+	"github.com/crumbyte/noxdir/command/snapshot"
 	"github.com/crumbyte/noxdir/drive"
 	"github.com/crumbyte/noxdir/filter"
 	"github.com/crumbyte/noxdir/render/table"
@@ -16,6 +21,7 @@ import (
 	tea "charm.land/bubbletea/v2"
 	"charm.land/lipgloss/v2"
 	"github.com/charmbracelet/bubbles/key"
+	"github.com/charmbracelet/x/ansi"
 )
 
 const (
@@ -24,6 +30,17 @@ const (
 	dirsTableRatio      = 0.7
 )
 
+// chartMode defines which usage chart is shown over the directory table. The
+// chart binding cycles through the modes.
+type chartMode int
+
+const (
+	chartHidden chartMode = iota
+	chartPie
+	chartSunburst
+	chartModesCount
+)
+
 // Mode defines a custom type that represents the current view mode. Depending
 // on the current Mode value, the UI behavior can vary.
 type Mode string
@@ -53,6 +70,26 @@ const (
 	// CMD mode represents the model state when the application awaits for the
 	// internal command.Model to be executed.
 	CMD Mode = "CMD"
+
+	// TRENDS mode represents the model state while showing the directories
+	// growth trends based on the cache snapshots. The UI behavior is limited in
+	// this mode.
+	TRENDS Mode = "TRENDS"
+
+	// TREEMAP mode represents the model state while showing the treemap of the
+	// current directory. The tiles navigation replaces the table navigation in
+	// this mode.
+	TREEMAP Mode = "TREEMAP"
+
+	// SEARCH mode represents the model state while searching the entries within
+	// the entire subtree of the current directory. The typed keys are used for
+	// the search pattern in this mode.
+	SEARCH Mode = "SEARCH"
+
+	// GOTO mode represents the model state while choosing the directory to go
+	// to in the fuzzy finder. The typed keys are used for the pattern in this
+	// mode.
+	GOTO Mode = "GOTO"
 )
 
 type summaryInfo struct {
@@ -85,6 +122,10 @@ type DirModel struct {
 	topEntries      *TopEntries
 	deleteDialog    *DeleteDialogModel
 	diff            *DiffModel
+	trends          *TrendsModel
+	treemap         *TreemapModel
+	search          *SearchModel
+	goTo            *GoToModel
 	nav             *Navigation
 	scanPG          *PG
 	filters         filter.FiltersList
@@ -93,18 +134,31 @@ type DirModel struct {
 	topStatusBar    *StatusBar
 	bottomStatusBar *StatusBar
 	summaryInfo     *summaryInfo
+	expanded        map[string]struct{}
 	sortState       SortState
 	view            tea.View
 	height          int
 	width           int
+	inputFilter     filter.ID
 	fullHelp        bool
-	showChart       bool
+	treeView        bool
+	chart           chartMode
+
+	// crumbs contains the breadcrumbs shown in the bottom status bar, and
+	// crumbsX and crumbsY contain their position on the screen, so the
+	// clicked ancestor can be resolved.
+	crumbs  string
+	crumbsX int
+	crumbsY int
 }
 
 func NewDirModel(nav *Navigation, filters ...filter.EntryFilter) *DirModel {
 	defaultFilters := append(
 		[]filter.EntryFilter{
 			filter.NewNameFilter(style.CS().FilterText),
+			filter.NewSizeFilter(style.CS().FilterText),
+			filter.NewAgeFilter(style.CS().FilterText),
+			filter.NewQueryFilter(style.CS().FilterText, nav.Settings().Queries),
 			&filter.DirsFilter{},
 			&filter.FilesFilter{},
 		},
@@ -150,15 +204,21 @@ func NewDirModel(nav *Navigation, filters ...filter.EntryFilter) *DirModel {
 		previewTable:    buildTable(),
 		topEntries:      NewTopEntries(),
 		diff:            NewDiffModel(nav),
+		trends:          NewTrendsModel(nav),
+		treemap:         NewTreemapModel(nav),
+		search:          NewSearchModel(nav, style.CS().FilterText),
+		goTo:            NewGoToModel(nav, style.CS().FilterText),
 		topStatusBar:    NewStatusBar(),
 		bottomStatusBar: NewStatusBar(),
 		cmd: command.NewModel(
 			func() { go teaProg.Send(EnqueueRefresh{Mode: CMD}) },
+			snapshot.NewSnapshotCmd(nav.tree),
 		),
 		errPopup: NewPopupModel(
 			ErrorTitle, time.Second*10, PopupDefaultErrorStyle(),
 		),
 		summaryInfo: &summaryInfo{},
+		expanded:    make(map[string]struct{}),
 		sortState:   SortState{Key: structure.SortSize, Desc: true},
 		mode:        PENDING,
 		nav:         nav,
@@ -196,6 +256,15 @@ func (dm *DirModel) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 	case EntryDeleted:
 		dm.mode, dm.deleteDialog = READY, nil
 
+		// some entries might be deleted even if the deletion failed.
+		dm.filters.Invalidate()
+		dm.goTo.Invalidate()
+
+		// the deletion was started from the search results.
+		if dm.search.Active() {
+			dm.mode = SEARCH
+		}
+
 		if msg.Err != nil {
 			dm.errPopup.Show(msg.Err.Error())
 
@@ -206,11 +275,23 @@ func (dm *DirModel) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 			dm.dirsTable.ResetMarked()
 
 			dm.updateTableData()
+
+			if dm.search.Active() {
+				dm.search.Restart()
+			}
 		}
+	case SearchStep:
+		dm.search.Update(msg)
+	case GoToStep:
+		dm.goTo.Update(msg)
+	case GoToDir:
+		dm.changeDir(msg.Path)
 	case UpdateDirState:
 		dm.mode = PENDING
 		runtime.GC()
 		dm.nav.tree.CalculateSize()
+		dm.filters.Invalidate()
+		dm.goTo.Invalidate()
 
 		dm.updateTableData()
 	case ScanFinished:
@@ -218,6 +299,8 @@ func (dm *DirModel) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 
 		runtime.GC()
 		dm.nav.tree.CalculateSize()
+		dm.filters.Invalidate()
+		dm.goTo.Invalidate()
 		dm.updateTableData()
 
 		dm.dirsTable.ResetMarked()
@@ -233,12 +316,20 @@ func (dm *DirModel) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 		if dm.nav.OnDrives() || dm.handleKeyBindings(msg) {
 			return dm, nil
 		}
+	case tea.MouseClickMsg:
+		if !dm.nav.OnDrives() && dm.mode == READY {
+			dm.handleBreadcrumbsClick(msg.Mouse())
+		}
 	}
 
 	if dm.mode == DIFF {
 		dm.diff.Update(msg)
 	}
 
+	if dm.mode == TRENDS {
+		dm.trends.Update(msg)
+	}
+
 	if dm.nav.OnDrives() {
 		return dm, nil
 	}
@@ -321,6 +412,9 @@ func (dm *DirModel) View() tea.View {
 
 	bg := lipgloss.JoinVertical(lipgloss.Top, rows...)
 
+	// the bottom status bar has a single line margin on top.
+	dm.crumbsY = h(bg) - h(keyBindings) - h(bsb) + 1
+
 	return dm.renderOverlay(&bg, h(bg)-h(keyBindings)-h(bsb))
 }
 
@@ -329,7 +423,7 @@ func (dm *DirModel) Clear() {
 }
 
 func (dm *DirModel) renderOverlay(layout *string, layoutHeight int) tea.View {
-	if dm.showChart {
+	if dm.chart != chartHidden {
 		chart := dm.viewChart()
 
 		*layout = Overlay(
@@ -359,6 +453,38 @@ func (dm *DirModel) renderOverlay(layout *string, layoutHeight int) tea.View {
 		return dm.view
 	}
 
+	if dm.mode == TREEMAP {
+		dm.view.SetContent(OverlayCenter(
+			dm.width, dm.height, *layout, dm.treemap.View().Content,
+		))
+
+		return dm.view
+	}
+
+	if dm.mode == GOTO {
+		dm.view.SetContent(OverlayCenter(
+			dm.width, dm.height, *layout, dm.goTo.View().Content,
+		))
+
+		return dm.view
+	}
+
+	if dm.mode == SEARCH {
+		dm.view.SetContent(OverlayCenter(
+			dm.width, dm.height, *layout, dm.search.View().Content,
+		))
+
+		return dm.view
+	}
+
+	if dm.mode == TRENDS {
+		dm.view.SetContent(OverlayCenter(
+			dm.width, dm.height, *layout, dm.trends.View().Content,
+		))
+
+		return dm.view
+	}
+
 	if dm.mode == DELETE {
 		dm.view.SetContent(OverlayCenter(
 			dm.width, dm.height, *layout, dm.deleteDialog.View().Content,
@@ -378,7 +504,14 @@ func (dm *DirModel) handleKeyBindings(msg tea.KeyPressMsg) bool {
 	}
 
 	handlers := []func(tea.KeyPressMsg) bool{
-		dm.handleFilter, dm.handleDiff, dm.handleDeletion, dm.handleCmd,
+		dm.handleFilter,
+		dm.handleDiff,
+		dm.handleTrends,
+		dm.handleTreemap,
+		dm.handleSearch,
+		dm.handleGoTo,
+		dm.handleDeletion,
+		dm.handleCmd,
 	}
 
 	for _, handler := range handlers {
@@ -393,7 +526,7 @@ func (dm *DirModel) handleKeyBindings(msg tea.KeyPressMsg) bool {
 
 		return true
 	case key.Matches(msg, Bindings.Dirs.Chart):
-		dm.showChart = !dm.showChart
+		dm.chart = (dm.chart + 1) % chartModesCount
 	case key.Matches(msg, Bindings.Help):
 		dm.fullHelp = !dm.fullHelp
 	case key.Matches(msg, Bindings.Explore):
@@ -408,6 +541,22 @@ func (dm *DirModel) handleKeyBindings(msg tea.KeyPressMsg) bool {
 		dm.updateTableData()
 	case key.Matches(msg, Bindings.Dirs.ToggleSelectAll):
 		dm.dirsTable.ToggleMarkAll()
+	case key.Matches(msg, Bindings.Dirs.Bookmark):
+		if err := dm.nav.ToggleBookmark(dm.nav.Entry().Path); err != nil {
+			dm.errPopup.Show(err.Error())
+		}
+	case key.Matches(msg, Bindings.Dirs.TreeView):
+		dm.toggleTreeView()
+	case dm.treeView && key.Matches(msg, Bindings.Dirs.Expand):
+		dm.toggleExpand()
+	case key.Matches(msg, Bindings.Dirs.Breadcrumbs):
+		levels := slices.Index(Bindings.Dirs.Breadcrumbs.Keys(), msg.String()) + 1
+
+		dm.nav.Ascend(levels, func(_ *structure.Entry, _ State) {
+			dm.dirsTable.ResetMarked()
+			dm.filters.Reset()
+			dm.updateTableData()
+		})
 	}
 
 	dm.topEntries.Update(msg)
@@ -416,12 +565,13 @@ func (dm *DirModel) handleKeyBindings(msg tea.KeyPressMsg) bool {
 }
 
 func (dm *DirModel) viewChart() string {
-	si := make([]SectorInfo, 0, len(dm.nav.entry.Child))
-
-	for _, child := range dm.nav.entry.Child {
-		si = append(si, SectorInfo{Label: child.Name(), Size: child.Size})
+	depth := 1
+	if dm.chart == chartSunburst {
+		depth = SunburstDepth
 	}
 
+	si := chartSectors(dm.nav.entry, depth)
+
 	c := NewChart(
 		max(int(float64(dm.width)*0.45), 100),
 		int(float64(dm.height)*0.43),
@@ -430,12 +580,43 @@ func (dm *DirModel) viewChart() string {
 		style.ChartColors(),
 	)
 
+	if dm.chart == chartSunburst {
+		return style.ChartBox().Render(c.RenderSunburst(dm.nav.entry.Size, si))
+	}
+
 	return style.ChartBox().Render(c.Render(dm.nav.entry.Size, si))
 }
 
+// chartSectors returns the sectors info for the entry's child entries. The
+// nested sectors are added for directories up to the provided depth.
+func chartSectors(e *structure.Entry, depth int) []SectorInfo {
+	if depth == 0 {
+		return nil
+	}
+
+	si := make([]SectorInfo, 0, len(e.Child))
+
+	for child := range e.Entries() {
+		sector := SectorInfo{Label: child.Name(), Size: child.Size}
+
+		if child.IsDir {
+			sector.Children = chartSectors(child, depth-1)
+		}
+
+		si = append(si, sector)
+	}
+
+	// keep the biggest entries out of the merged "Others" sector.
+	slices.SortFunc(si, func(a, b SectorInfo) int {
+		return cmp.Compare(b.Size, a.Size)
+	})
+
+	return si
+}
+
 func (dm *DirModel) handleExploreKey() bool {
 	sr := dm.dirsTable.SelectedRow()
-	if sr != nil && len(sr.Cols) < 2 {
+	if sr == nil || len(sr.Cols) < 2 {
 		return true
 	}
 
@@ -443,21 +624,43 @@ func (dm *DirModel) handleExploreKey() bool {
 }
 
 func (dm *DirModel) handleFilter(msg tea.KeyPressMsg) bool {
-	if key.Matches(msg, Bindings.Dirs.NameFilter) {
-		if dm.mode == READY {
-			dm.mode = INPUT
-		} else {
+	inputFilters := []struct {
+		id      filter.ID
+		binding key.Binding
+	}{
+		{filter.NameFilterID, Bindings.Dirs.NameFilter},
+		{filter.SizeFilterID, Bindings.Dirs.SizeFilter},
+		{filter.AgeFilterID, Bindings.Dirs.AgeFilter},
+		{filter.QueryFilterID, Bindings.Dirs.Query},
+	}
+
+	for _, f := range inputFilters {
+		if !key.Matches(msg, f.binding) {
+			continue
+		}
+
+		switch {
+		case dm.mode == READY:
+			dm.mode, dm.inputFilter = INPUT, f.id
+		case dm.mode == INPUT && dm.inputFilter == f.id:
 			dm.mode = READY
+		case dm.mode == INPUT:
+			// switch the input between the filters, so only a single
+			// prompt is shown at a time.
+			dm.filters.ToggleFilter(dm.inputFilter)
+			dm.inputFilter = f.id
+		default:
+			continue
 		}
 
-		dm.filters.ToggleFilter(filter.NameFilterID)
+		dm.filters.ToggleFilter(f.id)
 	}
 
 	if dm.mode == INPUT {
 		dm.filters.Update(msg)
 		dm.updateTableData()
 
-		if f, ok := dm.filters[filter.NameFilterID]; ok {
+		if f, ok := dm.filters[dm.inputFilter]; ok {
 			if !f.Enabled() {
 				dm.mode = READY
 			}
@@ -512,22 +715,26 @@ func (dm *DirModel) handleDeletion(msg tea.KeyPressMsg) bool {
 		toDelete := make([]*structure.Entry, 0)
 
 		for _, r := range dm.dirsTable.MarkedRows() {
-			childEntry := dm.nav.Entry().GetChildByName(r.Cols[1])
-
-			if childEntry != nil {
+			if childEntry := dm.rowEntry(&r); childEntry != nil {
 				toDelete = append(toDelete, childEntry)
 			}
 		}
 
 		if len(toDelete) == 0 {
-			childEntry := dm.nav.Entry().
-				GetChildByName(dm.dirsTable.SelectedRow().Cols[1])
-
-			if childEntry != nil {
+			if childEntry := dm.rowEntry(dm.dirsTable.SelectedRow()); childEntry != nil {
 				toDelete = append(toDelete, childEntry)
 			}
 		}
 
+		// the tree view allows marking an entry along with its descendants,
+		// which are deleted with the entry anyway.
+		toDelete = slices.DeleteFunc(toDelete, func(e *structure.Entry) bool {
+			return slices.ContainsFunc(toDelete, func(ancestor *structure.Entry) bool {
+				return ancestor.IsDir &&
+					strings.HasPrefix(e.Path, ancestor.Path+string(os.PathSeparator))
+			})
+		})
+
 		dm.deleteDialog = NewDeleteDialogModel(dm.nav, toDelete)
 
 		dm.updateTableData()
@@ -552,8 +759,9 @@ func (dm *DirModel) handleDiff(msg tea.KeyPressMsg) bool {
 	case isDiffKey && dm.mode == READY:
 		dm.mode = DIFF
 		dm.diff.Run(dm.width, dm.height)
-	case isDiffKey && dm.mode == DIFF:
+	case (isDiffKey || key.Matches(msg, Bindings.Dirs.Compare)) && dm.mode == DIFF:
 		dm.mode = READY
+		dm.diff.Close()
 	case dm.mode == DIFF:
 		dm.diff.Update(msg)
 	default:
@@ -563,6 +771,223 @@ func (dm *DirModel) handleDiff(msg tea.KeyPressMsg) bool {
 	return true
 }
 
+func (dm *DirModel) handleTrends(msg tea.KeyPressMsg) bool {
+	isTrendsKey := key.Matches(msg, Bindings.Dirs.Trends)
+
+	switch {
+	case isTrendsKey && dm.mode == READY:
+		dm.mode = TRENDS
+		dm.trends.Run(dm.width, dm.height)
+	case isTrendsKey && dm.mode == TRENDS:
+		dm.mode = READY
+	case dm.mode == TRENDS:
+		dm.trends.Update(msg)
+	default:
+		return false
+	}
+
+	return true
+}
+
+func (dm *DirModel) handleTreemap(msg tea.KeyPressMsg) bool {
+	isTreemapKey := key.Matches(msg, Bindings.Dirs.Treemap)
+
+	onLevelChange := func(_ *structure.Entry, _ State) {
+		dm.filters.Reset()
+		dm.dirsTable.ResetMarked()
+		dm.updateTableData()
+	}
+
+	switch {
+	case isTreemapKey && dm.mode == READY:
+		dm.mode = TREEMAP
+		dm.treemap.Resize(dm.width, dm.height)
+		dm.treemap.Reset("")
+	case isTreemapKey && dm.mode == TREEMAP:
+		dm.mode = READY
+	case dm.mode == TREEMAP && key.Matches(msg, Bindings.Treemap.Open):
+		selected := dm.treemap.Selected()
+
+		if selected != nil && selected.IsDir {
+			dm.nav.Down(selected.Name(), dm.dirsTable.Cursor(), onLevelChange)
+			dm.treemap.Reset("")
+		}
+	case dm.mode == TREEMAP && key.Matches(msg, Bindings.Treemap.Back):
+		if dm.nav.HasParent() {
+			prevPath := dm.nav.Entry().Path
+
+			dm.nav.Up(onLevelChange)
+			dm.treemap.Reset(prevPath)
+		}
+	case dm.mode == TREEMAP:
+		dm.treemap.Update(msg)
+	default:
+		return false
+	}
+
+	return true
+}
+
+func (dm *DirModel) handleSearch(msg tea.KeyPressMsg) bool {
+	isSearchKey := key.Matches(msg, Bindings.Dirs.Search)
+
+	switch {
+	case isSearchKey && dm.mode == READY:
+		dm.mode = SEARCH
+		dm.search.Run(dm.width, dm.height)
+	case dm.mode == SEARCH && (isSearchKey || key.Matches(msg, Bindings.Finder.Close)):
+		dm.mode = READY
+		dm.search.Close()
+	case dm.mode == SEARCH && key.Matches(msg, Bindings.Finder.Open):
+		dm.openSearchResult()
+	case dm.mode == SEARCH && key.Matches(msg, Bindings.Finder.Delete):
+		toDelete := dm.search.Marked()
+
+		if selected := dm.search.Selected(); len(toDelete) == 0 && selected != nil {
+			toDelete = append(toDelete, selected)
+		}
+
+		if len(toDelete) != 0 {
+			dm.mode = DELETE
+			dm.deleteDialog = NewDeleteDialogModel(dm.nav, toDelete)
+		}
+	case dm.mode == SEARCH:
+		dm.search.Update(msg)
+	default:
+		return false
+	}
+
+	return true
+}
+
+// openSearchResult changes the current directory to the one containing the
+// selected search result and moves the cursor to the result's row.
+func (dm *DirModel) openSearchResult() {
+	selected := dm.search.Selected()
+	if selected == nil {
+		return
+	}
+
+	if !dm.changeDir(filepath.Dir(selected.Path)) {
+		return
+	}
+
+	dm.mode = READY
+	dm.search.Close()
+
+	dm.selectEntry(selected.Name())
+}
+
+func (dm *DirModel) handleGoTo(msg tea.KeyPressMsg) bool {
+	isGoToKey := key.Matches(msg, Bindings.Dirs.GoTo)
+
+	switch {
+	case isGoToKey && dm.mode == READY:
+		dm.mode = GOTO
+		dm.goTo.Run(dm.width, dm.height)
+	case dm.mode == GOTO && (isGoToKey || key.Matches(msg, Bindings.Finder.Close)):
+		dm.mode = READY
+		dm.goTo.Close()
+	case dm.mode == GOTO && key.Matches(msg, Bindings.Finder.Open):
+		if selected := dm.goTo.Selected(); selected != nil && dm.changeDir(selected.Path) {
+			dm.mode = READY
+			dm.goTo.Close()
+		}
+	case dm.mode == GOTO:
+		dm.goTo.Update(msg)
+	default:
+		return false
+	}
+
+	return true
+}
+
+// compare shows the delta between two arbitrary directories.
+func (dm *DirModel) compare(left, right string) {
+	dm.mode = DIFF
+	dm.diff.Compare(left, right, dm.width, dm.height)
+}
+
+// changeDir changes the current directory to the provided path at any depth of
+// the tree. The error is shown in the popup, if the directory cannot be opened.
+func (dm *DirModel) changeDir(path string) bool {
+	err := dm.nav.GoTo(path, func(_ *structure.Entry, _ State) {
+		dm.filters.Reset()
+		dm.dirsTable.ResetMarked()
+		dm.updateTableData()
+	})
+	if err != nil {
+		dm.errPopup.Show(err.Error())
+
+		return false
+	}
+
+	return true
+}
+
+// breadcrumbs returns the names of the current entry's ancestors starting from
+// the tree root, followed by the current entry's name. The root is named by
+// its full path.
+func (dm *DirModel) breadcrumbs() []string {
+	entries := dm.nav.Breadcrumbs()
+	names := make([]string, 0, len(entries))
+
+	for i, e := range entries {
+		if i == 0 {
+			names = append(names, e.Path)
+
+			continue
+		}
+
+		names = append(names, e.Name())
+	}
+
+	return names
+}
+
+// handleBreadcrumbsClick changes the current directory to the ancestor whose
+// breadcrumbs segment was clicked.
+func (dm *DirModel) handleBreadcrumbsClick(m tea.Mouse) {
+	if m.Button != tea.MouseLeft || m.Y != dm.crumbsY || m.X < dm.crumbsX {
+		return
+	}
+
+	levels := BreadcrumbsLevel(dm.crumbs, m.X-dm.crumbsX)
+	if levels == 0 {
+		return
+	}
+
+	dm.nav.Ascend(levels, func(_ *structure.Entry, _ State) {
+		dm.dirsTable.ResetMarked()
+		dm.filters.Reset()
+		dm.updateTableData()
+	})
+}
+
+// typing tells whether the keys are used as a text input in the current mode,
+// so the global key bindings must not be handled.
+func (dm *DirModel) typing() bool {
+	return slices.Contains([]Mode{INPUT, CMD, SEARCH, GOTO}, dm.mode)
+}
+
+// selectEntry moves the table cursor to the row of the current directory's
+// child entry with the provided name. The cursor stays the same if the entry is
+// not shown.
+func (dm *DirModel) selectEntry(name string) {
+	idx := slices.IndexFunc(dm.dirsTable.Rows(), func(r table.Row) bool {
+		return len(r.Cols) > 1 && r.Cols[1] == name
+	})
+
+	if idx == -1 {
+		return
+	}
+
+	dm.dirsTable.MoveCursor(idx)
+	dm.nav.SetCursor(idx)
+
+	dm.updatePreviewTable()
+}
+
 func (dm *DirModel) updateTableData() {
 	if dm.nav.OnDrives() || dm.nav.Entry() == nil || !dm.nav.Entry().IsDir {
 		return
@@ -579,33 +1004,75 @@ func (dm *DirModel) updateTableData() {
 
 	dm.summaryInfo.clear()
 
-	rows := make([]table.Row, 0, len(dm.nav.Entry().Child))
+	rows := dm.appendRows(
+		make([]table.Row, 0, len(dm.nav.Entry().Child)),
+		dm.nav.Entry(),
+		"",
+		nameCol.Width,
+	)
+
+	dm.dirsTable.SetRows(rows)
+	dm.dirsTable.MoveCursor(dm.nav.cursor)
 
-	dm.nav.Entry().SortedChild(dm.sortState.Key, dm.sortState.Desc)
+	dm.updatePreviewTable()
+}
 
-	for _, child := range dm.nav.Entry().Child {
+// appendRows appends the rows of the parent's child entries passing the filters
+// and sorted according to the current sort state. In the tree view, the rows of
+// the expanded directories are followed by their own child entries, sorted and
+// filtered the same way. The entries are identified by their paths relative to
+// the current entry, so the top-level rows contain the entry names.
+func (dm *DirModel) appendRows(
+	rows []table.Row,
+	parent *structure.Entry,
+	prefix string,
+	nameWidth int,
+) []table.Row {
+	depth := strings.Count(prefix, string(os.PathSeparator))
+	if len(prefix) != 0 {
+		depth++
+	}
+
+	parentSize := dm.nav.ParentSize()
+	if depth > 0 {
+		parentSize = max(parent.Size, 1)
+	}
+
+	parent.SortedChild(dm.sortState.Key, dm.sortState.Desc)
+
+	for _, child := range parent.Child {
 		if !dm.filters.Valid(child) {
 			continue
 		}
 
 		totalDirs, totalFiles := "-", "-"
 
-		dm.summaryInfo.add(child)
+		if depth == 0 {
+			dm.summaryInfo.add(child)
+		}
 
 		if child.IsDir {
 			totalDirs = strconv.FormatUint(child.TotalDirs, 10)
 			totalFiles = strconv.FormatUint(child.TotalFiles, 10)
 		}
 
-		parentUsage := float64(child.Size) / float64(dm.nav.ParentSize())
+		name, relPath := child.Name(), child.Name()
+		_, expanded := dm.expanded[child.Path]
+
+		if dm.treeView {
+			relPath = filepath.Join(prefix, child.Name())
+			name = treeRowName(child, depth, expanded)
+		}
+
+		parentUsage := float64(child.Size) / float64(parentSize)
 
 		rows = append(
 			rows,
 			table.Row{
 				Cols: []string{
 					EntryIcon(child),
-					child.Name(),
-					WrapString(child.Name(), nameCol.Width),
+					relPath,
+					WrapString(name, nameWidth),
 					FmtSizeColor(child.Size, entrySizeWidth),
 					Faint(totalDirs),
 					Faint(totalFiles),
@@ -614,12 +1081,88 @@ func (dm *DirModel) updateTableData() {
 				},
 			},
 		)
+
+		if dm.treeView && child.IsDir && expanded {
+			rows = dm.appendRows(rows, child, relPath, nameWidth)
+		}
 	}
 
-	dm.dirsTable.SetRows(rows)
-	dm.dirsTable.MoveCursor(dm.nav.cursor)
+	return rows
+}
 
-	dm.updatePreviewTable()
+// treeRowName returns the entry name indented according to its depth in the
+// tree view. The directories are prefixed with the expansion state marker.
+func treeRowName(e *structure.Entry, depth int, expanded bool) string {
+	marker := "  "
+
+	switch {
+	case e.IsDir && expanded:
+		marker = "▾ "
+	case e.IsDir:
+		marker = "▸ "
+	}
+
+	return strings.Repeat("  ", depth) + marker + e.Name()
+}
+
+// rowEntry returns the entry of the directory table's row or nil if the entry
+// does not exist anymore.
+func (dm *DirModel) rowEntry(r *table.Row) *structure.Entry {
+	if r == nil || len(r.Cols) < 2 || len(r.Cols[1]) == 0 || dm.nav.Entry() == nil {
+		return nil
+	}
+
+	return dm.nav.Entry().FindChild(filepath.Join(dm.nav.Entry().Path, r.Cols[1]))
+}
+
+// toggleTreeView switches between the flat list of the current directory's
+// entries and the tree view. When switching back to the flat list, the cursor
+// moves to the top-level ancestor of the selected row.
+func (dm *DirModel) toggleTreeView() {
+	dm.treeView = !dm.treeView
+
+	var topLevel string
+
+	if sr := dm.dirsTable.SelectedRow(); sr != nil && len(sr.Cols) > 1 {
+		topLevel, _, _ = strings.Cut(sr.Cols[1], string(os.PathSeparator))
+	}
+
+	dm.dirsTable.ResetMarked()
+	dm.updateTableData()
+
+	if !dm.treeView && len(topLevel) != 0 {
+		dm.selectEntry(topLevel)
+	}
+}
+
+// toggleExpand expands or collapses the selected directory in the tree view.
+// The marks of the rows that stay visible are preserved.
+func (dm *DirModel) toggleExpand() {
+	e := dm.rowEntry(dm.dirsTable.SelectedRow())
+	if e == nil || !e.IsDir {
+		return
+	}
+
+	if _, ok := dm.expanded[e.Path]; ok {
+		delete(dm.expanded, e.Path)
+	} else {
+		dm.expanded[e.Path] = struct{}{}
+	}
+
+	marked := make(map[string]struct{}, len(dm.dirsTable.MarkedRows()))
+
+	for _, r := range dm.dirsTable.MarkedRows() {
+		marked[r.Cols[1]] = struct{}{}
+	}
+
+	dm.dirsTable.ResetMarked()
+	dm.updateTableData()
+
+	dm.dirsTable.MarkRows(func(r table.Row) bool {
+		_, ok := marked[r.Cols[1]]
+
+		return ok
+	})
 }
 
 func (dm *DirModel) updatePreviewTable() {
@@ -627,7 +1170,7 @@ func (dm *DirModel) updatePreviewTable() {
 		return
 	}
 
-	parent := dm.nav.Entry().GetChildByName(dm.dirsTable.SelectedRow().Cols[1])
+	parent := dm.rowEntry(dm.dirsTable.SelectedRow())
 	if parent == nil {
 		return
 	}
@@ -649,6 +1192,9 @@ func (dm *DirModel) updatePreviewTable() {
 
 	rows := make([]table.Row, 0, dm.height)
 
+	// sorting also loads the lazily decoded child entries.
+	parent.SortedChild("", true)
+
 	if len(parent.Child) == 0 {
 		rows = append(rows, table.Row{Cols: []string{"", "No Preview", ""}})
 
@@ -657,8 +1203,6 @@ func (dm *DirModel) updatePreviewTable() {
 		return
 	}
 
-	parent.SortedChild("", true)
-
 	for i := range min(dm.height, len(parent.Child)) {
 		child := parent.Child[i]
 
@@ -693,7 +1237,7 @@ func (dm *DirModel) viewTopStatusBar() string {
 	)
 
 	for _, selected := range dm.dirsTable.MarkedRows() {
-		if entry := dm.nav.entry.GetChildByName(selected.Cols[1]); entry != nil {
+		if entry := dm.rowEntry(&selected); entry != nil {
 			selectedSize += entry.Size
 		}
 	}
@@ -701,7 +1245,7 @@ func (dm *DirModel) viewTopStatusBar() string {
 	if dm.dirsTable.SelectedRow() != nil {
 		fullEntryName = dm.dirsTable.SelectedRow().Cols[1]
 
-		entry := dm.nav.entry.GetChildByName(fullEntryName)
+		entry := dm.rowEntry(dm.dirsTable.SelectedRow())
 		if entry != nil && selectedSize == 0 {
 			selectedSize = entry.Size
 			isDir = entry.IsDir
@@ -758,8 +1302,12 @@ func (dm *DirModel) viewBottomStatusBar() string {
 		{
 			Content: dm.nav.Entry().Path,
 			BGColor: statusBarStyle.BG,
-			Wrapper: PrefixWrapString,
-			Width:   -1,
+			Wrapper: func(_ string, limit int) string {
+				dm.crumbs = FmtBreadcrumbs(dm.breadcrumbs(), limit)
+
+				return dm.crumbs
+			},
+			Width: -1,
 		},
 	}
 
@@ -772,6 +1320,39 @@ func (dm *DirModel) viewBottomStatusBar() string {
 		)
 	}
 
+	if dm.nav.IsBookmarked(dm.nav.Entry().Path) {
+		barItems = append(
+			barItems, &BarItem{
+				Content: "BOOKMARKED",
+				BGColor: style.CS().StatusBar.VersionBG,
+			},
+		)
+	}
+
+	if sf, ok := dm.filters[filter.SizeFilterID].(*filter.SizeFilter); ok && sf.Active() {
+		barItems = append(
+			barItems,
+			&BarItem{Content: "SIZE FILTER", BGColor: statusBarStyle.Dirs.ModeBG},
+			&BarItem{Content: fmtSizeRange(sf.Limits()), BGColor: statusBarStyle.BG},
+		)
+	}
+
+	if af, ok := dm.filters[filter.AgeFilterID].(*filter.AgeFilter); ok && af.Active() {
+		barItems = append(
+			barItems,
+			&BarItem{Content: "AGE FILTER", BGColor: statusBarStyle.Dirs.ModeBG},
+			&BarItem{Content: af.Value(), BGColor: statusBarStyle.BG},
+		)
+	}
+
+	if qf, ok := dm.filters[filter.QueryFilterID].(*filter.QueryFilter); ok && qf.Active() {
+		barItems = append(
+			barItems,
+			&BarItem{Content: "QUERY", BGColor: statusBarStyle.Dirs.ModeBG},
+			&BarItem{Content: qf.Value(), BGColor: statusBarStyle.BG},
+		)
+	}
+
 	barItems = append(
 		barItems,
 		[]*BarItem{
@@ -792,9 +1373,15 @@ func (dm *DirModel) viewBottomStatusBar() string {
 
 	dm.bottomStatusBar.Add(barItems)
 
-	return style.StatusBar().Margin(1, 0, 1, 0).Render(
-		dm.bottomStatusBar.Render(dm.width),
-	)
+	bar := dm.bottomStatusBar.Render(dm.width)
+
+	plain := ansi.Strip(bar)
+
+	if idx := strings.Index(plain, dm.crumbs); len(dm.crumbs) != 0 && idx != -1 {
+		dm.crumbsX = ansi.StringWidth(plain[:idx])
+	}
+
+	return style.StatusBar().Margin(1, 0, 1, 0).Render(bar)
 }
 
 // updateTableSize accepts the [tea.WindowSizeMsg] message and updates the
@@ -806,6 +1393,10 @@ func (dm *DirModel) updateTableSize(msg tea.WindowSizeMsg) {
 	dm.updateTableData()
 
 	dm.diff.Update(msg)
+	dm.trends.Update(msg)
+	dm.treemap.Update(msg)
+	dm.search.Update(msg)
+	dm.goTo.Update(msg)
 	dm.filters.Update(msg)
 	dm.topEntries.Update(msg)
 	dm.cmd.Update(msg)
@@ -0,0 +1,96 @@
+package render_test
+
+import (
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"testing"
+
+	"github.com/crumbyte/noxdir/config"
+	"github.com/crumbyte/noxdir/render"
+	"github.com/crumbyte/noxdir/structure"
+
+	tea "charm.land/bubbletea/v2"
+	"github.com/charmbracelet/x/ansi"
+	"github.com/stretchr/testify/require"
+)
+
+func TestDirModel_TreeView(t *testing.T) {
+	s := render.InitStyle(render.DefaultColorSchema())
+	render.InitKeyMap(nil, s)
+
+	root := t.TempDir()
+
+	require.NoError(t, os.MkdirAll(filepath.Join(root, "projects", "noxdir"), 0750))
+	require.NoError(t, os.WriteFile(
+		filepath.Join(root, "projects", "noxdir", "main.go"), []byte("package main"), 0600,
+	))
+
+	nav, _ := newTestNavigation(t, root, config.Settings{})
+
+	dm := render.NewDirModel(nav)
+	dm.Update(tea.WindowSizeMsg{Width: 200, Height: 40})
+	dm.Update(render.ScanFinished{Mode: render.READY})
+
+	press := func(code rune, text string) {
+		dm.Update(tea.KeyPressMsg{Code: code, Text: text})
+	}
+
+	press('t', "t")
+	require.Contains(t, dm.View().Content, "▸ projects")
+
+	// the expanded directories show their child entries inline.
+	press(tea.KeyTab, "")
+	press(tea.KeyDown, "")
+	press(tea.KeyTab, "")
+
+	content := dm.View().Content
+	require.Contains(t, content, "▾ projects")
+	require.Contains(t, content, "▾ noxdir")
+	require.Contains(t, content, "main.go")
+
+	// the flat list shows the current directory's entries only.
+	press('t', "t")
+
+	content = dm.View().Content
+	require.Contains(t, content, "projects")
+	require.NotContains(t, content, "▾")
+	require.NotContains(t, content, "main.go")
+}
+
+func TestDirModel_BreadcrumbsClick(t *testing.T) {
+	s := render.InitStyle(render.DefaultColorSchema())
+	render.InitKeyMap(nil, s)
+
+	root := t.TempDir()
+	deep := filepath.Join(root, "projects", "noxdir")
+
+	require.NoError(t, os.MkdirAll(deep, 0750))
+
+	nav, _ := newTestNavigation(t, root, config.Settings{})
+
+	dm := render.NewDirModel(nav)
+	dm.Update(tea.WindowSizeMsg{Width: 200, Height: 40})
+	dm.Update(render.ScanFinished{Mode: render.READY})
+
+	require.NoError(t, nav.GoTo(deep, func(_ *structure.Entry, _ render.State) {}))
+
+	lines := strings.Split(ansi.Strip(dm.View().Content), "\n")
+	y := slices.IndexFunc(lines, func(l string) bool {
+		return strings.Contains(l, "projects¹")
+	})
+	require.NotEqual(t, -1, y)
+
+	x := ansi.StringWidth(lines[y][:strings.Index(lines[y], "projects¹")])
+
+	// the click on the current directory's segment does nothing.
+	dm.Update(tea.MouseClickMsg{X: x + 14, Y: y, Button: tea.MouseLeft})
+	require.Equal(t, deep, nav.Entry().Path)
+
+	dm.Update(tea.MouseClickMsg{X: x, Y: y, Button: tea.MouseRight})
+	require.Equal(t, deep, nav.Entry().Path)
+
+	dm.Update(tea.MouseClickMsg{X: x, Y: y, Button: tea.MouseLeft})
+	require.Equal(t, filepath.Dir(deep), nav.Entry().Path)
+}
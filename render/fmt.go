@@ -2,18 +2,16 @@ package render
 
 import (
 	"fmt"
-	"math"
 	"os"
+	"slices"
 	"strconv"
 	"strings"
 
+	"github.com/crumbyte/noxdir/pkg/bytesize"
+
 	"charm.land/lipgloss/v2"
 )
 
-var sizeUnits = []string{
-	"B", "KB", "MB", "GB", "TB", "PB", "EB",
-}
-
 type numeric interface {
 	int | uint | uint64 | int64 | int32 | float64 | float32
 }
@@ -29,6 +27,32 @@ func FmtSize[T numeric](bytesSize T, width int) string {
 	return fmt.Sprintf("%s%*s", size, padding, suffix)
 }
 
+// FmtSignedSize formats the size delta in the same way as FmtSize, but the
+// result always contains an explicit sign showing whether the size has grown
+// or shrunk.
+func FmtSignedSize(delta int64, width int) string {
+	sign := "+"
+
+	if delta < 0 {
+		sign, delta = "-", -delta
+	}
+
+	return sign + FmtSize(delta, max(width-1, 0))
+}
+
+// fmtSizeRange formats the size range, where a zero limit means there is no
+// corresponding boundary.
+func fmtSizeRange(minLimit, maxLimit int64) string {
+	switch {
+	case maxLimit == 0:
+		return "≥ " + FmtSize(minLimit, 0)
+	case minLimit == 0:
+		return "≤ " + FmtSize(maxLimit, 0)
+	default:
+		return FmtSize(minLimit, 0) + " – " + FmtSize(maxLimit, 0)
+	}
+}
+
 func FmtSizeColor[T numeric](bytesSize T, width int) string {
 	size, suffix := fmtSize(bytesSize)
 	padding, sizeUnitStyle := 1, style.SizeUnit(suffix)
@@ -46,23 +70,7 @@ func FmtSizeColor[T numeric](bytesSize T, width int) string {
 }
 
 func fmtSize[T numeric](bytesSize T) (string, string) {
-	size := float64(bytesSize)
-	val := size
-
-	suffix := sizeUnits[0]
-
-	if bytesSize > 0 {
-		e := math.Floor(math.Log(size) / math.Log(1024))
-		suffix = sizeUnits[min(int(e), len(sizeUnits)-1)]
-
-		val = math.Floor(size/math.Pow(1024, e)*10+0.5) / 10
-
-		if int(e) > len(sizeUnits)-1 {
-			val = 1024 * float64(int(e)-(len(sizeUnits)-1))
-		}
-	}
-
-	return fmt.Sprintf("%.2f", val), suffix
+	return bytesize.Split(float64(bytesSize))
 }
 
 func unitFmt(val uint64) string {
@@ -121,3 +129,116 @@ func PrefixWrapString(data string, limit int) string {
 
 	return prefix + data[truncateLength:][pathSeparatorIdx:]
 }
+
+const breadcrumbsSeparator = " › "
+
+var superscriptDigits = []rune("⁰¹²³⁴⁵⁶⁷⁸⁹")
+
+// FmtBreadcrumbs joins the path segments into breadcrumbs. Each ancestor
+// segment is suffixed with its distance to the last segment as a superscript
+// number, so the ancestors up to the 9th level can be reached by the matching
+// number key. If the limit was exceeded, the leading segments are replaced
+// with "...".
+func FmtBreadcrumbs(names []string, limit int) string {
+	if len(names) == 0 {
+		return ""
+	}
+
+	crumbs := make([]string, len(names))
+
+	for i, name := range names {
+		crumbs[i] = name
+
+		if level := len(names) - 1 - i; level > 0 && level < len(superscriptDigits) {
+			crumbs[i] += string(superscriptDigits[level])
+		}
+	}
+
+	for i := range crumbs {
+		result := strings.Join(crumbs[i:], breadcrumbsSeparator)
+
+		if i > 0 {
+			result = "..." + breadcrumbsSeparator + result
+		}
+
+		if limit < 0 || lipgloss.Width(result) <= limit {
+			return result
+		}
+	}
+
+	return PrefixWrapString(names[len(names)-1], limit)
+}
+
+// BreadcrumbsLevel returns the level of the ancestor whose segment is located at
+// the provided cell offset within the breadcrumbs built by FmtBreadcrumbs. Zero
+// is returned if the offset points to the last segment, a separator, or the
+// "..." prefix of the truncated breadcrumbs.
+func BreadcrumbsLevel(breadcrumbs string, x int) int {
+	segments := strings.Split(breadcrumbs, breadcrumbsSeparator)
+	sepWidth := lipgloss.Width(breadcrumbsSeparator)
+
+	for i, segment := range segments {
+		if x < 0 {
+			return 0
+		}
+
+		width := lipgloss.Width(segment)
+
+		if x < width {
+			if i == 0 && segment == "..." {
+				return 0
+			}
+
+			return len(segments) - 1 - i
+		}
+
+		x -= width + sepWidth
+	}
+
+	return 0
+}
+
+var sparkBars = []rune("▁▂▃▄▅▆▇█")
+
+// Sparkline renders the provided values as a single line chart using the block
+// characters of different heights. If the number of values exceeds the width,
+// the values will be sampled evenly, keeping the first and the last values.
+func Sparkline(values []int64, width int) string {
+	if len(values) == 0 || width <= 0 {
+		return ""
+	}
+
+	if len(values) > width {
+		sampled := make([]int64, width)
+
+		for i := range sampled {
+			idx := len(values) - 1
+
+			if width > 1 {
+				idx = i * (len(values) - 1) / (width - 1)
+			}
+
+			sampled[i] = values[idx]
+		}
+
+		values = sampled
+	}
+
+	minValue, maxValue := slices.Min(values), slices.Max(values)
+	line := make([]rune, 0, len(values))
+
+	for _, v := range values {
+		barIdx := (len(sparkBars) - 1) / 2
+
+		if maxValue > minValue {
+			barIdx = int(
+				float64(v-minValue) / float64(maxValue-minValue) *
+					float64(len(sparkBars)-1),
+			)
+		}
+
+		line = append(line, sparkBars[barIdx])
+	}
+
+	return string(line)
+}
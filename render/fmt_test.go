@@ -36,6 +36,50 @@ func TestFmtSize(t *testing.T) {
 	}
 }
 
+func TestFmtBreadcrumbs(t *testing.T) {
+	names := []string{"/", "home", "user", "projects"}
+
+	require.Empty(t, render.FmtBreadcrumbs(nil, 10))
+	require.Equal(t, "/³ › home² › user¹ › projects", render.FmtBreadcrumbs(names, -1))
+	require.Equal(t, "... › user¹ › projects", render.FmtBreadcrumbs(names, 25))
+	require.Equal(t, "...ects", render.FmtBreadcrumbs(names, 4))
+}
+
+func TestBreadcrumbsLevel(t *testing.T) {
+	names := []string{"/", "home", "user", "projects"}
+
+	crumbs := render.FmtBreadcrumbs(names, -1)
+
+	for x, expected := range map[int]int{0: 3, 1: 3, 3: 0, 5: 2, 17: 1, 21: 0, 40: 0, -1: 0} {
+		require.Equal(t, expected, render.BreadcrumbsLevel(crumbs, x), x)
+	}
+
+	// the "..." prefix of the truncated breadcrumbs is not an ancestor.
+	crumbs = render.FmtBreadcrumbs(names, 25)
+
+	require.Zero(t, render.BreadcrumbsLevel(crumbs, 1))
+	require.Equal(t, 1, render.BreadcrumbsLevel(crumbs, 6))
+}
+
+func TestFmtSignedSize(t *testing.T) {
+	tableData := []struct {
+		expected string
+		delta    int64
+		width    int
+	}{
+		{"+0.00 B", 0, 0},
+		{"+1.00 KB", 1024, 0},
+		{"-1.00 KB", -1024, 0},
+		{"+49.00 GB", 49 << 30, 0},
+		{"-512.00 MB", -(512 << 20), 0},
+		{"+1.00      KB", 1024, 13},
+	}
+
+	for _, data := range tableData {
+		require.Equal(t, data.expected, render.FmtSignedSize(data.delta, data.width))
+	}
+}
+
 func TestFmtUsage(t *testing.T) {
 	render.InitStyle(render.DefaultColorSchema())
 	tableData := []struct {
@@ -85,3 +129,23 @@ func TestWrapPath(t *testing.T) {
 		)
 	}
 }
+
+func TestSparkline(t *testing.T) {
+	tableData := []struct {
+		expected string
+		values   []int64
+		width    int
+	}{
+		{"", nil, 10},
+		{"", []int64{1, 2}, 0},
+		{"▁█", []int64{1, 2}, 10},
+		{"▄▄▄", []int64{5, 5, 5}, 10},
+		{"▁▂▃▄▅▆▇█", []int64{0, 1, 2, 3, 4, 5, 6, 7}, 8},
+		{"▁▄█", []int64{0, 1, 2, 3, 4, 5, 6, 7, 8}, 3},
+		{"█▁", []int64{10, 0}, 5},
+	}
+
+	for _, data := range tableData {
+		require.Equal(t, data.expected, render.Sparkline(data.values, data.width))
+	}
+}
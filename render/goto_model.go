@@ -0,0 +1,367 @@
+package render
+
+import (
+	"cmp"
+	"fmt"
+	"math"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/crumbyte/noxdir/filter"
+	"github.com/crumbyte/noxdir/pkg/fuzzy"
+	"github.com/crumbyte/noxdir/render/table"
+	"github.com/crumbyte/noxdir/structure"
+
+	"charm.land/bubbles/v2/textinput"
+	tea "charm.land/bubbletea/v2"
+	"charm.land/lipgloss/v2"
+	"github.com/charmbracelet/bubbles/key"
+)
+
+const (
+	maxGoToResults = 100
+
+	// goToStepSize defines the number of directories collected per a single
+	// step. The steps are interleaved with the UI updates, so collecting the
+	// directories of a large or lazily decoded tree does not freeze the UI.
+	goToStepSize = 20_000
+
+	// frecencyWeight defines how much the visits history affects the ranking
+	// compared to the fuzzy match score.
+	frecencyWeight = 4
+
+	goToRatio = 0.7
+)
+
+// GoToStep triggers the next step of the directories collection identified by
+// the id. The steps of a closed or invalidated collection are ignored.
+type GoToStep struct {
+	id int
+}
+
+type goToCandidate struct {
+	entry *structure.Entry
+	score float64
+}
+
+// GoToModel is a fuzzy finder of the directories within the entire tree. The
+// matched directories are ranked by the match score and by the frequency and
+// recency of their visits in this and the previous sessions. Without a
+// pattern, the most frequently and recently visited directories are shown.
+//
+// The directories are collected in steps and kept for the subsequent runs
+// until the tree root changes or the collection is invalidated.
+type GoToModel struct {
+	nav     *Navigation
+	root    *structure.Entry
+	input   textinput.Model
+	table   *table.Model
+	columns []table.Column
+	dirs    []*structure.Entry
+	pending []*structure.Entry
+	matches []goToCandidate
+	pattern string
+	id      int
+	active  bool
+	width   int
+	height  int
+}
+
+func NewGoToModel(n *Navigation, textColor string) *GoToModel {
+	ti := filter.NewInput(
+		textColor,
+		`Go to… Examples: "dldocs" (matches "Downloads/docs")`,
+		"\uF07C  ",
+	)
+
+	return &GoToModel{
+		nav:   n,
+		input: ti,
+		table: buildTable(),
+		columns: []table.Column{
+			{Title: ""},
+			{Title: ""},
+			{Title: "Directory"},
+			{Title: "Size"},
+		},
+	}
+}
+
+func (gm *GoToModel) Init() tea.Cmd {
+	return nil
+}
+
+func (gm *GoToModel) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
+	switch msg := msg.(type) {
+	case tea.WindowSizeMsg:
+		gm.resize(msg.Width, msg.Height)
+		gm.updateTableData()
+	case GoToStep:
+		if msg.id == gm.id && gm.active {
+			gm.step()
+		}
+	case tea.KeyPressMsg:
+		switch {
+		case key.Matches(msg, Bindings.Finder.Up):
+			gm.table.MoveUp(1)
+		case key.Matches(msg, Bindings.Finder.Down):
+			gm.table.MoveDown(1)
+		case key.Matches(msg, Bindings.Finder.PageUp):
+			gm.table.MoveUp(gm.table.Height())
+		case key.Matches(msg, Bindings.Finder.PageDown):
+			gm.table.MoveDown(gm.table.Height())
+		default:
+			value := gm.input.Value()
+
+			gm.input, _ = gm.input.Update(msg)
+
+			if gm.input.Value() != value {
+				gm.updateTableData()
+			}
+		}
+	}
+
+	return gm, nil
+}
+
+func (gm *GoToModel) View() tea.View {
+	header := lipgloss.NewStyle().
+		Align(lipgloss.Center).
+		Width(gm.width).
+		Bold(true).
+		Faint(true).
+		Render(gm.viewHeader())
+
+	help := lipgloss.NewStyle().Width(gm.width).Align(lipgloss.Center).Render(
+		gm.table.Help.ShortHelpView([]key.Binding{
+			Bindings.Finder.Open,
+			Bindings.Finder.Close,
+		}),
+	)
+
+	input := gm.input.View()
+
+	gm.table.SetHeight(
+		gm.height - lipgloss.Height(header) - lipgloss.Height(input) -
+			lipgloss.Height(help),
+	)
+
+	return tea.NewView(
+		style.DialogBox().Render(
+			lipgloss.NewStyle().Padding(0, 1, 0, 1).Render(
+				lipgloss.JoinVertical(
+					lipgloss.Top, header, input, gm.table.View().Content, help,
+				),
+			),
+		),
+	)
+}
+
+// Run opens the finder and starts collecting the directories of the entire
+// tree, so the directories outside the current entry can be found as well. The
+// directories collected by the previous runs are reused if the tree root has
+// not changed.
+func (gm *GoToModel) Run(width, height int) {
+	if root := gm.nav.tree.Root(); root != gm.root {
+		gm.root = root
+		gm.Invalidate()
+	}
+
+	gm.active = true
+	gm.input.SetValue("")
+
+	// the first step is done right away, so the small trees are collected
+	// without waiting for the next update.
+	if len(gm.pending) > 0 {
+		gm.step()
+	}
+
+	gm.resize(width, height)
+	gm.updateTableData()
+}
+
+// Close closes the finder and pauses the directories collection. The already
+// collected directories are kept for the next run.
+func (gm *GoToModel) Close() {
+	gm.active, gm.matches, gm.pattern = false, nil, ""
+	gm.id++
+}
+
+// Invalidate drops the collected directories, so they are collected again
+// from the tree root. It must be called once the tree structure changes, e.g.,
+// after a rescan or a deletion.
+func (gm *GoToModel) Invalidate() {
+	gm.id++
+	gm.dirs, gm.pending, gm.matches, gm.pattern = nil, nil, nil, ""
+
+	if gm.root != nil {
+		gm.pending = append(gm.pending, gm.root)
+	}
+
+	if gm.active && gm.root != nil {
+		gm.next()
+	}
+}
+
+// Selected returns the selected directory or nil if there are no results.
+func (gm *GoToModel) Selected() *structure.Entry {
+	sr := gm.table.SelectedRow()
+	if sr == nil {
+		return nil
+	}
+
+	return gm.nav.tree.Find(sr.Cols[0])
+}
+
+// step collects the next portion of the directories and matches them against
+// the current pattern.
+func (gm *GoToModel) step() {
+	collected := len(gm.dirs)
+
+	for len(gm.pending) > 0 && len(gm.dirs)-collected < goToStepSize {
+		dir := gm.pending[len(gm.pending)-1]
+		gm.pending = gm.pending[:len(gm.pending)-1]
+
+		gm.dirs = append(gm.dirs, dir)
+
+		for child := range dir.EntriesByType(true) {
+			gm.pending = append(gm.pending, child)
+		}
+	}
+
+	if len(gm.pattern) != 0 {
+		gm.matches = gm.match(gm.matches, gm.dirs[collected:])
+		gm.updateTableData()
+	}
+
+	if len(gm.pending) > 0 {
+		gm.next()
+	}
+}
+
+func (gm *GoToModel) next() {
+	id := gm.id
+
+	go func() {
+		teaProg.Send(GoToStep{id: id})
+	}()
+}
+
+// candidates returns the directories matching the current pattern, sorted by
+// their rank.
+func (gm *GoToModel) candidates() []goToCandidate {
+	now := time.Now()
+	pattern := strings.TrimSpace(gm.input.Value())
+
+	if len(pattern) == 0 {
+		gm.matches, gm.pattern = nil, ""
+
+		candidates := make([]goToCandidate, 0, maxGoToResults)
+
+		for _, path := range gm.nav.visits.Top(maxGoToResults, now) {
+			if e := gm.nav.tree.Find(path); e != nil && e.IsDir {
+				candidates = append(candidates, goToCandidate{entry: e})
+			}
+		}
+
+		return candidates
+	}
+
+	if pattern != gm.pattern {
+		dirs := gm.dirs
+
+		// a directory matching the extended pattern also matches the previous
+		// one, so only the previous matches have to be checked again.
+		if len(gm.pattern) != 0 && strings.HasPrefix(pattern, gm.pattern) {
+			dirs = make([]*structure.Entry, 0, len(gm.matches))
+
+			for _, c := range gm.matches {
+				dirs = append(dirs, c.entry)
+			}
+		}
+
+		gm.pattern = pattern
+		gm.matches = gm.match(nil, dirs)
+	}
+
+	return gm.matches[:min(len(gm.matches), maxGoToResults)]
+}
+
+// match appends the directories matching the current pattern to the matches
+// and returns them sorted by their rank.
+func (gm *GoToModel) match(matches []goToCandidate, dirs []*structure.Entry) []goToCandidate {
+	now := time.Now()
+
+	for _, dir := range dirs {
+		score, ok := fuzzy.Match(gm.pattern, dir.Path)
+		if !ok {
+			continue
+		}
+
+		matches = append(matches, goToCandidate{
+			entry: dir,
+			score: float64(score) +
+				frecencyWeight*math.Log2(1+gm.nav.visits.Score(dir.Path, now)),
+		})
+	}
+
+	slices.SortStableFunc(matches, func(a, b goToCandidate) int {
+		return cmp.Or(
+			cmp.Compare(b.score, a.score),
+			cmp.Compare(len(a.entry.Path), len(b.entry.Path)),
+		)
+	})
+
+	return matches
+}
+
+func (gm *GoToModel) viewHeader() string {
+	if len(strings.TrimSpace(gm.input.Value())) == 0 {
+		return "Frequently and recently visited directories"
+	}
+
+	header := fmt.Sprintf("%d of %d directories matched", len(gm.matches), len(gm.dirs))
+
+	if len(gm.pending) > 0 {
+		header += " (collecting…)"
+	}
+
+	return header
+}
+
+func (gm *GoToModel) resize(width, height int) {
+	gm.width = int(float64(width) * goToRatio)
+	gm.height = int(float64(height) * goToRatio)
+
+	gm.table.SetWidth(gm.width)
+	gm.input.SetWidth(gm.width - lipgloss.Width(gm.input.Prompt))
+}
+
+func (gm *GoToModel) updateTableData() {
+	iconWidth, sizeWidth := 5, 15
+
+	gm.columns[0].Width = 0
+	gm.columns[1].Width = iconWidth
+	gm.columns[2].Width = max(gm.width-iconWidth-sizeWidth, 0)
+	gm.columns[3].Width = sizeWidth
+
+	gm.table.SetColumns(gm.columns)
+
+	candidates := gm.candidates()
+	rows := make([]table.Row, 0, len(candidates))
+
+	for _, c := range candidates {
+		rows = append(rows, table.Row{
+			Cols: []string{
+				c.entry.Path,
+				EntryIcon(c.entry),
+				PrefixWrapString(c.entry.Path, gm.columns[2].Width-2),
+				FmtSizeColor(c.entry.Size, entrySizeWidth),
+			},
+		})
+	}
+
+	gm.table.SetRows(rows)
+	gm.table.SetCursor(0)
+}
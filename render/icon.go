@@ -1,11 +1,29 @@
 package render
 
-import "github.com/crumbyte/noxdir/structure"
+import (
+	"github.com/crumbyte/noxdir/pkg/filetype"
+	"github.com/crumbyte/noxdir/structure"
+)
+
+// categoryIcons contains the icons of the file type categories. The files with
+// more specific icons are resolved by their extensions first.
+var categoryIcons = map[filetype.Category]string{
+	filetype.Code:       "💻",
+	filetype.Image:      "📸",
+	filetype.Video:      "🎬",
+	filetype.Audio:      "🎵",
+	filetype.Archive:    "🪤",
+	filetype.Document:   "📄",
+	filetype.Config:     "🔧",
+	filetype.Executable: "📦",
+	filetype.Other:      "📄",
+}
 
 // EntryIcon resolves an emoji icon for the provided Entry instance based on the
-// file extension.
+// file extension. The icon represents the file type category unless the file
+// has a more specific icon.
 //
-//nolint:cyclop,funlen // speed and simplicity over another map resolver
+//nolint:cyclop // speed and simplicity over another map resolver
 func EntryIcon(e *structure.Entry) string {
 	icon := "📁"
 
@@ -17,23 +35,9 @@ func EntryIcon(e *structure.Entry) string {
 		return icon
 	}
 
-	switch e.Ext() {
-	case "go", "py", "js", "ts", "java", "cpp", "c", "cs", "rb", "rs", "sh", "php":
-		icon = "💻"
-	case "jpg", "jpeg", "png", "gif", "bmp", "webp", "tiff":
-		icon = "📸"
-	case "mp4", "mkv", "avi", "mov", "webm", "m4v", "wmv", "flv":
-		icon = "🎬"
-	case "json", "csv", "xml", "env", "yml", "yaml", "ini":
-		icon = "🔧"
+	switch ext := e.Ext(); ext {
 	case "jks", "pub", "key", "p12", "ppk":
 		icon = "🔑"
-	case "zip", "rar", "7z", "tar", "gz":
-		icon = "🪤"
-	case "mp3", "wav", "flac", "ogg":
-		icon = "🎵"
-	case "exe", "bin", "dll", "app":
-		icon = "📦"
 	case "doc", "docx":
 		icon = "📝"
 	case "xls", "xlsx":
@@ -51,7 +55,7 @@ func EntryIcon(e *structure.Entry) string {
 	case "iso":
 		icon = "📀"
 	default:
-		icon = "📄"
+		icon = categoryIcons[filetype.Of(ext)]
 	}
 
 	return icon
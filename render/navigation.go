@@ -4,10 +4,15 @@ import (
 	"errors"
 	"fmt"
 	"os"
+	"path/filepath"
+	"slices"
 	"sync/atomic"
+	"time"
 
 	"github.com/crumbyte/noxdir/config"
 	"github.com/crumbyte/noxdir/drive"
+	"github.com/crumbyte/noxdir/pkg/cache"
+	"github.com/crumbyte/noxdir/pkg/frecency"
 	"github.com/crumbyte/noxdir/structure"
 )
 
@@ -71,6 +76,7 @@ type Navigation struct {
 	currentDrive *drive.Info
 	entryStack   *entryStack
 	settings     config.Settings
+	visits       *frecency.Store
 	state        State
 	cursor       int
 	locked       atomic.Bool
@@ -86,6 +92,10 @@ func NewNavigation(t *structure.Tree, s config.Settings) *Navigation {
 		cacheEnabled: s.UseCache,
 	}
 
+	// the history only affects the ranking of the go-to results, so the app
+	// starts with an empty history if the file is broken.
+	n.visits, _ = frecency.Load(s.VisitsPath())
+
 	n.RefreshDrives()
 
 	return n
@@ -93,13 +103,14 @@ func NewNavigation(t *structure.Tree, s config.Settings) *Navigation {
 
 // NewRootNavigation creates navigation for a predefined root directory entry.
 // It starts the blocking traversal immediately rather than in interactive mode.
-// Therefore, a root with a wide subdirectory structure might cause a delay.
+// Therefore, a root with a wide subdirectory structure might cause a delay. If
+// caching is enabled, the root will be restored from the cache instead.
 func NewRootNavigation(t *structure.Tree, s config.Settings) (*Navigation, error) {
 	if t.Root() == nil {
 		return nil, errors.New("root is nil")
 	}
 
-	done, errChan := t.TraverseAsync(true)
+	done, errChan := t.TraverseAsync(false)
 	if done == nil {
 		return nil, errors.New("root is nil")
 	}
@@ -118,11 +129,12 @@ wait:
 	t.CalculateSize()
 
 	n := NewNavigation(t, s)
-	n.cacheEnabled = false
 
 	n.state = Dirs
 	n.entry = t.Root()
 
+	n.visit()
+
 	return n, nil
 }
 
@@ -181,7 +193,8 @@ func (n *Navigation) Up(ocl OnChangeLevel) {
 			return
 		}
 
-		_, _ = n.tree.PersistCache()
+		_ = n.tree.PersistCache()
+		_ = n.PersistVisits()
 
 		n.state, n.cursor = Drives, 0
 
@@ -195,6 +208,58 @@ func (n *Navigation) Up(ocl OnChangeLevel) {
 	if lastItem := n.entryStack.pop(); lastItem != nil {
 		n.entry, n.cursor = lastItem.entry, lastItem.cursor
 	}
+
+	n.visit()
+}
+
+// HasParent checks whether the current entry has a parent entry in the
+// navigation history, i.e., the Up call will not lead to the drives list.
+func (n *Navigation) HasParent() bool {
+	return !n.OnDrives() && n.entryStack.len() > 0
+}
+
+// Breadcrumbs returns the entries of the navigation history starting from the
+// tree root, followed by the current entry. It returns an empty slice if the
+// current state is Drives.
+func (n *Navigation) Breadcrumbs() []*structure.Entry {
+	if n.OnDrives() || n.entry == nil {
+		return nil
+	}
+
+	crumbs := make([]*structure.Entry, 0, n.entryStack.len()+1)
+
+	for _, item := range *n.entryStack {
+		crumbs = append(crumbs, item.entry)
+	}
+
+	return append(crumbs, n.entry)
+}
+
+// Ascend changes the current tree level up by the provided number of levels,
+// i.e., Ascend(1) works the same way as the Up function, except that it never
+// leads to the drives list. The function does nothing if there are fewer
+// parent entries in the navigation history than requested.
+//
+// If the navigation is currently locked, the function will do nothing and return
+// immediately without an error.
+func (n *Navigation) Ascend(levels int, ocl OnChangeLevel) {
+	if levels <= 0 || levels > n.entryStack.len() || n.OnDrives() || !n.lock() {
+		return
+	}
+
+	defer n.unlock()
+
+	var item *stackItem
+
+	for range levels {
+		item = n.entryStack.pop()
+	}
+
+	n.entry, n.cursor = item.entry, item.cursor
+
+	n.visit()
+
+	ocl(n.entry, n.state)
 }
 
 // SetCursor preserves the current position of the table's cursor. The cursor
@@ -228,6 +293,7 @@ func (n *Navigation) Down(path string, cursor int, ocl OnChangeLevel) (chan stru
 		n.currentDrive = n.drives.DriveInfo(path)
 		n.tree.SetRoot(n.entry)
 		n.tree.SetPartialRoot(false)
+		n.visit()
 
 		doneChan, errChan := n.tree.TraverseAsync(false)
 
@@ -252,11 +318,146 @@ func (n *Navigation) Down(path string, cursor int, ocl OnChangeLevel) (chan stru
 	n.entryStack.push(&stackItem{entry: n.entry, cursor: cursor})
 	n.entry, n.cursor = entry, 0
 
+	n.visit()
+
 	ocl(n.entry, n.state)
 
 	return nil, nil
 }
 
+// GoTo changes the current tree level to the directory with the provided full
+// path. Unlike the Down function, the target can be located at any depth of the
+// current tree. The navigation history is rebuilt from the tree root, so the Up
+// call leads to the target's parent directory rather than to the previous entry.
+//
+// If the navigation is currently locked, the function will do nothing and return
+// immediately without an error.
+func (n *Navigation) GoTo(path string, ocl OnChangeLevel) error {
+	if n.OnDrives() || !n.lock() {
+		return nil
+	}
+
+	defer n.unlock()
+
+	root := n.tree.Root()
+
+	target := n.tree.Find(path)
+	if target == nil || !target.IsDir {
+		return fmt.Errorf("go to: directory not found: %s", path)
+	}
+
+	items := make([]*stackItem, 0)
+
+	for e := target; e != root; {
+		parent := root.FindChild(filepath.Dir(e.Path))
+		if parent == nil || parent == e {
+			return fmt.Errorf("go to: parent not found: %s", e.Path)
+		}
+
+		// the cursor points to the child entry, so going back highlights the
+		// directory the navigation came from.
+		items = append(
+			items,
+			&stackItem{entry: parent, cursor: max(slices.Index(parent.Child, e), 0)},
+		)
+
+		e = parent
+	}
+
+	n.entryStack.reset()
+
+	for _, item := range slices.Backward(items) {
+		n.entryStack.push(item)
+	}
+
+	n.entry, n.cursor = target, 0
+
+	n.visit()
+
+	ocl(n.entry, n.state)
+
+	return nil
+}
+
+// OpenPath starts a new scan for showing the provided directory path that does
+// not belong to the current tree. If the navigation was started with a root
+// directory, the path itself becomes the new root, otherwise the drive
+// containing the path is scanned. The path is scanned as a root directory if no
+// drive contains it.
+//
+// The returned channels work the same way as for the drive selection in the
+// Down function. The client is responsible for changing the level to the path
+// using the GoTo function once the scan is done.
+func (n *Navigation) OpenPath(path string) (chan struct{}, chan error, error) {
+	fi, err := os.Stat(path)
+	if err != nil {
+		return nil, nil, fmt.Errorf("open path: %w", err)
+	}
+
+	if !fi.IsDir() {
+		return nil, nil, fmt.Errorf("open path: not a directory: %s", path)
+	}
+
+	if !n.lock() {
+		return nil, nil, nil
+	}
+
+	defer n.unlock()
+
+	if !n.OnDrives() {
+		_ = n.tree.PersistCache()
+	}
+
+	root, partialRoot := path, true
+	n.currentDrive = nil
+
+	if !n.tree.IsPartialRoot() {
+		if d := n.drives.Containing(path); d != nil {
+			root, partialRoot, n.currentDrive = d.Path, false, d
+		}
+	}
+
+	n.state, n.cursor = Dirs, 0
+	n.entry = structure.NewDirEntry(root, 0)
+	n.entryStack.reset()
+
+	n.tree.SetRoot(n.entry)
+	n.tree.SetPartialRoot(partialRoot)
+
+	doneChan, errChan := n.tree.TraverseAsync(false)
+
+	return doneChan, errChan, nil
+}
+
+// Bookmarks returns the paths of the bookmarked directories.
+func (n *Navigation) Bookmarks() []string {
+	return n.settings.Bookmarks
+}
+
+// IsBookmarked checks whether the directory path is bookmarked.
+func (n *Navigation) IsBookmarked(path string) bool {
+	return slices.Contains(n.settings.Bookmarks, path)
+}
+
+// ToggleBookmark adds the directory path to the bookmarks or removes it if the
+// path is already bookmarked. The bookmarks are saved to the settings file
+// immediately.
+func (n *Navigation) ToggleBookmark(path string) error {
+	bookmarks := slices.Clone(n.settings.Bookmarks)
+
+	if idx := slices.Index(bookmarks, path); idx != -1 {
+		bookmarks = slices.Delete(bookmarks, idx, idx+1)
+	} else {
+		bookmarks = append(bookmarks, path)
+	}
+
+	if err := n.settings.SaveBookmarks(bookmarks); err != nil {
+		return fmt.Errorf("bookmark: %w", err)
+	}
+
+	return nil
+}
+
 // ToDrives resets the navigation state back to Drives and clears the navigation
 // stack. The OnChangeLevel handler will be called in the same way as for a
 // regular level change.
@@ -272,7 +473,8 @@ func (n *Navigation) ToDrives(ocl OnChangeLevel) {
 
 	ocl(n.entry, n.state)
 
-	_, _ = n.tree.PersistCache()
+	_ = n.tree.PersistCache()
+	_ = n.PersistVisits()
 }
 
 // RefreshDrives refreshes the list of the available drives and their memory
@@ -339,6 +541,32 @@ func (n *Navigation) RefreshEntry() (chan struct{}, chan error, error) {
 	return doneChan, errChan, nil
 }
 
+// RescanRoot rescans the entire tree skipping the cache and returns to the tree
+// root. It restores the tree whose cached entries cannot be loaded. See
+// structure.Tree.LoadErr.
+//
+// The navigation will be locked until the scanning is complete and the "done"
+// channel is closed.
+func (n *Navigation) RescanRoot() (chan struct{}, chan error) {
+	if n.OnDrives() || !n.lock() {
+		return nil, nil
+	}
+
+	n.entryStack.reset()
+	n.entry, n.cursor = n.tree.Root(), 0
+
+	doneChan, errChan := n.tree.TraverseNodeAsync(n.entry)
+
+	go func() {
+		<-doneChan
+		n.unlock()
+	}()
+
+	return doneChan, errChan
+}
+
+// Explore opens the drive or the entry in the system file explorer. The entry
+// name might be a path relative to the current entry.
 func (n *Navigation) Explore(name string) error {
 	if len(name) == 0 {
 		return nil
@@ -354,7 +582,7 @@ func (n *Navigation) Explore(name string) error {
 
 		fullPath = d.Path
 	} else {
-		entry := n.entry.GetChildByName(name)
+		entry := n.entry.FindChild(filepath.Join(n.entry.Path, name))
 		if entry == nil {
 			return nil
 		}
@@ -366,18 +594,24 @@ func (n *Navigation) Explore(name string) error {
 }
 
 // Delete deletes the file or directory from the file system represented by the
-// provided instance of *Entry, including all internal content. The entry lookup
-// will be done within the current active *Entry instance, limiting the deletion
-// scope.
+// provided instance of *Entry, including all internal content. The entry is
+// removed from its parent directory, which is resolved by the entry path, so
+// the entries found deeper in the tree, e.g., by the search, can be deleted as
+// well. If the parent cannot be resolved, the current active *Entry instance is
+// used instead.
 //
-// If the entry was not found in the current active *Entry instance no error will
-// be returned.
+// If the entry was not found in its parent no error will be returned.
 func (n *Navigation) Delete(entry *structure.Entry) error {
 	if err := os.RemoveAll(entry.Path); err != nil {
 		return fmt.Errorf("delete: path: %s: %w", entry.Path, err)
 	}
 
-	if removed := n.entry.RemoveChild(entry); removed {
+	parent := n.tree.Find(filepath.Dir(entry.Path))
+	if parent == nil {
+		parent = n.entry
+	}
+
+	if removed := parent.RemoveChild(entry); removed {
 		n.tree.MarkDirty()
 		n.tree.CalculateSize()
 	}
@@ -385,30 +619,142 @@ func (n *Navigation) Delete(entry *structure.Entry) error {
 	return nil
 }
 
-func (n *Navigation) Diff() (*structure.Tree, *structure.Diff, error) {
+// Snapshots returns a list of cache snapshots available for the current tree
+// root. The list is sorted by the creation time, starting from the most recent
+// snapshot.
+func (n *Navigation) Snapshots() ([]cache.Snapshot, error) {
+	if n.OnDrives() || n.entry == nil {
+		return nil, nil
+	}
+
+	return n.tree.Snapshots()
+}
+
+// Diff calculates the delta between the current active entry and its state
+// stored in the provided cache snapshot. It returns the restored snapshot tree
+// and the calculated delta. The returned tree must be closed once it's not used
+// anymore. If the current entry does not exist in the snapshot, both values
+// will be nil.
+func (n *Navigation) Diff(s cache.Snapshot) (*structure.Tree, *structure.Diff, error) {
 	if n.OnDrives() || !n.lock() || n.entry == nil {
 		return nil, nil, nil
 	}
 
 	defer n.unlock()
 
-	cachedTree, err := n.tree.Cached()
+	cachedTree, err := n.tree.CachedSnapshot(s)
 	if err != nil {
 		return nil, nil, err
 	}
 
-	if cachedTree == nil {
-		return nil, nil, err
-	}
-
-	cashedEntry := cachedTree.Root().FindChild(n.entry.Path)
+	cashedEntry := cachedTree.Find(n.entry.Path)
 	if cashedEntry == nil {
-		return nil, nil, nil
+		return nil, nil, cachedTree.Close()
 	}
 
 	return cachedTree, cashedEntry.Diff(n.entry), nil
 }
 
+// Compare scans two arbitrary directories and returns the delta between them.
+// The directories are scanned as separate trees with the same settings as the
+// current tree, but without using the cache, so the current tree stays intact.
+// The returned trees contain the left and the right directory respectively.
+//
+// The directories are scanned one by one, since the scan deduplicates the
+// hard links, and the backups often consist of the hard links to the source.
+func (n *Navigation) Compare(left, right string) (*structure.Tree, *structure.Tree, *structure.Diff, error) {
+	trees := make([]*structure.Tree, 0, 2)
+
+	for _, path := range []string{left, right} {
+		fi, err := os.Stat(path)
+		if err != nil {
+			return nil, nil, nil, fmt.Errorf("compare: %w", err)
+		}
+
+		if !fi.IsDir() {
+			return nil, nil, nil, fmt.Errorf("compare: not a directory: %s", path)
+		}
+
+		t := n.tree.Clone(structure.NewDirEntry(path, 0), structure.WithPartialRoot())
+
+		// ignore permission related errors for now
+		_ = t.Traverse(true)
+
+		t.CalculateSize()
+
+		trees = append(trees, t)
+	}
+
+	return trees[0], trees[1], trees[0].Root().Compare(trees[1].Root()), nil
+}
+
+// Trends builds the size time series for the current active entry and its
+// subdirectories up to the specified depth. All cache snapshots available for
+// the tree root are used as the data points, and the current state is always
+// added as the most recent point. The snapshots that cannot be restored, e.g.,
+// the ones created by an incompatible version, are skipped, and their number
+// is returned along with the trends.
+func (n *Navigation) Trends(depth int) ([]structure.Trend, int, error) {
+	if n.OnDrives() || !n.lock() || n.entry == nil {
+		return nil, 0, nil
+	}
+
+	defer n.unlock()
+
+	snapshots, err := n.tree.Snapshots()
+	if err != nil {
+		return nil, 0, err
+	}
+
+	skipped := 0
+	samples := make([]structure.TrendSample, 0, len(snapshots)+1)
+	cachedTrees := make([]*structure.Tree, 0, len(snapshots))
+
+	for _, s := range slices.Backward(snapshots) {
+		cachedTree, snapshotErr := n.tree.CachedSnapshot(s)
+		if snapshotErr != nil {
+			skipped++
+
+			continue
+		}
+
+		cachedTrees = append(cachedTrees, cachedTree)
+
+		samples = append(
+			samples,
+			structure.TrendSample{Time: s.Created, Root: cachedTree.Root()},
+		)
+	}
+
+	samples = append(
+		samples,
+		structure.TrendSample{Time: time.Now(), Root: n.tree.Root()},
+	)
+
+	trends := structure.Trends(n.entry.Path, samples, depth)
+
+	// the trends contain only the sizes, so the snapshots are not needed once
+	// the trends are built.
+	for _, cachedTree := range cachedTrees {
+		_ = cachedTree.Close()
+	}
+
+	return trends, skipped, nil
+}
+
+// PersistVisits saves the history of the visited directories used for ranking
+// the go-to results across the sessions.
+func (n *Navigation) PersistVisits() error {
+	return n.visits.Save()
+}
+
+// visit registers the visit of the current active entry.
+func (n *Navigation) visit() {
+	if n.entry != nil {
+		n.visits.Visit(n.entry.Path, time.Now())
+	}
+}
+
 func (n *Navigation) lock() bool {
 	return !n.locked.Swap(true)
 }
@@ -0,0 +1,281 @@
+package render_test
+
+import (
+	"io"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"testing"
+
+	"github.com/crumbyte/noxdir/config"
+	"github.com/crumbyte/noxdir/pkg/cache"
+	"github.com/crumbyte/noxdir/render"
+	"github.com/crumbyte/noxdir/structure"
+
+	tea "charm.land/bubbletea/v2"
+	"github.com/stretchr/testify/require"
+)
+
+func TestNavigation_GoTo(t *testing.T) {
+	root := t.TempDir()
+	deep := filepath.Join(root, "projects", "noxdir", "render")
+
+	require.NoError(t, os.MkdirAll(deep, 0750))
+	require.NoError(t, os.WriteFile(filepath.Join(deep, "file"), []byte("data"), 0600))
+
+	nav, tree := newTestNavigation(t, root, config.Settings{})
+
+	ocl := func(_ *structure.Entry, _ render.State) {}
+
+	require.Error(t, nav.GoTo(filepath.Join(root, "unknown"), ocl))
+	require.Error(t, nav.GoTo(filepath.Join(deep, "file"), ocl))
+	require.Equal(t, root, nav.Entry().Path)
+
+	require.NoError(t, nav.GoTo(deep, ocl))
+	require.Equal(t, deep, nav.Entry().Path)
+
+	// the navigation stack is rebuilt, so going back leads to the parents.
+	for _, expected := range []string{filepath.Dir(deep), filepath.Dir(filepath.Dir(deep)), root} {
+		require.True(t, nav.HasParent())
+
+		nav.Up(ocl)
+
+		require.Equal(t, expected, nav.Entry().Path)
+	}
+
+	require.False(t, nav.HasParent())
+
+	// the entry is deleted from its own parent rather than the current entry.
+	file := tree.Find(filepath.Join(deep, "file"))
+	require.NotNil(t, file)
+	require.NoError(t, nav.Delete(file))
+	require.Nil(t, tree.Find(filepath.Join(deep, "file")))
+	require.NoFileExists(t, filepath.Join(deep, "file"))
+}
+
+func TestGoToModel(t *testing.T) {
+	s := render.InitStyle(render.DefaultColorSchema())
+	render.InitKeyMap(nil, s)
+
+	root := t.TempDir()
+
+	for _, dir := range []string{"downloads/docs", "documents", "music"} {
+		require.NoError(t, os.MkdirAll(filepath.Join(root, dir), 0750))
+	}
+
+	nav, _ := newTestNavigation(t, root, config.Settings{})
+
+	gm := render.NewGoToModel(nav, "#FFFFFF")
+	gm.Run(100, 40)
+
+	// only the visited root is shown without a pattern.
+	require.Equal(t, root, gm.Selected().Path)
+
+	for _, r := range "dldocs" {
+		gm.Update(tea.KeyPressMsg{Code: r, Text: string(r)})
+	}
+
+	require.Equal(t, filepath.Join(root, "downloads", "docs"), gm.Selected().Path)
+
+	// the collected directories are reused by the next run.
+	gm.Close()
+	gm.Run(100, 40)
+
+	for _, r := range "mus" {
+		gm.Update(tea.KeyPressMsg{Code: r, Text: string(r)})
+	}
+
+	require.Equal(t, filepath.Join(root, "music"), gm.Selected().Path)
+}
+
+func TestNavigation_Bookmarks(t *testing.T) {
+	cfgDir := t.TempDir()
+	settingsPath := filepath.Join(cfgDir, config.FileName)
+
+	settings := "{\n  \"unknownField\": 42,\n  \"unknownObject\": {\"b\": 2, \"a\": 1}\n}\n"
+
+	require.NoError(t, os.WriteFile(settingsPath, []byte(settings), 0600))
+
+	root, other := t.TempDir(), t.TempDir()
+
+	nav, _ := newTestNavigation(t, root, config.Settings{Path: cfgDir})
+
+	require.NoError(t, nav.ToggleBookmark(root))
+	require.NoError(t, nav.ToggleBookmark(other))
+	require.True(t, nav.IsBookmarked(other))
+
+	require.NoError(t, nav.ToggleBookmark(root))
+	require.False(t, nav.IsBookmarked(root))
+	require.Equal(t, []string{other}, nav.Bookmarks())
+
+	data, err := os.ReadFile(settingsPath)
+	require.NoError(t, err)
+	require.JSONEq(
+		t,
+		`{"unknownField": 42, "unknownObject": {"b": 2, "a": 1}, "bookmarks": [`+strconv.Quote(other)+`]}`,
+		string(data),
+	)
+
+	// the other fields keep their order and formatting.
+	require.True(t, strings.HasPrefix(string(data), strings.TrimSuffix(settings, "\n}\n")+",\n"))
+
+	// the bookmark outside the root directory becomes the new root.
+	done, _, err := nav.OpenPath(other)
+	require.NoError(t, err)
+	require.NotNil(t, done)
+
+	<-done
+
+	require.Equal(t, other, nav.Entry().Path)
+	require.False(t, nav.HasParent())
+
+	_, _, err = nav.OpenPath(filepath.Join(other, "unknown"))
+	require.Error(t, err)
+}
+
+func TestNavigation_Ascend(t *testing.T) {
+	root := t.TempDir()
+	deep := filepath.Join(root, "projects", "noxdir", "render")
+
+	require.NoError(t, os.MkdirAll(deep, 0750))
+
+	nav, _ := newTestNavigation(t, root, config.Settings{})
+
+	ocl := func(_ *structure.Entry, _ render.State) {}
+
+	require.NoError(t, nav.GoTo(deep, ocl))
+
+	paths := func() []string {
+		var result []string
+
+		for _, e := range nav.Breadcrumbs() {
+			result = append(result, e.Path)
+		}
+
+		return result
+	}
+
+	require.Equal(
+		t,
+		[]string{root, filepath.Dir(filepath.Dir(deep)), filepath.Dir(deep), deep},
+		paths(),
+	)
+
+	// the levels exceeding the navigation history are ignored.
+	nav.Ascend(4, ocl)
+	require.Equal(t, deep, nav.Entry().Path)
+
+	nav.Ascend(2, ocl)
+	require.Equal(t, filepath.Dir(filepath.Dir(deep)), nav.Entry().Path)
+	require.Equal(t, []string{root, filepath.Dir(filepath.Dir(deep))}, paths())
+
+	nav.Ascend(1, ocl)
+	require.Equal(t, root, nav.Entry().Path)
+	require.False(t, nav.HasParent())
+}
+
+func TestNavigation_Compare(t *testing.T) {
+	left, right := t.TempDir(), t.TempDir()
+
+	files := map[string]string{
+		filepath.Join(left, "same"):               "data",
+		filepath.Join(right, "same"):              "data",
+		filepath.Join(left, "docs", "changed"):    "data",
+		filepath.Join(right, "docs", "changed"):   "more data",
+		filepath.Join(left, "docs", "left_only"):  "data",
+		filepath.Join(right, "docs", "right_one"): "data",
+	}
+
+	for path, data := range files {
+		require.NoError(t, os.MkdirAll(filepath.Dir(path), 0750))
+		require.NoError(t, os.WriteFile(path, []byte(data), 0600))
+	}
+
+	nav, tree := newTestNavigation(t, left, config.Settings{})
+
+	leftTree, rightTree, diff, err := nav.Compare(left, right)
+	require.NoError(t, err)
+
+	require.Equal(t, left, leftTree.Root().Path)
+	require.Equal(t, right, rightTree.Root().Path)
+
+	require.Len(t, diff.Removed, 1)
+	require.Equal(t, filepath.Join(left, "docs", "left_only"), diff.Removed[0].Path)
+
+	require.Len(t, diff.Added, 1)
+	require.Equal(t, filepath.Join(right, "docs", "right_one"), diff.Added[0].Path)
+
+	// both the file and its parent directory have different sizes.
+	changed := make([]string, 0, len(diff.Changed))
+
+	for _, c := range diff.Changed {
+		changed = append(changed, c.New.Path)
+	}
+
+	require.ElementsMatch(
+		t,
+		[]string{filepath.Join(right, "docs"), filepath.Join(right, "docs", "changed")},
+		changed,
+	)
+
+	// the current tree stays intact.
+	require.Same(t, tree.Root(), nav.Entry())
+
+	_, _, _, err = nav.Compare(left, filepath.Join(right, "same"))
+	require.Error(t, err)
+}
+
+func TestNavigation_Trends(t *testing.T) {
+	root := t.TempDir()
+
+	require.NoError(t, os.MkdirAll(filepath.Join(root, "docs"), 0750))
+	require.NoError(t, os.WriteFile(filepath.Join(root, "docs", "file"), []byte("data"), 0600))
+
+	c, err := cache.NewCache(
+		func(w io.Writer) cache.Encoder { return structure.NewEncoder(w) },
+		func(r io.Reader) cache.Decoder { return structure.NewDecoder(r) },
+		false,
+		t.TempDir(),
+	)
+	require.NoError(t, err)
+
+	nav, tree := newTestNavigation(
+		t, root, config.Settings{}, structure.WithCache(c), structure.WithUseCache(),
+	)
+	require.NoError(t, <-tree.PersistCache())
+
+	snapshots, err := tree.Snapshots()
+	require.NoError(t, err)
+	require.Len(t, snapshots, 1)
+
+	// the older snapshot in an unknown format must be skipped.
+	legacy := filepath.Join(filepath.Dir(snapshots[0].Path), "1")
+	require.NoError(t, os.WriteFile(legacy, []byte("legacy"), 0600))
+
+	trends, skipped, err := nav.Trends(1)
+	require.NoError(t, err)
+	require.Equal(t, 1, skipped)
+	require.NotEmpty(t, trends)
+}
+
+// newTestNavigation creates the navigation over a partial tree of the root
+// directory. The tree options are applied after the partial root option.
+func newTestNavigation(
+	t *testing.T,
+	root string,
+	settings config.Settings,
+	opts ...structure.TreeOpt,
+) (*render.Navigation, *structure.Tree) {
+	t.Helper()
+
+	tree := structure.NewTree(
+		structure.NewDirEntry(root, 0),
+		append([]structure.TreeOpt{structure.WithPartialRoot()}, opts...)...,
+	)
+
+	nav, err := render.NewRootNavigation(tree, settings)
+	require.NoError(t, err)
+
+	return nav, tree
+}
@@ -0,0 +1,291 @@
+package render
+
+import (
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/crumbyte/noxdir/filter"
+
+	"charm.land/bubbles/v2/textinput"
+	tea "charm.land/bubbletea/v2"
+	"charm.land/lipgloss/v2"
+	"github.com/charmbracelet/bubbles/help"
+	"github.com/charmbracelet/bubbles/key"
+)
+
+const (
+	maxPathCandidates = 10
+
+	pathPromptRatio = 0.6
+)
+
+// PathPromptModel is a text prompt for jumping to an arbitrary path or for
+// choosing the directory to compare with. The relative paths are resolved
+// against the current directory, and the last path segment is completed from
+// the child directories of the in-memory tree or from the file system if the
+// directory was not scanned.
+type PathPromptModel struct {
+	nav         *Navigation
+	input       textinput.Model
+	help        help.Model
+	candidates  []string
+	compareWith string
+	err         error
+	width       int
+	active      bool
+}
+
+func NewPathPromptModel(n *Navigation, textColor string) *PathPromptModel {
+	ti := filter.NewInput(
+		textColor,
+		`Path… Examples: "~/Downloads", "../docs"`,
+		"\uF07B  ",
+	)
+
+	return &PathPromptModel{nav: n, input: ti, help: help.New()}
+}
+
+func (pm *PathPromptModel) Init() tea.Cmd {
+	return nil
+}
+
+func (pm *PathPromptModel) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
+	switch msg := msg.(type) {
+	case tea.WindowSizeMsg:
+		pm.width = int(float64(msg.Width) * pathPromptRatio)
+		pm.input.SetWidth(pm.width - lipgloss.Width(pm.input.Prompt))
+	case tea.KeyPressMsg:
+		if key.Matches(msg, Bindings.Finder.Complete) {
+			pm.complete()
+
+			return pm, nil
+		}
+
+		value := pm.input.Value()
+
+		pm.input, _ = pm.input.Update(msg)
+
+		if pm.input.Value() != value {
+			pm.candidates, pm.err = nil, nil
+		}
+	}
+
+	return pm, nil
+}
+
+func (pm *PathPromptModel) View() tea.View {
+	rows := make([]string, 0, 4)
+	open := Bindings.Finder.Open
+
+	if len(pm.compareWith) != 0 {
+		rows = append(
+			rows,
+			lipgloss.NewStyle().
+				Align(lipgloss.Center).
+				Width(pm.width).
+				Bold(true).
+				Faint(true).
+				Render("Compare "+pm.compareWith+" with:"),
+		)
+
+		open.SetHelp(open.Help().Key, style.Help().Render(" - compare"))
+	}
+
+	rows = append(rows, pm.input.View())
+
+	messageStyle := lipgloss.NewStyle().Width(pm.width)
+
+	switch {
+	case pm.err != nil:
+		rows = append(rows, messageStyle.Bold(true).Render(pm.err.Error()))
+	case len(pm.candidates) > 0:
+		rows = append(rows, messageStyle.Faint(true).Render(
+			strings.Join(pm.candidates, "  "),
+		))
+	}
+
+	rows = append(
+		rows,
+		lipgloss.NewStyle().Width(pm.width).Align(lipgloss.Center).Render(
+			pm.help.ShortHelpView([]key.Binding{
+				Bindings.Finder.Complete,
+				open,
+				Bindings.Finder.Close,
+			}),
+		),
+	)
+
+	return tea.NewView(
+		style.DialogBox().Render(
+			lipgloss.NewStyle().Padding(0, 1, 0, 1).Render(
+				lipgloss.JoinVertical(lipgloss.Top, rows...),
+			),
+		),
+	)
+}
+
+// Open shows the prompt prefilled with the current directory path.
+func (pm *PathPromptModel) Open() {
+	value := ""
+
+	if !pm.nav.OnDrives() {
+		value = pm.nav.Entry().Path
+	}
+
+	pm.open(value, "")
+}
+
+// OpenCompare shows the prompt for choosing the directory to compare the
+// provided one with. The prompt is prefilled with the parent directory path,
+// so the sibling directories are completed first.
+func (pm *PathPromptModel) OpenCompare(left string) {
+	pm.open(filepath.Dir(left), left)
+}
+
+// CompareWith returns the directory to compare the typed path with or an empty
+// string if the prompt was opened for jumping to the path.
+func (pm *PathPromptModel) CompareWith() string {
+	return pm.compareWith
+}
+
+func (pm *PathPromptModel) open(value, compareWith string) {
+	pm.active, pm.err, pm.candidates = true, nil, nil
+	pm.compareWith = compareWith
+
+	if len(value) != 0 && !strings.HasSuffix(value, string(os.PathSeparator)) {
+		value += string(os.PathSeparator)
+	}
+
+	pm.input.SetValue(value)
+	pm.input.CursorEnd()
+}
+
+// Close hides the prompt.
+func (pm *PathPromptModel) Close() {
+	pm.active = false
+}
+
+// Active tells whether the prompt is shown.
+func (pm *PathPromptModel) Active() bool {
+	return pm.active
+}
+
+// SetError shows the error under the prompt until the input is changed.
+func (pm *PathPromptModel) SetError(err error) {
+	pm.err = err
+}
+
+// Path returns the absolute path of the typed value.
+func (pm *PathPromptModel) Path() string {
+	return pm.resolve(pm.input.Value())
+}
+
+// complete completes the last segment of the typed path. A single matching
+// directory is completed entirely, while multiple matches are completed up to
+// their common prefix and shown as candidates.
+func (pm *PathPromptModel) complete() {
+	value := pm.input.Value()
+
+	idx := strings.LastIndexAny(value, "/"+string(os.PathSeparator))
+	head, prefix := value[:idx+1], value[idx+1:]
+
+	matches := make([]string, 0)
+
+	for _, name := range pm.childDirs(pm.resolve(head)) {
+		if strings.HasPrefix(name, prefix) {
+			matches = append(matches, name)
+		}
+	}
+
+	slices.Sort(matches)
+
+	pm.candidates, pm.err = nil, nil
+
+	switch len(matches) {
+	case 0:
+		return
+	case 1:
+		value = head + matches[0] + string(os.PathSeparator)
+	default:
+		value = head + commonPrefix(matches)
+		pm.candidates = matches[:min(len(matches), maxPathCandidates)]
+	}
+
+	pm.input.SetValue(value)
+	pm.input.CursorEnd()
+}
+
+// childDirs returns the names of the directory's child directories. The tree
+// is used if it contains the directory, so no disk access is required.
+func (pm *PathPromptModel) childDirs(dir string) []string {
+	names := make([]string, 0)
+
+	if !pm.nav.OnDrives() {
+		if e := pm.nav.tree.Find(dir); e != nil && e.IsDir {
+			for child := range e.EntriesByType(true) {
+				names = append(names, child.Name())
+			}
+
+			return names
+		}
+	}
+
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return names
+	}
+
+	for _, e := range entries {
+		if e.IsDir() {
+			names = append(names, e.Name())
+		}
+	}
+
+	return names
+}
+
+// resolve converts the typed value to an absolute path. The "~" prefix is
+// replaced with the home directory, and the relative paths are resolved
+// against the current directory.
+func (pm *PathPromptModel) resolve(value string) string {
+	value = strings.TrimSpace(value)
+
+	if value == "~" || strings.HasPrefix(value, "~/") ||
+		strings.HasPrefix(value, "~"+string(os.PathSeparator)) {
+		if home, err := os.UserHomeDir(); err == nil {
+			value = filepath.Join(home, value[1:])
+		}
+	}
+
+	if !filepath.IsAbs(value) {
+		base, _ := os.Getwd()
+
+		if !pm.nav.OnDrives() {
+			base = pm.nav.Entry().Path
+		}
+
+		value = filepath.Join(base, value)
+	}
+
+	return filepath.Clean(value)
+}
+
+func commonPrefix(values []string) string {
+	if len(values) == 0 {
+		return ""
+	}
+
+	prefix := values[0]
+
+	for _, v := range values[1:] {
+		for !strings.HasPrefix(v, prefix) {
+			_, size := utf8.DecodeLastRuneInString(prefix)
+			prefix = prefix[:len(prefix)-size]
+		}
+	}
+
+	return prefix
+}
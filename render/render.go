@@ -1,6 +1,10 @@
 package render
 
 import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
 	"time"
 
 	"github.com/crumbyte/noxdir/drive"
@@ -24,6 +28,10 @@ type (
 	UpdateDirState struct{}
 	ScanFinished   struct{ Mode Mode }
 	EnqueueRefresh struct{ Mode Mode }
+
+	// GoToDir changes the current directory to the provided path at any depth
+	// of the current tree.
+	GoToDir struct{ Path string }
 )
 
 var teaProg *tea.Program
@@ -31,7 +39,10 @@ var teaProg *tea.Program
 type ViewModel struct {
 	driveModel *DriveModel
 	dirModel   *DirModel
+	bookmarks  *BookmarksModel
+	pathPrompt *PathPromptModel
 	nav        *Navigation
+	loadErr    error
 	lastErr    []error
 }
 
@@ -41,6 +52,8 @@ func NewViewModel(n *Navigation, driveModel *DriveModel, dirMode *DirModel) *Vie
 		nav:        n,
 		driveModel: driveModel,
 		dirModel:   dirMode,
+		bookmarks:  NewBookmarksModel(n),
+		pathPrompt: NewPathPromptModel(n, style.CS().FilterText),
 	}
 }
 
@@ -58,8 +71,27 @@ func (vm *ViewModel) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 		return vm, tea.Batch(cmd, dirModelCMD)
 	case EnqueueRefresh:
 		vm.refresh(msg.Mode)
+	case tea.WindowSizeMsg:
+		vm.bookmarks.Update(msg)
+		vm.pathPrompt.Update(msg)
+	case tea.MouseClickMsg:
+		if vm.bookmarks.Active() || vm.pathPrompt.Active() {
+			return vm, nil
+		}
 	case tea.KeyPressMsg:
-		if vm.dirModel.mode == INPUT || vm.dirModel.mode == CMD {
+		if vm.bookmarks.Active() {
+			vm.handleBookmarks(msg)
+
+			return vm, nil
+		}
+
+		if vm.pathPrompt.Active() {
+			vm.handlePathPrompt(msg)
+
+			return vm, nil
+		}
+
+		if vm.dirModel.typing() {
 			break
 		}
 
@@ -71,6 +103,8 @@ func (vm *ViewModel) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 		case key.Matches(msg, Bindings.Refresh):
 			vm.refresh(READY)
 		case key.Matches(msg, Bindings.Quit):
+			_ = vm.nav.PersistVisits()
+
 			return vm, tea.Quit
 		case key.Matches(msg, Bindings.Drive.LevelDown):
 			vm.levelDown()
@@ -78,12 +112,32 @@ func (vm *ViewModel) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 			vm.levelUp()
 		case key.Matches(msg, Bindings.Dirs.ToDrives):
 			vm.toDrives()
+		case key.Matches(msg, Bindings.Bookmarks):
+			if vm.nav.OnDrives() || vm.dirModel.mode == READY {
+				vm.bookmarks.Open()
+
+				return vm, nil
+			}
+		case key.Matches(msg, Bindings.Dirs.Compare):
+			if !vm.nav.OnDrives() && vm.dirModel.mode == READY {
+				vm.compare()
+
+				return vm, nil
+			}
+		case key.Matches(msg, Bindings.PathPrompt):
+			if vm.nav.OnDrives() || vm.dirModel.mode == READY {
+				vm.pathPrompt.Open()
+
+				return vm, nil
+			}
 		}
 	}
 
 	vm.driveModel.Update(msg)
 	vm.dirModel.Update(msg)
 
+	vm.reportLoadErr()
+
 	return vm, tea.Batch(cmd)
 }
 
@@ -96,9 +150,27 @@ func (vm *ViewModel) View() tea.View {
 		v = vm.dirModel.View()
 	}
 
+	if vm.bookmarks.Active() {
+		v.SetContent(OverlayCenter(
+			vm.dirModel.width,
+			vm.dirModel.height,
+			v.Content,
+			vm.bookmarks.View().Content,
+		))
+	}
+
+	if vm.pathPrompt.Active() {
+		v.SetContent(OverlayCenter(
+			vm.dirModel.width,
+			vm.dirModel.height,
+			v.Content,
+			vm.pathPrompt.View().Content,
+		))
+	}
+
 	v.AltScreen = true
 	v.WindowTitle = "NoxDir " + Version
-	v.MouseMode = tea.MouseModeNone
+	v.MouseMode = tea.MouseModeCellMotion
 
 	return v
 }
@@ -117,7 +189,16 @@ func (vm *ViewModel) levelDown() {
 		sr = vm.driveModel.drivesTable.SelectedRow()
 	}
 
-	if sr != nil && len(sr.Cols) < 2 {
+	if sr == nil || len(sr.Cols) < 2 {
+		return
+	}
+
+	// the nested rows of the tree view are opened by their full paths.
+	if !vm.nav.OnDrives() && strings.ContainsRune(sr.Cols[1], os.PathSeparator) {
+		if e := vm.dirModel.rowEntry(sr); e != nil && e.IsDir {
+			vm.dirModel.changeDir(e.Path)
+		}
+
 		return
 	}
 
@@ -135,31 +216,7 @@ func (vm *ViewModel) levelDown() {
 		return
 	}
 
-	go func() {
-		vm.lastErr = []error{}
-
-		ticker := time.NewTicker(updateTickerInterval)
-		defer func() {
-			ticker.Stop()
-		}()
-
-		teaProg.Send(UpdateDirState{})
-
-		for {
-			select {
-			case err := <-errChan:
-				if err != nil {
-					vm.lastErr = append(vm.lastErr, err)
-				}
-			case <-ticker.C:
-				teaProg.Send(UpdateDirState{})
-			case <-done:
-				teaProg.Send(ScanFinished{Mode: READY})
-
-				return
-			}
-		}
-	}()
+	vm.awaitScan(done, errChan, ScanFinished{Mode: READY})
 }
 
 func (vm *ViewModel) levelUp() {
@@ -208,6 +265,17 @@ func (vm *ViewModel) refresh(mode Mode) {
 		return
 	}
 
+	// the tree with the cached entries that cannot be loaded is rescanned
+	// entirely, since the affected directories are shown empty.
+	if vm.nav.tree.LoadErr() != nil {
+		if done, errChan := vm.nav.RescanRoot(); done != nil {
+			vm.dirModel.dirsTable.ResetMarked()
+			vm.awaitScan(done, errChan, ScanFinished{Mode: mode})
+		}
+
+		return
+	}
+
 	done, errChan, err := vm.nav.RefreshEntry()
 	if err != nil {
 		// TODO: the error might occur only if there were no directories in stack
@@ -218,6 +286,129 @@ func (vm *ViewModel) refresh(mode Mode) {
 		return
 	}
 
+	vm.awaitScan(done, errChan, ScanFinished{Mode: mode})
+}
+
+// reportLoadErr shows the error that occurred while loading the cached entries
+// of the tree. The directories that failed to load are shown empty, so the user
+// is offered to rescan the tree root. Each error is shown only once.
+func (vm *ViewModel) reportLoadErr() {
+	err := vm.nav.tree.LoadErr()
+	if err == nil || err == vm.loadErr { //nolint:errorlint // the same instance
+		return
+	}
+
+	vm.loadErr = err
+
+	vm.dirModel.errPopup.Show(fmt.Sprintf(
+		"The cached data cannot be read: %s. Press %q to rescan %s.",
+		err, Bindings.Refresh.Help().Key, vm.nav.tree.Root().Path,
+	))
+}
+
+func (vm *ViewModel) handleBookmarks(msg tea.KeyPressMsg) {
+	switch {
+	case key.Matches(msg, Bindings.Bookmarks, Bindings.Finder.Close):
+		vm.bookmarks.Close()
+	case key.Matches(msg, Bindings.Finder.Open):
+		if path := vm.bookmarks.Selected(); len(path) != 0 {
+			if err := vm.openPath(path); err != nil {
+				vm.bookmarks.SetError(err)
+
+				return
+			}
+
+			vm.bookmarks.Close()
+		}
+	default:
+		vm.bookmarks.Update(msg)
+	}
+}
+
+func (vm *ViewModel) handlePathPrompt(msg tea.KeyPressMsg) {
+	switch {
+	case key.Matches(msg, Bindings.PathPrompt, Bindings.Finder.Close):
+		vm.pathPrompt.Close()
+	case key.Matches(msg, Bindings.Finder.Open):
+		if left := vm.pathPrompt.CompareWith(); len(left) != 0 {
+			vm.pathPrompt.Close()
+			vm.dirModel.compare(left, vm.pathPrompt.Path())
+
+			return
+		}
+
+		if err := vm.openPath(vm.pathPrompt.Path()); err != nil {
+			vm.pathPrompt.SetError(err)
+
+			return
+		}
+
+		vm.pathPrompt.Close()
+	default:
+		vm.pathPrompt.Update(msg)
+	}
+}
+
+// compare compares two marked directories. If there are no two marked
+// directories, the current directory is compared with the one chosen in the
+// path prompt.
+func (vm *ViewModel) compare() {
+	marked := make([]*structure.Entry, 0, 2)
+
+	for _, r := range vm.dirModel.dirsTable.MarkedRows() {
+		if e := vm.dirModel.rowEntry(&r); e != nil && e.IsDir {
+			marked = append(marked, e)
+		}
+	}
+
+	if len(marked) == 2 {
+		vm.dirModel.compare(marked[0].Path, marked[1].Path)
+
+		return
+	}
+
+	vm.pathPrompt.OpenCompare(vm.nav.Entry().Path)
+}
+
+// openPath changes the current directory to the provided path. If the path
+// does not belong to the current tree, the corresponding drive or root is
+// scanned first. If the path points to a file within the current tree, its
+// directory is opened and the file is selected.
+func (vm *ViewModel) openPath(path string) error {
+	if !vm.nav.OnDrives() {
+		if e := vm.nav.tree.Find(path); e != nil {
+			if e.IsDir {
+				vm.dirModel.changeDir(path)
+			} else if vm.dirModel.changeDir(filepath.Dir(path)) {
+				vm.dirModel.selectEntry(e.Name())
+			}
+
+			return nil
+		}
+	}
+
+	done, errChan, err := vm.nav.OpenPath(path)
+	if err != nil {
+		return err
+	}
+
+	if done == nil {
+		return nil
+	}
+
+	vm.dirModel.Clear()
+	vm.dirModel.filters.Reset()
+	vm.dirModel.dirsTable.ResetMarked()
+	vm.driveModel.drivesTable.ResetMarked()
+
+	vm.awaitScan(done, errChan, ScanFinished{Mode: READY}, GoToDir{Path: path})
+
+	return nil
+}
+
+// awaitScan sends the scan state updates until the scan is done, and then
+// sends the provided messages in order.
+func (vm *ViewModel) awaitScan(done chan struct{}, errChan chan error, finished ...tea.Msg) {
 	go func() {
 		vm.lastErr = []error{}
 
@@ -230,14 +421,16 @@ func (vm *ViewModel) refresh(mode Mode) {
 
 		for {
 			select {
-			case err = <-errChan:
+			case err := <-errChan:
 				if err != nil {
 					vm.lastErr = append(vm.lastErr, err)
 				}
 			case <-ticker.C:
 				teaProg.Send(UpdateDirState{})
 			case <-done:
-				teaProg.Send(ScanFinished{Mode: mode})
+				for _, msg := range finished {
+					teaProg.Send(msg)
+				}
 
 				return
 			}
@@ -0,0 +1,331 @@
+package render
+
+import (
+	"fmt"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/crumbyte/noxdir/filter"
+	"github.com/crumbyte/noxdir/render/table"
+	"github.com/crumbyte/noxdir/structure"
+
+	"charm.land/bubbles/v2/textinput"
+	tea "charm.land/bubbletea/v2"
+	"charm.land/lipgloss/v2"
+	"github.com/charmbracelet/bubbles/key"
+)
+
+const (
+	// searchStepSize defines the number of entries checked per a single search
+	// step. The steps are interleaved with the UI updates, so the search does
+	// not freeze the UI on large trees.
+	searchStepSize = 50_000
+
+	// maxSearchResults limits the number of the shown results. The search
+	// stops once the limit is reached.
+	maxSearchResults = 10_000
+
+	searchRatio = 0.8
+)
+
+// SearchStep triggers the next step of the search identified by the id. The
+// steps of a replaced or closed search are ignored.
+type SearchStep struct {
+	id int
+}
+
+// SearchModel searches the entries within the current active entry and its
+// entire subtree by the name pattern. The results are streamed into the table
+// while the tree is being walked.
+type SearchModel struct {
+	nav     *Navigation
+	root    *structure.Entry
+	search  *structure.Search
+	input   textinput.Model
+	table   *table.Model
+	columns []table.Column
+	results []*structure.Entry
+	err     error
+	size    int64
+	id      int
+	width   int
+	height  int
+	active  bool
+}
+
+func NewSearchModel(n *Navigation, textColor string) *SearchModel {
+	ti := filter.NewInput(
+		textColor,
+		`Search… Examples: "vmdk" (name contains), "*.vmdk" (glob pattern)`,
+		"\uF002  ",
+	)
+
+	return &SearchModel{
+		nav:   n,
+		input: ti,
+		table: buildTable(),
+		columns: []table.Column{
+			{Title: ""},
+			{Title: ""},
+			{Title: "Path"},
+			{Title: "Size"},
+			{Title: "Last Change"},
+		},
+	}
+}
+
+func (sm *SearchModel) Init() tea.Cmd {
+	return nil
+}
+
+func (sm *SearchModel) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
+	switch msg := msg.(type) {
+	case tea.WindowSizeMsg:
+		sm.resize(msg.Width, msg.Height)
+		sm.updateTableData()
+	case SearchStep:
+		if msg.id == sm.id && sm.search != nil {
+			sm.step()
+		}
+	case tea.KeyPressMsg:
+		switch {
+		case key.Matches(msg, Bindings.Finder.Up):
+			sm.table.MoveUp(1)
+		case key.Matches(msg, Bindings.Finder.Down):
+			sm.table.MoveDown(1)
+		case key.Matches(msg, Bindings.Finder.PageUp):
+			sm.table.MoveUp(sm.table.Height())
+		case key.Matches(msg, Bindings.Finder.PageDown):
+			sm.table.MoveDown(sm.table.Height())
+		case key.Matches(msg, Bindings.Finder.Mark):
+			if sm.table.SelectedRow() != nil {
+				sm.table.MarkSelected()
+				sm.table.MoveDown(1)
+			}
+		default:
+			value := sm.input.Value()
+
+			sm.input, _ = sm.input.Update(msg)
+
+			if sm.input.Value() != value {
+				sm.Restart()
+			}
+		}
+	}
+
+	return sm, nil
+}
+
+func (sm *SearchModel) View() tea.View {
+	messageStyle := lipgloss.NewStyle().
+		Align(lipgloss.Center).
+		Width(sm.width).
+		Bold(true)
+
+	header := messageStyle.Faint(true).Render(sm.viewHeader())
+
+	help := lipgloss.NewStyle().Width(sm.width).Align(lipgloss.Center).Render(
+		sm.table.Help.ShortHelpView([]key.Binding{
+			Bindings.Finder.Open,
+			Bindings.Finder.Mark,
+			Bindings.Finder.Delete,
+			Bindings.Finder.Close,
+		}),
+	)
+
+	input := sm.input.View()
+
+	sm.table.SetHeight(
+		sm.height - lipgloss.Height(header) - lipgloss.Height(input) -
+			lipgloss.Height(help),
+	)
+
+	return tea.NewView(
+		style.DialogBox().Render(
+			lipgloss.NewStyle().Padding(0, 1, 0, 1).Render(
+				lipgloss.JoinVertical(
+					lipgloss.Top, header, input, sm.table.View().Content, help,
+				),
+			),
+		),
+	)
+}
+
+// Run opens the search within the current active entry. The previous search
+// pattern is kept, so the search is restarted for the new entry immediately.
+func (sm *SearchModel) Run(width, height int) {
+	sm.root, sm.active = sm.nav.Entry(), true
+
+	sm.resize(width, height)
+	sm.Restart()
+}
+
+// Close stops the running search, if any, and marks the model as inactive.
+func (sm *SearchModel) Close() {
+	sm.active, sm.search = false, nil
+	sm.id++
+}
+
+// Active tells whether the search is opened.
+func (sm *SearchModel) Active() bool {
+	return sm.active
+}
+
+// Restart cancels the running search and starts a new one with the current
+// pattern. The search results are cleared, including the marked rows.
+func (sm *SearchModel) Restart() {
+	sm.id++
+	sm.search, sm.results, sm.err, sm.size = nil, nil, nil, 0
+
+	sm.table.ResetMarked()
+	sm.updateTableData()
+
+	pattern := strings.TrimSpace(sm.input.Value())
+	if len(pattern) == 0 || sm.root == nil {
+		return
+	}
+
+	sm.search, sm.err = structure.NewSearch(sm.root, pattern)
+	if sm.err != nil {
+		return
+	}
+
+	sm.next()
+}
+
+// Selected returns the entry of the selected search result or nil if there are
+// no results.
+func (sm *SearchModel) Selected() *structure.Entry {
+	sr := sm.table.SelectedRow()
+	if sr == nil {
+		return nil
+	}
+
+	return sm.nav.tree.Find(sr.Cols[0])
+}
+
+// Marked returns the entries of the marked search results. The entries that no
+// longer exist in the tree are skipped.
+func (sm *SearchModel) Marked() []*structure.Entry {
+	marked := make([]*structure.Entry, 0)
+
+	for _, r := range sm.table.MarkedRows() {
+		if e := sm.nav.tree.Find(r.Cols[0]); e != nil {
+			marked = append(marked, e)
+		}
+	}
+
+	return marked
+}
+
+// step runs a single search step and appends the matched entries to the
+// results. The next step is scheduled until the tree is walked entirely or the
+// results limit is reached.
+func (sm *SearchModel) step() {
+	matched, done := sm.search.Step(searchStepSize)
+
+	matched = matched[:min(len(matched), maxSearchResults-len(sm.results))]
+
+	rows := sm.table.Rows()
+
+	for _, e := range matched {
+		sm.size += e.Size
+		sm.results = append(sm.results, e)
+
+		rows = append(rows, sm.row(e))
+	}
+
+	sm.table.SetRows(rows)
+
+	if done || len(sm.results) >= maxSearchResults {
+		sm.search = nil
+
+		return
+	}
+
+	sm.next()
+}
+
+func (sm *SearchModel) next() {
+	id := sm.id
+
+	go func() {
+		teaProg.Send(SearchStep{id: id})
+	}()
+}
+
+func (sm *SearchModel) viewHeader() string {
+	switch {
+	case sm.err != nil:
+		return sm.err.Error()
+	case sm.root == nil || len(strings.TrimSpace(sm.input.Value())) == 0:
+		return "Type the name pattern to search within the current directory"
+	}
+
+	status := "Found"
+
+	if sm.search != nil {
+		status = "Searching"
+	}
+
+	header := fmt.Sprintf(
+		"%s %d entries (%s) within %s",
+		status,
+		len(sm.results),
+		FmtSize(sm.size, 0),
+		sm.root.Path,
+	)
+
+	if len(sm.results) >= maxSearchResults {
+		header += fmt.Sprintf(", showing the first %d", maxSearchResults)
+	}
+
+	return header
+}
+
+func (sm *SearchModel) resize(width, height int) {
+	sm.width = int(float64(width) * searchRatio)
+	sm.height = int(float64(height) * searchRatio)
+
+	sm.table.SetWidth(sm.width)
+	sm.input.SetWidth(sm.width - lipgloss.Width(sm.input.Prompt))
+}
+
+func (sm *SearchModel) updateTableData() {
+	iconWidth, sizeWidth, dateWidth := 5, 15, 15
+
+	sm.columns[0].Width = 0
+	sm.columns[1].Width = iconWidth
+	sm.columns[2].Width = max(sm.width-iconWidth-sizeWidth-dateWidth, 0)
+	sm.columns[3].Width = sizeWidth
+	sm.columns[4].Width = dateWidth
+
+	sm.table.SetColumns(sm.columns)
+
+	rows := make([]table.Row, 0, len(sm.results))
+
+	for _, e := range sm.results {
+		rows = append(rows, sm.row(e))
+	}
+
+	sm.table.SetRows(rows)
+	sm.table.SetCursor(sm.table.Cursor())
+}
+
+func (sm *SearchModel) row(e *structure.Entry) table.Row {
+	relPath := strings.TrimPrefix(
+		strings.TrimPrefix(e.Path, sm.root.Path),
+		string(filepath.Separator),
+	)
+
+	return table.Row{
+		Cols: []string{
+			e.Path,
+			EntryIcon(e),
+			WrapString(relPath, sm.columns[2].Width),
+			FmtSizeColor(e.Size, entrySizeWidth),
+			Faint(time.Unix(e.ModTime, 0).Format("02 Jan 2006")),
+		},
+	}
+}
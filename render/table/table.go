@@ -272,6 +272,18 @@ func (m *Model) ToggleMarkAll() {
 	}
 }
 
+// MarkRows marks the rows matching the provided function in addition to the
+// already marked rows.
+func (m *Model) MarkRows(match func(r Row) bool) {
+	for i, r := range m.rows {
+		if match(r) {
+			m.marked[i] = struct{}{}
+		}
+	}
+
+	m.UpdateViewport()
+}
+
 func (m *Model) ResetMarked() {
 	m.marked = make(map[int]struct{})
 }
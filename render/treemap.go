@@ -0,0 +1,437 @@
+package render
+
+import (
+	"fmt"
+	"image/color"
+	"math"
+	"slices"
+	"strings"
+
+	"github.com/crumbyte/noxdir/pkg/treemap"
+	"github.com/crumbyte/noxdir/structure"
+
+	tea "charm.land/bubbletea/v2"
+	"charm.land/lipgloss/v2"
+	"github.com/charmbracelet/bubbles/key"
+)
+
+const (
+	// maxTreemapTiles limits the number of the top-level tiles. The smallest
+	// entries that exceed the limit are not shown, since they would not fit
+	// into a single terminal cell anyway.
+	maxTreemapTiles = 150
+
+	// treemapRatio defines the treemap size with respect to the terminal window.
+	treemapRatio = 0.9
+)
+
+// treemapCell represents a single terminal cell of the treemap canvas.
+type treemapCell struct {
+	fg   color.Color
+	char rune
+	bold bool
+}
+
+// treemapTile represents a top-level entry rectangle in terminal cells. The
+// bounds are half-open: [x0, x1) and [y0, y1).
+type treemapTile struct {
+	entry  *structure.Entry
+	color  color.Color
+	x0, y0 int
+	x1, y1 int
+}
+
+func (t treemapTile) center() (float64, float64) {
+	return float64(t.x0+t.x1) / 2, float64(t.y0+t.y1) / 2
+}
+
+// TreemapModel renders the child entries of the current directory as nested
+// rectangles with areas proportional to their sizes. The first level contains
+// the child entries of the current directory, and the second level contains
+// their own child entries if there is enough space.
+type TreemapModel struct {
+	nav       *Navigation
+	tiles     []treemapTile
+	canvas    [][]treemapCell
+	layoutKey string
+	cursor    int
+	selected  int
+	width     int
+	height    int
+}
+
+func NewTreemapModel(n *Navigation) *TreemapModel {
+	return &TreemapModel{nav: n}
+}
+
+func (tm *TreemapModel) Init() tea.Cmd {
+	return nil
+}
+
+func (tm *TreemapModel) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
+	switch msg := msg.(type) {
+	case tea.WindowSizeMsg:
+		tm.Resize(msg.Width, msg.Height)
+	case tea.KeyPressMsg:
+		switch {
+		case key.Matches(msg, Bindings.Treemap.Up):
+			tm.move(0, -1)
+		case key.Matches(msg, Bindings.Treemap.Down):
+			tm.move(0, 1)
+		case key.Matches(msg, Bindings.Treemap.Left):
+			tm.move(-1, 0)
+		case key.Matches(msg, Bindings.Treemap.Right):
+			tm.move(1, 0)
+		}
+	}
+
+	return tm, nil
+}
+
+func (tm *TreemapModel) View() tea.View {
+	entry := tm.nav.Entry()
+	if entry == nil {
+		return tea.NewView("")
+	}
+
+	tm.layout(entry)
+
+	header := lipgloss.NewStyle().Bold(true).Width(tm.width).Render(
+		WrapString(entry.Path, tm.width) + "  " + FmtSize(entry.Size, 0),
+	)
+
+	footer := ""
+
+	if selected := tm.Selected(); selected != nil {
+		footer = lipgloss.NewStyle().Faint(true).Width(tm.width).Render(
+			EntryIcon(selected) + " " + WrapString(selected.Name(), tm.width/2) +
+				"  " + FmtSize(selected.Size, 0) + "  " +
+				FmtUsage(float64(selected.Size)/float64(max(entry.Size, 1)), 100),
+		)
+	}
+
+	return tea.NewView(
+		style.DialogBox().Render(
+			lipgloss.JoinVertical(lipgloss.Top, header, tm.render(), footer),
+		),
+	)
+}
+
+// Resize sets the treemap size with respect to the terminal window size.
+func (tm *TreemapModel) Resize(width, height int) {
+	tm.width = int(float64(width) * treemapRatio)
+	tm.height = int(float64(height)*treemapRatio) - 4
+}
+
+// Selected returns the entry of the selected tile or nil if there are no tiles.
+func (tm *TreemapModel) Selected() *structure.Entry {
+	if tm.cursor < 0 || tm.cursor >= len(tm.tiles) {
+		return nil
+	}
+
+	return tm.tiles[tm.cursor].entry
+}
+
+// Reset drops the current layout, so it will be rebuilt on the next render. If
+// the entry with the provided path is shown after that, it will be selected.
+func (tm *TreemapModel) Reset(selectPath string) {
+	tm.layoutKey, tm.cursor = "", 0
+
+	entry := tm.nav.Entry()
+	if entry == nil || len(selectPath) == 0 {
+		return
+	}
+
+	tm.layout(entry)
+
+	for i, t := range tm.tiles {
+		if t.entry.Path == selectPath {
+			tm.cursor = i
+
+			return
+		}
+	}
+}
+
+// layout builds the tiles and draws them on the canvas for the provided entry,
+// including the nested entries of each tile. The layout is rebuilt only if the
+// entry or the treemap size has changed.
+func (tm *TreemapModel) layout(entry *structure.Entry) {
+	if tm.width <= 2 || tm.height <= 2 {
+		return
+	}
+
+	layoutKey := fmt.Sprintf("%s|%d|%d|%d", entry.Path, entry.Size, tm.width, tm.height)
+
+	if layoutKey == tm.layoutKey {
+		return
+	}
+
+	tm.layoutKey = layoutKey
+	tm.canvas = make([][]treemapCell, tm.height)
+
+	for y := range tm.canvas {
+		tm.canvas[y] = make([]treemapCell, tm.width)
+
+		for x := range tm.canvas[y] {
+			tm.canvas[y][x].char = ' '
+		}
+	}
+
+	colors := style.ChartColors()
+	children := treemapEntries(entry)
+
+	tm.tiles = tm.tiles[:0]
+
+	for i, rect := range tm.squarify(children, 0, 0, tm.width, tm.height) {
+		tile := treemapTile{
+			entry: children[i],
+			color: colors[i%len(colors)],
+			x0:    rect[0], y0: rect[1], x1: rect[2], y1: rect[3],
+		}
+
+		// the tiles that are too small to be rendered are not shown and
+		// cannot be selected.
+		if tile.x1 <= tile.x0 || tile.y1 <= tile.y0 {
+			continue
+		}
+
+		tm.tiles = append(tm.tiles, tile)
+		tm.drawTile(tile)
+	}
+
+	tm.cursor = min(tm.cursor, max(len(tm.tiles)-1, 0))
+	tm.selected = -1
+}
+
+// squarify lays out the entries within the provided cell area and returns the
+// bounds of each entry in cells: x0, y0, x1, y1. The layout is calculated with
+// respect to the terminal cell aspect ratio, so the tiles look square-like.
+func (tm *TreemapModel) squarify(entries []*structure.Entry, x, y, w, h int) [][4]int {
+	aspect := max(style.CS().ChartColors.AspectRatioFix, 1)
+	values := make([]float64, 0, len(entries))
+
+	for _, e := range entries {
+		values = append(values, float64(e.Size))
+	}
+
+	rects := treemap.Squarify(
+		values, treemap.Rect{W: float64(w), H: float64(h) * aspect},
+	)
+
+	bounds := make([][4]int, 0, len(rects))
+
+	for _, r := range rects {
+		bounds = append(bounds, [4]int{
+			x + int(math.Round(r.X)),
+			y + int(math.Round(r.Y/aspect)),
+			x + int(math.Round(r.X+r.W)),
+			y + int(math.Round((r.Y+r.H)/aspect)),
+		})
+	}
+
+	return bounds
+}
+
+// render returns the canvas as a string. The selected tile is drawn with a
+// thick border, and only the borders of the previously and currently selected
+// tiles are redrawn when the cursor moves.
+func (tm *TreemapModel) render() string {
+	if len(tm.canvas) == 0 {
+		return ""
+	}
+
+	if tm.selected != tm.cursor {
+		if tm.selected >= 0 && tm.selected < len(tm.tiles) {
+			tm.drawBorder(tm.tiles[tm.selected], false)
+		}
+
+		if tm.cursor < len(tm.tiles) {
+			tm.drawBorder(tm.tiles[tm.cursor], true)
+		}
+
+		tm.selected = tm.cursor
+	}
+
+	sb := strings.Builder{}
+
+	for y, row := range tm.canvas {
+		if y > 0 {
+			sb.WriteByte('\n')
+		}
+
+		for x := 0; x < len(row); {
+			end := x + 1
+
+			for end < len(row) && row[end].fg == row[x].fg && row[end].bold == row[x].bold {
+				end++
+			}
+
+			chars := make([]rune, 0, end-x)
+
+			for _, c := range row[x:end] {
+				chars = append(chars, c.char)
+			}
+
+			s := lipgloss.NewStyle().Bold(row[x].bold)
+
+			if row[x].fg != nil {
+				s = s.Foreground(row[x].fg)
+			}
+
+			sb.WriteString(s.Render(string(chars)))
+
+			x = end
+		}
+	}
+
+	return sb.String()
+}
+
+// drawTile draws the not selected tile along with its nested entries.
+func (tm *TreemapModel) drawTile(t treemapTile) {
+	tm.drawBorder(t, false)
+
+	innerX0, innerY0, innerX1, innerY1 := t.x0+1, t.y0+1, t.x1-1, t.y1-1
+
+	if !t.entry.IsDir || innerX1-innerX0 < 2 || innerY1-innerY0 < 1 {
+		return
+	}
+
+	nested := treemapEntries(t.entry)
+	shades := []rune{'▒', '░'}
+
+	for i, r := range tm.squarify(
+		nested, innerX0, innerY0, innerX1-innerX0, innerY1-innerY0,
+	) {
+		tm.fill(r[0], r[1], r[2], r[3], shades[i%len(shades)], t.color, false)
+		tm.text(r[0], r[1], r[2]-r[0], nested[i].Name(), t.color, false)
+	}
+}
+
+// drawBorder draws the tile border and its label. The tiles that are too small
+// for the border are filled entirely instead.
+func (tm *TreemapModel) drawBorder(t treemapTile, selected bool) {
+	w, h := t.x1-t.x0, t.y1-t.y0
+
+	if w < 2 || h < 2 {
+		tm.fill(t.x0, t.y0, t.x1, t.y1, '█', t.color, selected)
+
+		return
+	}
+
+	border := lipgloss.RoundedBorder()
+	if selected {
+		border = lipgloss.ThickBorder()
+	}
+
+	for x := t.x0; x < t.x1; x++ {
+		tm.set(x, t.y0, []rune(border.Top)[0], t.color, selected)
+		tm.set(x, t.y1-1, []rune(border.Bottom)[0], t.color, selected)
+	}
+
+	for y := t.y0; y < t.y1; y++ {
+		tm.set(t.x0, y, []rune(border.Left)[0], t.color, selected)
+		tm.set(t.x1-1, y, []rune(border.Right)[0], t.color, selected)
+	}
+
+	tm.set(t.x0, t.y0, []rune(border.TopLeft)[0], t.color, selected)
+	tm.set(t.x1-1, t.y0, []rune(border.TopRight)[0], t.color, selected)
+	tm.set(t.x0, t.y1-1, []rune(border.BottomLeft)[0], t.color, selected)
+	tm.set(t.x1-1, t.y1-1, []rune(border.BottomRight)[0], t.color, selected)
+
+	tm.text(t.x0+1, t.y0, w-2, t.entry.Name()+" "+FmtSize(t.entry.Size, 0), t.color, true)
+}
+
+func (tm *TreemapModel) fill(x0, y0, x1, y1 int, char rune, c color.Color, bold bool) {
+	for y := y0; y < y1; y++ {
+		for x := x0; x < x1; x++ {
+			tm.set(x, y, char, c, bold)
+		}
+	}
+}
+
+// text draws the label starting from the provided position. The label is
+// truncated to the provided width and not drawn at all if it does not fit at
+// least a few characters.
+func (tm *TreemapModel) text(x, y, width int, label string, c color.Color, bold bool) {
+	const minLabelWidth = 3
+
+	chars := []rune(label)
+
+	if width < minLabelWidth {
+		return
+	}
+
+	if len(chars) > width {
+		chars = append(chars[:width-1], '…')
+	}
+
+	for i, char := range chars {
+		tm.set(x+i, y, char, c, bold)
+	}
+}
+
+func (tm *TreemapModel) set(x, y int, char rune, c color.Color, bold bool) {
+	if y < 0 || y >= len(tm.canvas) || x < 0 || x >= len(tm.canvas[y]) {
+		return
+	}
+
+	tm.canvas[y][x] = treemapCell{char: char, fg: c, bold: bold}
+}
+
+// move selects the closest tile in the provided direction. The distance along
+// the direction is preferred over the offset across it, so the tiles in the
+// same row or column are selected first.
+func (tm *TreemapModel) move(dx, dy int) {
+	if tm.cursor >= len(tm.tiles) {
+		return
+	}
+
+	aspect := max(style.CS().ChartColors.AspectRatioFix, 1)
+	cx, cy := tm.tiles[tm.cursor].center()
+	best, bestScore := -1, math.MaxFloat64
+
+	for i, t := range tm.tiles {
+		if i == tm.cursor {
+			continue
+		}
+
+		tx, ty := t.center()
+		ddx, ddy := tx-cx, (ty-cy)*aspect
+
+		primary := ddx*float64(dx) + ddy*float64(dy)
+		if primary <= 0 {
+			continue
+		}
+
+		secondary := math.Abs(ddx*float64(dy)) + math.Abs(ddy*float64(dx))
+
+		if score := primary + secondary*2; score < bestScore {
+			best, bestScore = i, score
+		}
+	}
+
+	if best != -1 {
+		tm.cursor = best
+	}
+}
+
+// treemapEntries returns the child entries of the provided entry sorted by
+// size and limited to the maxTreemapTiles.
+func treemapEntries(e *structure.Entry) []*structure.Entry {
+	entries := slices.Collect(e.Entries())
+
+	slices.SortFunc(entries, func(a, b *structure.Entry) int {
+		switch {
+		case a.Size > b.Size:
+			return -1
+		case a.Size < b.Size:
+			return 1
+		}
+
+		return strings.Compare(a.Path, b.Path)
+	})
+
+	return entries[:min(len(entries), maxTreemapTiles)]
+}
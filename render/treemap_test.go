@@ -0,0 +1,62 @@
+package render_test
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/crumbyte/noxdir/config"
+	"github.com/crumbyte/noxdir/render"
+
+	tea "charm.land/bubbletea/v2"
+	"github.com/charmbracelet/x/ansi"
+	"github.com/stretchr/testify/require"
+)
+
+func TestTreemapModel(t *testing.T) {
+	s := render.InitStyle(render.DefaultColorSchema())
+	render.InitKeyMap(nil, s)
+
+	root := t.TempDir()
+
+	for name, size := range map[string]int{"big": 3000, "medium": 2000, "small": 1000} {
+		require.NoError(t, os.Mkdir(filepath.Join(root, name), 0750))
+		require.NoError(
+			t,
+			os.WriteFile(filepath.Join(root, name, "file"), make([]byte, size), 0600),
+		)
+	}
+
+	nav, _ := newTestNavigation(t, root, config.Settings{})
+
+	tm := render.NewTreemapModel(nav)
+	tm.Update(tea.WindowSizeMsg{Width: 100, Height: 40})
+
+	view := ansi.Strip(tm.View().Content)
+	lines := strings.Split(view, "\n")
+
+	// the dialog border, header, and footer are included.
+	require.Len(t, lines, 36)
+
+	for _, name := range []string{"big", "medium", "small"} {
+		require.Contains(t, view, name)
+	}
+
+	require.Equal(t, "big", tm.Selected().Name())
+
+	selected := map[string]bool{}
+
+	for _, k := range []tea.KeyPressMsg{
+		{Code: tea.KeyRight}, {Code: tea.KeyDown}, {Code: tea.KeyLeft}, {Code: tea.KeyUp},
+	} {
+		tm.Update(k)
+
+		// only the selected tile is drawn with the thick border.
+		require.Equal(t, 1, strings.Count(ansi.Strip(tm.View().Content), "┏"))
+
+		selected[tm.Selected().Name()] = true
+	}
+
+	require.True(t, selected["medium"] || selected["small"])
+}
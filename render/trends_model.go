@@ -0,0 +1,328 @@
+package render
+
+import (
+	"cmp"
+	"fmt"
+	"path/filepath"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/crumbyte/noxdir/drive"
+	"github.com/crumbyte/noxdir/render/table"
+	"github.com/crumbyte/noxdir/structure"
+
+	tea "charm.land/bubbletea/v2"
+	"charm.land/lipgloss/v2"
+	"github.com/charmbracelet/bubbles/key"
+)
+
+// trendsDepth defines the number of directory levels below the current entry
+// that are included in the trends view.
+const trendsDepth = 2
+
+type (
+	UpdateTrendsState  struct{}
+	TrendsScanFinished struct{}
+)
+
+// trendWindow defines the time window used for calculating the growth. The zero
+// duration means all available data points.
+type trendWindow struct {
+	name     string
+	duration time.Duration
+}
+
+var trendWindows = []trendWindow{
+	{name: "all time"},
+	{name: "last 24 hours", duration: time.Hour * 24},
+	{name: "last 7 days", duration: time.Hour * 24 * 7},
+	{name: "last 30 days", duration: time.Hour * 24 * 30},
+}
+
+// TrendsModel shows the directories growing the fastest within the current
+// active entry. The growth is calculated using the cache snapshots of the tree
+// root and the current state.
+type TrendsModel struct {
+	nav       *Navigation
+	table     *table.Model
+	trends    []structure.Trend
+	columns   []table.Column
+	lastError error
+	skipped   int
+	window    int
+	height    int
+	width     int
+	ready     bool
+}
+
+func NewTrendsModel(n *Navigation) *TrendsModel {
+	return &TrendsModel{
+		nav:   n,
+		table: buildTable(),
+		columns: []table.Column{
+			{Title: ""},
+			{Title: "Entry Path"},
+			{Title: "Size"},
+			{Title: "Growth"},
+			{Title: "Per Day"},
+			{Title: "Trend"},
+		},
+	}
+}
+
+func (tm *TrendsModel) Init() tea.Cmd {
+	return nil
+}
+
+func (tm *TrendsModel) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
+	switch msg := msg.(type) {
+	case tea.WindowSizeMsg:
+		tm.height = int(float64(msg.Height) * 0.7)
+		tm.width = int(float64(msg.Width) * 0.7)
+
+		tm.table.SetWidth(tm.width)
+		tm.table.SetHeight(tm.height)
+
+		tm.updateTableData()
+
+		return tm, nil
+	case UpdateTrendsState:
+		tm.ready = false
+	case TrendsScanFinished:
+		tm.ready = true
+
+		tm.updateTableData()
+	case tea.KeyPressMsg:
+		switch {
+		case key.Matches(msg, Bindings.Explore):
+			tm.handleExploreKey()
+		case key.Matches(msg, Bindings.Dirs.TrendsWindow):
+			tm.window = (tm.window + 1) % len(trendWindows)
+
+			tm.updateTableData()
+
+			return tm, nil
+		}
+	}
+
+	t, _ := tm.table.Update(msg)
+	tm.table = &t
+
+	return tm, nil
+}
+
+func (tm *TrendsModel) View() tea.View {
+	rows := make([]string, 0, 3)
+
+	messageStyle := lipgloss.NewStyle().
+		Align(lipgloss.Center).
+		Width(tm.width).
+		Bold(true)
+
+	switch {
+	case tm.lastError != nil:
+		rows = append(
+			rows,
+			messageStyle.Render(
+				"Error occurred during calculating trends: "+tm.lastError.Error(),
+			),
+		)
+	case !tm.ready:
+		rows = append(
+			rows,
+			messageStyle.Render("Calculating trends for: "+tm.nav.Entry().Path),
+		)
+	case len(tm.table.Rows()) == 0:
+		rows = append(
+			rows,
+			messageStyle.Render(
+				"Not enough cached snapshots found for: "+tm.nav.Entry().Path,
+			),
+		)
+	default:
+		summary := tm.viewSummary()
+		title := "Growth over the " + trendWindows[tm.window].name
+
+		if tm.skipped > 0 {
+			title += fmt.Sprintf(" (%d unreadable snapshots skipped)", tm.skipped)
+		}
+
+		header := messageStyle.Faint(true).Render(title)
+
+		tm.table.SetHeight(
+			tm.height - lipgloss.Height(summary) - lipgloss.Height(header),
+		)
+
+		rows = append(rows, header, tm.table.View().Content, summary)
+	}
+
+	return tea.NewView(
+		style.DialogBox().Render(
+			lipgloss.NewStyle().Padding(0, 1, 0, 1).Render(
+				lipgloss.JoinVertical(lipgloss.Top, rows...),
+			),
+		),
+	)
+}
+
+// Run starts the trends calculation for the current active entry. The cached
+// snapshots are restored in the background, and the model state is updated
+// once the calculation is finished.
+func (tm *TrendsModel) Run(width, height int) {
+	tm.height = int(float64(height) * 0.7)
+	tm.width = int(float64(width) * 0.7)
+
+	tm.table.SetWidth(tm.width)
+	tm.table.SetHeight(tm.height)
+
+	tm.trends, tm.skipped, tm.lastError, tm.ready = nil, 0, nil, false
+
+	tm.table.SetRows(nil)
+
+	done := make(chan struct{})
+
+	go func() {
+		tm.trends, tm.skipped, tm.lastError = tm.nav.Trends(trendsDepth)
+
+		close(done)
+	}()
+
+	go func() {
+		ticker := time.NewTicker(updateTickerInterval)
+		defer func() {
+			ticker.Stop()
+		}()
+
+		teaProg.Send(UpdateTrendsState{})
+
+		for {
+			select {
+			case <-ticker.C:
+				teaProg.Send(UpdateTrendsState{})
+			case <-done:
+				teaProg.Send(TrendsScanFinished{})
+
+				return
+			}
+		}
+	}()
+}
+
+func (tm *TrendsModel) handleExploreKey() bool {
+	sr := tm.table.SelectedRow()
+	if sr == nil || len(sr.Cols) < 2 {
+		return true
+	}
+
+	return drive.Explore(sr.Cols[0]) != nil
+}
+
+// windowTrends returns the trends of the subdirectories limited to the current
+// window and sorted by their growth, so the fastest-growing directories are
+// shown first. The trends without at least two data points are skipped.
+func (tm *TrendsModel) windowTrends() []structure.Trend {
+	from := tm.windowStart()
+
+	trends := make([]structure.Trend, 0, len(tm.trends))
+
+	for _, t := range tm.trends {
+		wt := t.Since(from)
+
+		if len(wt.Points) > 1 && wt.Path != tm.nav.Entry().Path {
+			trends = append(trends, wt)
+		}
+	}
+
+	slices.SortStableFunc(trends, func(a, b structure.Trend) int {
+		return cmp.Compare(b.Growth(), a.Growth())
+	})
+
+	return trends
+}
+
+// windowStart returns the start time of the current window. The zero value is
+// returned if the window includes all data points.
+func (tm *TrendsModel) windowStart() time.Time {
+	if d := trendWindows[tm.window].duration; d > 0 {
+		return time.Now().Add(-d)
+	}
+
+	return time.Time{}
+}
+
+func (tm *TrendsModel) updateTableData() {
+	if !tm.ready || tm.nav.Entry() == nil {
+		return
+	}
+
+	sizeWidth := 15
+	trendWidth := 20
+	nameWidth := tm.width - sizeWidth*3 - trendWidth
+
+	tm.columns[0].Width = 0
+	tm.columns[1].Width = nameWidth
+	tm.columns[2].Width = sizeWidth
+	tm.columns[3].Width = sizeWidth
+	tm.columns[4].Width = sizeWidth
+	tm.columns[5].Width = trendWidth
+
+	tm.table.SetColumns(tm.columns)
+
+	trends := tm.windowTrends()
+	rows := make([]table.Row, 0, len(trends))
+
+	for _, t := range trends {
+		relPath := strings.TrimPrefix(
+			strings.TrimPrefix(t.Path, tm.nav.Entry().Path),
+			string(filepath.Separator),
+		)
+
+		rows = append(
+			rows,
+			table.Row{
+				Cols: []string{
+					t.Path,
+					WrapString(relPath, nameWidth),
+					FmtSize(t.Points[len(t.Points)-1].Size, entrySizeWidth),
+					FmtSignedSize(t.Growth(), entrySizeWidth),
+					FmtSignedSize(int64(t.Rate()), entrySizeWidth),
+					Sparkline(t.Sizes(), trendWidth),
+				},
+			},
+		)
+	}
+
+	tm.table.SetRows(rows)
+	tm.table.SetCursor(0)
+}
+
+func (tm *TrendsModel) viewSummary() string {
+	idx := slices.IndexFunc(tm.trends, func(t structure.Trend) bool {
+		return t.Path == tm.nav.Entry().Path
+	})
+
+	if idx == -1 {
+		return ""
+	}
+
+	total := tm.trends[idx].Since(tm.windowStart())
+	statStyle := lipgloss.NewStyle().Bold(true).Underline(true)
+
+	return lipgloss.NewStyle().Width(tm.width).
+		BorderStyle(lipgloss.NormalBorder()).
+		BorderForeground(lipgloss.Color("240")).
+		BorderTop(true).
+		Align(lipgloss.Center).
+		Render(
+			lipgloss.JoinHorizontal(
+				lipgloss.Center,
+				"TOTAL: ",
+				statStyle.Render(FmtSignedSize(total.Growth(), 0)),
+				", per day - ",
+				statStyle.Render(FmtSignedSize(int64(total.Rate()), 0)),
+				fmt.Sprintf(", data points - %d ", len(total.Points)),
+				Sparkline(total.Sizes(), 30),
+			),
+		)
+}
@@ -0,0 +1,121 @@
+package server
+
+import (
+	"container/heap"
+	"slices"
+	"time"
+
+	"github.com/crumbyte/noxdir/pkg/cache"
+	"github.com/crumbyte/noxdir/structure"
+)
+
+// Entry represents a single file or directory in the API responses.
+type Entry struct {
+	Path       string    `json:"path"`
+	Name       string    `json:"name"`
+	ModTime    time.Time `json:"modTime"`
+	Size       int64     `json:"size"`
+	LocalDirs  uint64    `json:"localDirs"`
+	LocalFiles uint64    `json:"localFiles"`
+	TotalDirs  uint64    `json:"totalDirs"`
+	TotalFiles uint64    `json:"totalFiles"`
+	IsDir      bool      `json:"isDir"`
+}
+
+func NewEntry(e *structure.Entry) Entry {
+	return Entry{
+		Path:       e.Path,
+		Name:       e.Name(),
+		ModTime:    time.Unix(e.ModTime, 0),
+		Size:       e.Size,
+		LocalDirs:  e.LocalDirs,
+		LocalFiles: e.LocalFiles,
+		TotalDirs:  e.TotalDirs,
+		TotalFiles: e.TotalFiles,
+		IsDir:      e.IsDir,
+	}
+}
+
+type EntriesResponse struct {
+	Entry    Entry   `json:"entry"`
+	Children []Entry `json:"children"`
+}
+
+type Snapshot struct {
+	ID      string    `json:"id"`
+	Created time.Time `json:"created"`
+	Size    int64     `json:"size"`
+}
+
+func NewSnapshot(s cache.Snapshot) Snapshot {
+	return Snapshot{ID: s.ID, Created: s.Created, Size: s.Size}
+}
+
+// Change represents an entry that exists in both compared states, but its size
+// has been changed.
+type Change struct {
+	Entry   Entry `json:"entry"`
+	OldSize int64 `json:"oldSize"`
+	Delta   int64 `json:"delta"`
+}
+
+// DiffResponse contains the delta between the cached snapshot and the current
+// state. Each list is sorted by the size or delta and limited separately.
+type DiffResponse struct {
+	Snapshot Snapshot `json:"snapshot"`
+	Added    []Entry  `json:"added"`
+	Removed  []Entry  `json:"removed"`
+	Changed  []Change `json:"changed"`
+}
+
+func NewDiffResponse(s cache.Snapshot, d *structure.Diff, limit int) DiffResponse {
+	resp := DiffResponse{
+		Snapshot: NewSnapshot(s),
+		Added:    make([]Entry, 0, min(len(d.Added), limit)),
+		Removed:  make([]Entry, 0, min(len(d.Removed), limit)),
+		Changed:  make([]Change, 0, min(len(d.Changed), limit)),
+	}
+
+	for _, e := range d.Added[:min(len(d.Added), limit)] {
+		resp.Added = append(resp.Added, NewEntry(e))
+	}
+
+	for _, e := range d.Removed[:min(len(d.Removed), limit)] {
+		resp.Removed = append(resp.Removed, NewEntry(e))
+	}
+
+	for _, c := range d.Changed[:min(len(d.Changed), limit)] {
+		resp.Changed = append(
+			resp.Changed,
+			Change{Entry: NewEntry(c.New), OldSize: c.Old.Size, Delta: c.Delta()},
+		)
+	}
+
+	return resp
+}
+
+type RescanResponse struct {
+	Entry    Entry   `json:"entry"`
+	Duration float64 `json:"duration"`
+	Errors   int     `json:"errors"`
+}
+
+type ErrorResponse struct {
+	Error string `json:"error"`
+}
+
+// topEntries drains the provided heap of the structure.TopEntries and returns
+// the entries sorted by size, starting from the biggest one.
+func topEntries(h heap.Interface) []Entry {
+	entries := make([]Entry, 0, h.Len())
+
+	for h.Len() > 0 {
+		if e, ok := heap.Pop(h).(*structure.Entry); ok {
+			entries = append(entries, NewEntry(e))
+		}
+	}
+
+	slices.Reverse(entries)
+
+	return entries
+}
@@ -0,0 +1,320 @@
+// Package server provides a read-only HTTP JSON API on top of the scanned
+// *structure.Tree. It allows building external dashboards without
+// re-implementing the scanner.
+package server
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/crumbyte/noxdir/drive"
+	"github.com/crumbyte/noxdir/pkg/cache"
+	"github.com/crumbyte/noxdir/structure"
+)
+
+const (
+	defaultTopLimit  = structure.DefaultMaxTopEntries
+	defaultDiffLimit = 100
+)
+
+var (
+	ErrNotFound   = errors.New("entry not found")
+	ErrNotDir     = errors.New("entry is not a directory")
+	ErrNoSnapshot = errors.New("snapshot not found")
+)
+
+// DrivesFunc returns the list of the available drives. It's used for replacing
+// the drive.NewList in tests.
+type DrivesFunc func() (*drive.List, error)
+
+// Server serves the state of the *structure.Tree over HTTP. All requests are
+// serialized, since the tree is not safe for the concurrent access, and the
+// rescan request blocks the other requests until the scan is finished.
+type Server struct {
+	tree   *structure.Tree
+	drives DrivesFunc
+	mux    *http.ServeMux
+	mx     sync.Mutex
+}
+
+// New creates a new Server instance for the provided tree. The tree must be
+// already scanned or restored from the cache.
+func New(t *structure.Tree, drives DrivesFunc) *Server {
+	s := &Server{tree: t, drives: drives, mux: http.NewServeMux()}
+
+	s.mux.HandleFunc("GET /api/drives", s.handleDrives)
+	s.mux.HandleFunc("GET /api/entries", s.handleEntries)
+	s.mux.HandleFunc("GET /api/top/files", s.handleTop(false))
+	s.mux.HandleFunc("GET /api/top/dirs", s.handleTop(true))
+	s.mux.HandleFunc("GET /api/snapshots", s.handleSnapshots)
+	s.mux.HandleFunc("GET /api/diff", s.handleDiff)
+	s.mux.HandleFunc("POST /api/rescan", s.handleRescan)
+
+	return s
+}
+
+func (s *Server) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	s.mux.ServeHTTP(w, r)
+}
+
+func (s *Server) handleDrives(w http.ResponseWriter, _ *http.Request) {
+	dl, err := s.drives()
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, err)
+
+		return
+	}
+
+	drives := make([]*drive.Info, 0, len(dl.All()))
+
+	// the device entries duplicate the usage of their first mount point.
+	for _, d := range dl.Sort(drive.TotalCap, true) {
+		if d.IsDev == 0 {
+			drives = append(drives, d)
+		}
+	}
+
+	writeJSON(w, http.StatusOK, drives)
+}
+
+func (s *Server) handleEntries(w http.ResponseWriter, r *http.Request) {
+	s.mx.Lock()
+	defer s.mx.Unlock()
+
+	entry, ok := s.findDir(w, r)
+	if !ok {
+		return
+	}
+
+	entry.SortedChild(structure.SortSize, true)
+
+	resp := EntriesResponse{
+		Entry: NewEntry(entry), Children: make([]Entry, 0, len(entry.Child)),
+	}
+
+	for child := range entry.Entries() {
+		resp.Children = append(resp.Children, NewEntry(child))
+	}
+
+	writeJSON(w, http.StatusOK, resp)
+}
+
+func (s *Server) handleTop(dirs bool) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		s.mx.Lock()
+		defer s.mx.Unlock()
+
+		entry, ok := s.findDir(w, r)
+		if !ok {
+			return
+		}
+
+		limit, err := queryInt(r, "limit", defaultTopLimit)
+		if err != nil {
+			writeError(w, http.StatusBadRequest, err)
+
+			return
+		}
+
+		te := structure.NewTopEntries(limit)
+		top := te.Files()
+
+		if dirs {
+			te.ScanDirs(entry)
+			top = te.Dirs()
+		} else {
+			te.ScanFiles(entry)
+		}
+
+		writeJSON(w, http.StatusOK, topEntries(top))
+	}
+}
+
+func (s *Server) handleSnapshots(w http.ResponseWriter, _ *http.Request) {
+	s.mx.Lock()
+	defer s.mx.Unlock()
+
+	snapshots, err := s.tree.Snapshots()
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, err)
+
+		return
+	}
+
+	resp := make([]Snapshot, 0, len(snapshots))
+
+	for _, snapshot := range snapshots {
+		resp = append(resp, NewSnapshot(snapshot))
+	}
+
+	writeJSON(w, http.StatusOK, resp)
+}
+
+func (s *Server) handleDiff(w http.ResponseWriter, r *http.Request) {
+	s.mx.Lock()
+	defer s.mx.Unlock()
+
+	entry, ok := s.findDir(w, r)
+	if !ok {
+		return
+	}
+
+	limit, err := queryInt(r, "limit", defaultDiffLimit)
+	if err != nil {
+		writeError(w, http.StatusBadRequest, err)
+
+		return
+	}
+
+	snapshot, err := s.snapshot(r.URL.Query().Get("snapshot"))
+	if err != nil {
+		status := http.StatusInternalServerError
+
+		if errors.Is(err, ErrNoSnapshot) {
+			status = http.StatusNotFound
+		}
+
+		writeError(w, status, err)
+
+		return
+	}
+
+	cachedTree, err := s.tree.CachedSnapshot(snapshot)
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, err)
+
+		return
+	}
+
+	defer func() {
+		_ = cachedTree.Close()
+	}()
+
+	cachedEntry := cachedTree.Find(entry.Path)
+	if cachedEntry == nil {
+		writeError(w, http.StatusNotFound, ErrNotFound)
+
+		return
+	}
+
+	writeJSON(
+		w,
+		http.StatusOK,
+		NewDiffResponse(snapshot, cachedEntry.Diff(entry), limit),
+	)
+}
+
+func (s *Server) handleRescan(w http.ResponseWriter, r *http.Request) {
+	s.mx.Lock()
+	defer s.mx.Unlock()
+
+	entry, ok := s.findDir(w, r)
+	if !ok {
+		return
+	}
+
+	start := time.Now()
+	scanErrors := 0
+
+	done, errChan := s.tree.TraverseNodeAsync(entry)
+
+	// the error channel is closed right after the done channel, so both are
+	// drained to count all reported errors.
+	for done != nil || errChan != nil {
+		select {
+		case err, ok := <-errChan:
+			if !ok {
+				errChan = nil
+
+				continue
+			}
+
+			if err != nil {
+				scanErrors++
+			}
+		case <-done:
+			done = nil
+		}
+	}
+
+	s.tree.CalculateSize()
+
+	writeJSON(
+		w,
+		http.StatusOK,
+		RescanResponse{
+			Entry:    NewEntry(entry),
+			Duration: time.Since(start).Seconds(),
+			Errors:   scanErrors,
+		},
+	)
+}
+
+// findDir resolves the directory entry from the "path" query parameter. The
+// tree root is used if the parameter is empty. If the entry cannot be resolved,
+// the corresponding error response is written and false is returned.
+func (s *Server) findDir(w http.ResponseWriter, r *http.Request) (*structure.Entry, bool) {
+	path := r.URL.Query().Get("path")
+	entry := s.tree.Root()
+
+	if len(path) != 0 {
+		entry = s.tree.Find(path)
+	}
+
+	switch {
+	case entry == nil:
+		writeError(w, http.StatusNotFound, ErrNotFound)
+	case !entry.IsDir:
+		writeError(w, http.StatusBadRequest, ErrNotDir)
+	default:
+		return entry, true
+	}
+
+	return nil, false
+}
+
+// snapshot returns the cache snapshot with the provided ID. The most recent
+// snapshot is returned if the ID is empty.
+func (s *Server) snapshot(id string) (cache.Snapshot, error) {
+	snapshots, err := s.tree.Snapshots()
+	if err != nil {
+		return cache.Snapshot{}, err
+	}
+
+	for _, snapshot := range snapshots {
+		if len(id) == 0 || snapshot.ID == id {
+			return snapshot, nil
+		}
+	}
+
+	return cache.Snapshot{}, ErrNoSnapshot
+}
+
+func queryInt(r *http.Request, name string, defaultValue int) (int, error) {
+	raw := r.URL.Query().Get(name)
+	if len(raw) == 0 {
+		return defaultValue, nil
+	}
+
+	value, err := strconv.Atoi(raw)
+	if err != nil || value <= 0 {
+		return 0, errors.New("invalid " + name + " value: " + raw)
+	}
+
+	return value, nil
+}
+
+func writeJSON(w http.ResponseWriter, status int, v any) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+
+	_ = json.NewEncoder(w).Encode(v)
+}
+
+func writeError(w http.ResponseWriter, status int, err error) {
+	writeJSON(w, status, ErrorResponse{Error: err.Error()})
+}
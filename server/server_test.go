@@ -0,0 +1,180 @@
+package server_test
+
+import (
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/crumbyte/noxdir/drive"
+	"github.com/crumbyte/noxdir/pkg/cache"
+	"github.com/crumbyte/noxdir/server"
+	"github.com/crumbyte/noxdir/structure"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestServer(t *testing.T) {
+	root, c := initTestRoot(t), newTestCache(t)
+
+	tree := structure.NewTree(
+		structure.NewDirEntry(root, 0),
+		structure.WithCache(c),
+		structure.WithPartialRoot(),
+	)
+	require.NoError(t, tree.Traverse(true))
+	tree.CalculateSize()
+	require.NoError(t, <-tree.PersistCache())
+
+	srv := httptest.NewServer(
+		server.New(tree, func() (*drive.List, error) { return &drive.List{}, nil }),
+	)
+	defer srv.Close()
+
+	var entries server.EntriesResponse
+
+	requireGet(t, srv.URL+"/api/entries", http.StatusOK, &entries)
+	require.Equal(t, root, entries.Entry.Path)
+	require.Equal(t, int64(330), entries.Entry.Size)
+	require.Len(t, entries.Children, 3)
+	require.Equal(t, "dir_a", entries.Children[0].Name)
+	require.Equal(t, uint64(1), entries.Children[0].TotalDirs)
+
+	var errResp server.ErrorResponse
+
+	requireGet(t, srv.URL+"/api/entries?path="+filepath.Join(root, "unknown"), http.StatusNotFound, &errResp)
+	require.Equal(t, server.ErrNotFound.Error(), errResp.Error)
+
+	requireGet(t, srv.URL+"/api/entries?path="+filepath.Join(root, "root_file"), http.StatusBadRequest, &errResp)
+	require.Equal(t, server.ErrNotDir.Error(), errResp.Error)
+
+	var top []server.Entry
+
+	requireGet(t, srv.URL+"/api/top/files?limit=2", http.StatusOK, &top)
+	require.Len(t, top, 2)
+	require.Equal(t, "big", top[0].Name)
+	require.Equal(t, "root_file", top[1].Name)
+
+	requireGet(t, srv.URL+"/api/top/files?limit=-1", http.StatusBadRequest, &errResp)
+
+	var drives []*drive.Info
+
+	requireGet(t, srv.URL+"/api/drives", http.StatusOK, &drives)
+	require.Empty(t, drives)
+
+	require.NoError(t, os.WriteFile(filepath.Join(root, "dir_b", "new"), make([]byte, 50), 0600))
+
+	resp, err := http.Post(srv.URL+"/api/rescan?path="+filepath.Join(root, "dir_b"), "", nil)
+	require.NoError(t, err)
+	require.Equal(t, http.StatusOK, resp.StatusCode)
+
+	var rescan server.RescanResponse
+
+	require.NoError(t, json.NewDecoder(resp.Body).Decode(&rescan))
+	require.NoError(t, resp.Body.Close())
+	require.Equal(t, int64(60), rescan.Entry.Size)
+	require.Zero(t, rescan.Errors)
+
+	var snapshots []server.Snapshot
+
+	requireGet(t, srv.URL+"/api/snapshots", http.StatusOK, &snapshots)
+	require.Len(t, snapshots, 1)
+
+	var diff server.DiffResponse
+
+	requireGet(t, srv.URL+"/api/diff", http.StatusOK, &diff)
+	require.Equal(t, snapshots[0].ID, diff.Snapshot.ID)
+	require.Len(t, diff.Added, 1)
+	require.Equal(t, "new", diff.Added[0].Name)
+	require.Len(t, diff.Changed, 1)
+	require.Equal(t, int64(50), diff.Changed[0].Delta)
+	require.Empty(t, diff.Removed)
+
+	requireGet(t, srv.URL+"/api/diff?snapshot=unknown", http.StatusNotFound, &errResp)
+	require.Equal(t, server.ErrNoSnapshot.Error(), errResp.Error)
+}
+
+func TestServer_LazyCache(t *testing.T) {
+	root, c := initTestRoot(t), newTestCache(t)
+
+	newTree := func() *structure.Tree {
+		return structure.NewTree(
+			structure.NewDirEntry(root, 0),
+			structure.WithCache(c),
+			structure.WithUseCache(),
+			structure.WithPartialRoot(),
+		)
+	}
+
+	tree := newTree()
+	require.NoError(t, tree.Traverse(true))
+	tree.CalculateSize()
+	require.NoError(t, <-tree.PersistCache())
+
+	// only the root level is decoded, and the nested directories are loaded on
+	// demand while serving the requests.
+	tree = newTree()
+	require.NoError(t, tree.Traverse(false))
+
+	srv := httptest.NewServer(
+		server.New(tree, func() (*drive.List, error) { return &drive.List{}, nil }),
+	)
+	defer srv.Close()
+
+	var top []server.Entry
+
+	requireGet(t, srv.URL+"/api/top/files?limit=3", http.StatusOK, &top)
+	require.Len(t, top, 3)
+	require.Equal(t, "big", top[0].Name)
+	require.Equal(t, "root_file", top[1].Name)
+	require.Equal(t, "small", top[2].Name)
+
+	requireGet(t, srv.URL+"/api/top/dirs", http.StatusOK, &top)
+	require.NotEmpty(t, top)
+	require.Equal(t, filepath.Join(root, "dir_a", "nested"), top[0].Path)
+}
+
+func initTestRoot(t *testing.T) string {
+	t.Helper()
+
+	root := t.TempDir()
+
+	require.NoError(t, os.MkdirAll(filepath.Join(root, "dir_a", "nested"), 0750))
+	require.NoError(t, os.MkdirAll(filepath.Join(root, "dir_b"), 0750))
+	require.NoError(t, os.WriteFile(filepath.Join(root, "dir_a", "nested", "big"), make([]byte, 300), 0600))
+	require.NoError(t, os.WriteFile(filepath.Join(root, "dir_b", "small"), make([]byte, 10), 0600))
+	require.NoError(t, os.WriteFile(filepath.Join(root, "root_file"), make([]byte, 20), 0600))
+
+	return root
+}
+
+func newTestCache(t *testing.T) *cache.Cache {
+	t.Helper()
+
+	c, err := cache.NewCache(
+		func(w io.Writer) cache.Encoder { return structure.NewEncoder(w) },
+		func(r io.Reader) cache.Decoder { return structure.NewDecoder(r) },
+		false,
+		t.TempDir(),
+	)
+	require.NoError(t, err)
+
+	return c
+}
+
+func requireGet(t *testing.T, url string, status int, target any) {
+	t.Helper()
+
+	resp, err := http.Get(url) //nolint:gosec,noctx // test server URL
+	require.NoError(t, err)
+
+	defer func() {
+		require.NoError(t, resp.Body.Close())
+	}()
+
+	require.Equal(t, status, resp.StatusCode)
+	require.NoError(t, json.NewDecoder(resp.Body).Decode(target))
+}
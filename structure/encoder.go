@@ -1,13 +1,130 @@
 package structure
 
 import (
+	"bytes"
 	"encoding/binary"
+	"errors"
 	"fmt"
+	"hash/crc64"
 	"io"
+	"path/filepath"
+	"runtime"
 	"sync"
+	"time"
 	"unsafe"
+
+	"github.com/klauspost/compress/zstd"
+)
+
+// FormatVersion defines the current version of the binary cache format. The
+// version must be incremented on each change of the encoded data layout, so
+// the files created by the previous versions will be rejected instead of being
+// misread.
+const FormatVersion uint16 = 4
+
+// formatMagic defines the signature at the beginning of each cache file.
+var formatMagic = [4]byte{'N', 'O', 'X', 'D'}
+
+const (
+	// maxStringLen defines the maximal length of the encoded string. A longer
+	// value indicates the corrupted data.
+	maxStringLen = 1 << 20
+
+	// maxBlockLen defines the maximal size of the compressed block. A bigger
+	// value indicates the corrupted data.
+	maxBlockLen = 1 << 30
+
+	// recordLen defines the size of the fixed part of the encoded entry: 2 int64
+	// and 4 uint64 values, 1 byte for the dir flag.
+	recordLen = 8*6 + 1
+
+	// blockRefLen defines the size of the encoded blockRef: the block offset,
+	// its length, its checksum, and the length of the subtree blocks.
+	blockRefLen = 8 + 4 + 8 + 8
+
+	// footerLen defines the size of the footer containing the root entry record,
+	// its block reference, and the checksum of the header and the footer.
+	footerLen = recordLen + blockRefLen + 8
 )
 
+var (
+	// ErrIncompatibleFormat defines an error that occurs when the decoded data
+	// was created by an incompatible encoder version or is not a cache file at
+	// all.
+	ErrIncompatibleFormat = errors.New("structure: incompatible cache format")
+
+	// ErrCorruptedData defines an error that occurs when the decoded data does
+	// not match its checksum or contains invalid values.
+	ErrCorruptedData = errors.New("structure: corrupted cache data")
+)
+
+var crcTable = crc64.MakeTable(crc64.ECMA)
+
+// Header contains the metadata describing the encoded tree. It is written at
+// the beginning of the cache file and allows validating the cache before
+// using it.
+type Header struct {
+	// Created contains the time when the tree was encoded.
+	Created time.Time
+
+	// Root contains the full path of the encoded root entry.
+	Root string
+
+	// Options contains the fingerprint of the scan options that were used to
+	// build the encoded tree. See ScanOptions.String.
+	Options string
+
+	// Entries contains the total number of encoded entries, including the root.
+	Entries uint64
+
+	// Version contains the format version of the encoded data.
+	Version uint16
+}
+
+// CacheData represents the encoded tree state along with its Header. It is
+// the only type supported by the Encoder and Decoder.
+type CacheData struct {
+	Root   *Entry
+	Header Header
+
+	// loader contains the blocks loader of the lazily decoded tree.
+	loader *blockLoader
+}
+
+// Close closes the reader of the lazily decoded tree. The entries that were not
+// loaded yet cannot be loaded afterward. See DecodeLazy.
+func (cd *CacheData) Close() error {
+	if cd.loader == nil {
+		return nil
+	}
+
+	return cd.loader.Close()
+}
+
+// blockRef describes the location of the encoded directory's child entries
+// within the encoded data.
+type blockRef struct {
+	offset   uint64
+	checksum uint64
+
+	// span contains the total length of the blocks of the directory's subtree,
+	// including its own block, which is the last one. The subtree blocks are
+	// written contiguously, so they can be copied as is.
+	span   uint64
+	length uint32
+}
+
+// Encoder encodes the *Entry tree into the indexed binary format. The child
+// entries of each directory are stored as a separate compressed block, and
+// each directory record contains the location of its block. Hence, the
+// encoded tree can be decoded partially, starting from the root, without
+// reading the entire data. See DecodeLazy.
+//
+// The encoded data has the following layout: the Header, the directory blocks
+// written in depth-first post-order, and the fixed-size footer with the root
+// entry record. The block offsets are stored relative to the block containing
+// the record, so the blocks of a subtree do not depend on their position and
+// can be copied into a new file without decoding.
 type Encoder struct {
 	w io.Writer
 }
@@ -16,77 +133,171 @@ func NewEncoder(w io.Writer) *Encoder {
 	return &Encoder{w: w}
 }
 
-var bufferPool = sync.Pool{
-	New: func() any {
-		// 48 bytes for 2 int64 and 4 uint64, 1 byte for dir flag, and 4 bytes
-		// for the number of child entries.
-		buf := make([]byte, 8*6+1+4)
+// Encode writes the provided *CacheData instance. The blocks of the lazily
+// decoded entries that were not loaded are copied from the source data as is.
+//
+// The Header's Version, Root, and Entries fields are always populated by the
+// encoder, and the Created field is set to the current time if it's empty.
+func (e *Encoder) Encode(v any) error {
+	data, ok := v.(*CacheData)
+	if !ok || data.Root == nil {
+		return fmt.Errorf("structure: encoding %T: not *CacheData", v)
+	}
 
-		return &buf
-	},
-}
+	h := data.Header
+	h.Version, h.Root, h.Entries = FormatVersion, data.Root.Path, countEntries(data.Root)
 
-func (e *Encoder) Encode(v any) error {
-	entry, ok := v.(*Entry)
-	if !ok {
-		return fmt.Errorf("structure: encoding %T: not *Entry", v)
+	if h.Created.IsZero() {
+		h.Created = time.Now()
 	}
 
-	buf, ok := bufferPool.Get().(*[]byte)
-	if !ok {
-		buf = new([]byte)
+	zw, err := zstd.NewWriter(nil)
+	if err != nil {
+		return fmt.Errorf("structure: init compression: %w", err)
 	}
 
-	defer bufferPool.Put(buf)
+	defer func() {
+		_ = zw.Close()
+	}()
 
-	//nolint:gosec // too bad
-	{
-		binary.LittleEndian.PutUint64((*buf)[0:], uint64(entry.ModTime))
-		binary.LittleEndian.PutUint64((*buf)[8:], uint64(entry.Size))
+	checksum := crc64.New(crcTable)
+
+	// the header is included into the footer's checksum.
+	bw := &blockWriter{w: io.MultiWriter(e.w, checksum), zw: zw}
+
+	if err = bw.writeHeader(h); err != nil {
+		return fmt.Errorf("structure: write header: %w", err)
 	}
 
-	binary.LittleEndian.PutUint64((*buf)[16:], entry.LocalDirs)
-	binary.LittleEndian.PutUint64((*buf)[24:], entry.LocalFiles)
-	binary.LittleEndian.PutUint64((*buf)[32:], entry.TotalDirs)
-	binary.LittleEndian.PutUint64((*buf)[40:], entry.TotalFiles)
-	(*buf)[48] = 0
+	bw.w = e.w
 
-	if entry.IsDir {
-		(*buf)[48] = 1
+	rootRef, err := bw.writeBlock(data.Root)
+	if err != nil {
+		return fmt.Errorf("structure: write block: %w", err)
 	}
 
-	//nolint:gosec // ...
-	binary.LittleEndian.PutUint32((*buf)[49:], uint32(len(entry.Child)))
+	footer := appendRecord(make([]byte, 0, footerLen), data.Root, rootRef, bw.offset)
+	_, _ = checksum.Write(footer)
+	footer = binary.LittleEndian.AppendUint64(footer, checksum.Sum64())
+
+	if _, err = e.w.Write(footer); err != nil {
+		return fmt.Errorf("structure: write footer: %w", err)
+	}
+
+	return nil
+}
+
+type blockWriter struct {
+	w      io.Writer
+	zw     *zstd.Encoder
+	buf    []byte
+	cbuf   []byte
+	offset uint64
+}
+
+func (bw *blockWriter) write(b []byte) error {
+	n, err := bw.w.Write(b)
+	bw.offset += uint64(n)
+
+	return err
+}
+
+func (bw *blockWriter) writeHeader(h Header) error {
+	buf := append(make([]byte, 0, 64), formatMagic[:]...)
+
+	buf = binary.LittleEndian.AppendUint16(buf, h.Version)
+	//nolint:gosec // the time is always positive
+	buf = binary.LittleEndian.AppendUint64(buf, uint64(h.Created.UnixNano()))
+	buf = binary.LittleEndian.AppendUint64(buf, h.Entries)
+	buf = appendString(buf, h.Root)
+	buf = appendString(buf, h.Options)
+
+	return bw.write(buf)
+}
 
-	if _, err := e.w.Write(*buf); err != nil {
-		return fmt.Errorf("structure: write buffer: %w", err)
+// writeBlock writes the blocks of all nested directories and then the block of
+// the provided directory itself. Therefore, the locations of all child blocks
+// are known at the moment of writing the directory's block.
+func (bw *blockWriter) writeBlock(e *Entry) (blockRef, error) {
+	if lb := e.lazy.Load(); lb != nil {
+		return bw.copyBlocks(lb)
 	}
 
-	if err := e.writeString(entry.Path); err != nil {
-		return fmt.Errorf("structure: write path: %w", err)
+	if !e.IsDir || len(e.Child) == 0 {
+		return blockRef{}, nil
 	}
 
-	for _, child := range entry.Child {
-		if err := e.Encode(child); err != nil {
-			return err
+	start := bw.offset
+	refs := make([]blockRef, len(e.Child))
+
+	for i, child := range e.Child {
+		if !child.IsDir {
+			continue
+		}
+
+		ref, err := bw.writeBlock(child)
+		if err != nil {
+			return ref, err
 		}
+
+		refs[i] = ref
 	}
 
-	return nil
+	//nolint:gosec // the number of child entries fits into uint32
+	bw.buf = binary.LittleEndian.AppendUint32(bw.buf[:0], uint32(len(e.Child)))
+
+	for i, child := range e.Child {
+		bw.buf = appendRecord(bw.buf, child, refs[i], bw.offset)
+		bw.buf = appendString(bw.buf, child.Name())
+	}
+
+	bw.cbuf = bw.zw.EncodeAll(bw.buf, bw.cbuf[:0])
+
+	//nolint:gosec // the block size is limited by the number of entries
+	ref := blockRef{
+		offset:   bw.offset,
+		length:   uint32(len(bw.cbuf)),
+		checksum: crc64.Checksum(bw.cbuf, crcTable),
+		span:     bw.offset + uint64(len(bw.cbuf)) - start,
+	}
+
+	return ref, bw.write(bw.cbuf)
 }
 
-func (e *Encoder) writeString(s string) error {
-	//nolint:gosec // ...
-	err := binary.Write(e.w, binary.LittleEndian, int32(len(s)))
-	if err != nil {
-		return err
+// copyBlocks copies the encoded blocks of the not loaded directory's subtree
+// from the source data without decoding them. The blocks are validated when
+// they're loaded from the new data.
+func (bw *blockWriter) copyBlocks(lb *lazyBlock) (blockRef, error) {
+	ref := lb.ref
+
+	if ref.length == 0 {
+		return blockRef{}, nil
 	}
 
-	_, err = e.w.Write(unsafeBytes(s))
+	end := ref.offset + uint64(ref.length)
 
-	return err
+	//nolint:gosec // the data length is always positive
+	if ref.span < uint64(ref.length) || ref.span > end || end > uint64(lb.loader.dataLen) {
+		return blockRef{}, fmt.Errorf("%w: invalid block location", ErrCorruptedData)
+	}
+
+	start := end - ref.span
+	ref.offset = bw.offset + ref.offset - start
+
+	//nolint:gosec // validated above
+	n, err := io.Copy(bw.w, io.NewSectionReader(lb.loader.r, int64(start), int64(ref.span)))
+	bw.offset += uint64(n) //nolint:gosec // the copied length is always positive
+
+	if err == nil && uint64(n) != ref.span { //nolint:gosec // same as above
+		err = fmt.Errorf("%w: truncated block", ErrCorruptedData)
+	}
+
+	return ref, err
 }
 
+// Decoder decodes the entire encoded tree from the io.Reader. Since the
+// encoded data is not sequential, the entire data is read into memory first.
+// Use DecodeLazy for decoding the tree from the io.ReaderAt on demand.
 type Decoder struct {
 	r io.Reader
 }
@@ -95,72 +306,421 @@ func NewDecoder(r io.Reader) *Decoder {
 	return &Decoder{r: r}
 }
 
+// Decode reads the encoded tree into the provided *CacheData instance. All
+// entries are decoded into a new root *Entry, so the target is modified only
+// if the data was read and validated successfully.
+//
+// The ErrIncompatibleFormat error will be returned if the data was not created
+// by the current encoder version, and the ErrCorruptedData error will be
+// returned if the data does not match its checksums.
 func (d *Decoder) Decode(v any) error {
-	entry, ok := v.(*Entry)
+	data, ok := v.(*CacheData)
 	if !ok {
-		return fmt.Errorf("decoding %T: not *Entry", v)
+		return fmt.Errorf("structure: decoding %T: not *CacheData", v)
 	}
 
-	var err error
+	raw, err := io.ReadAll(d.r)
+	if err != nil {
+		return fmt.Errorf("%w: read data: %w", ErrCorruptedData, err)
+	}
 
-	buf, ok := bufferPool.Get().(*[]byte)
-	if !ok {
-		buf = new([]byte)
+	decoded, err := DecodeLazy(bytes.NewReader(raw), int64(len(raw)))
+	if err != nil {
+		return err
 	}
 
-	if _, err = io.ReadFull(d.r, *buf); err != nil {
-		return fmt.Errorf("structure: read buffer: %w", err)
+	if err = decoded.loader.loadAll(decoded.Root); err != nil {
+		return err
 	}
 
-	//nolint:gosec // how could I
-	{
-		entry.ModTime = int64(binary.LittleEndian.Uint64((*buf)[0:]))
-		entry.Size = int64(binary.LittleEndian.Uint64((*buf)[8:]))
+	*data = *decoded
+
+	return nil
+}
+
+// ReadHeader reads only the Header of the encoded data. It does not validate
+// the checksum, since the footer is not read.
+func (d *Decoder) ReadHeader() (Header, error) {
+	return readHeader(d.r)
+}
+
+// DecodeLazy decodes the Header and the root entry of the encoded tree. The
+// child entries of each directory are decoded on demand, when they're accessed
+// for the first time. Hence, the provided reader must remain valid while the
+// decoded tree is in use. If the reader implements io.Closer, it will be closed
+// by CacheData.Close, or once the decoded tree is not referenced anymore. The
+// reader is not closed if the decoding fails.
+//
+// The blocks are validated when they're loaded. If the block cannot be loaded,
+// the directory will have no child entries. See Tree.LoadErr.
+func DecodeLazy(r io.ReaderAt, size int64) (*CacheData, error) {
+	if size < footerLen {
+		return nil, ErrIncompatibleFormat
+	}
+
+	checksum := crc64.New(crcTable)
+	sr := io.NewSectionReader(r, 0, size-footerLen)
+
+	h, err := readHeader(io.TeeReader(sr, checksum))
+	if err != nil {
+		return nil, err
 	}
 
-	entry.LocalDirs = uint64(binary.LittleEndian.Uint32((*buf)[16:]))
-	entry.LocalFiles = uint64(binary.LittleEndian.Uint32((*buf)[24:]))
-	entry.TotalDirs = uint64(binary.LittleEndian.Uint32((*buf)[32:]))
-	entry.TotalFiles = uint64(binary.LittleEndian.Uint32((*buf)[40:]))
-	entry.IsDir = (*buf)[48] == 1
+	footer := make([]byte, footerLen)
 
-	childCount := binary.LittleEndian.Uint32((*buf)[49:])
+	if _, err = r.ReadAt(footer, size-footerLen); err != nil {
+		return nil, fmt.Errorf("%w: read footer: %w", ErrCorruptedData, err)
+	}
+
+	_, _ = checksum.Write(footer[:footerLen-8])
+
+	if checksum.Sum64() != binary.LittleEndian.Uint64(footer[footerLen-8:]) {
+		return nil, fmt.Errorf("%w: checksum mismatch", ErrCorruptedData)
+	}
+
+	zr, err := zstd.NewReader(nil, zstd.WithDecoderConcurrency(1))
+	if err != nil {
+		return nil, fmt.Errorf("structure: init decompression: %w", err)
+	}
 
-	bufferPool.Put(buf)
+	loader := newBlockLoader(r, zr, size-footerLen)
 
-	entry.Path, err = d.readString()
+	//nolint:gosec // the size is validated above
+	root, _, err := loader.readRecord(footer, h.Root, uint64(size-footerLen))
 	if err != nil {
-		return fmt.Errorf("decoding path: %w", err)
+		_ = loader.Close()
+
+		return nil, err
 	}
 
-	entry.Child = make([]*Entry, 0, childCount)
+	loader.src.closer, _ = r.(io.Closer)
+
+	return &CacheData{Root: root, Header: h, loader: loader}, nil
+}
+
+// blockLoader loads the encoded directory blocks on demand.
+type blockLoader struct {
+	r       io.ReaderAt
+	zr      *zstd.Decoder
+	src     *blockSource
+	err     error
+	dataLen int64
+	mx      sync.Mutex
+}
+
+func newBlockLoader(r io.ReaderAt, zr *zstd.Decoder, dataLen int64) *blockLoader {
+	bl := &blockLoader{
+		r:       r,
+		zr:      zr,
+		src:     &blockSource{zr: zr},
+		dataLen: dataLen,
+	}
+
+	runtime.AddCleanup(bl, func(src *blockSource) { _ = src.close() }, bl.src)
+
+	return bl
+}
+
+// blockSource contains the resources the blocks are read with. They're released
+// either explicitly or once the loader is not referenced anymore.
+type blockSource struct {
+	zr     *zstd.Decoder
+	closer io.Closer
+	err    error
+	once   sync.Once
+}
+
+func (bs *blockSource) close() error {
+	bs.once.Do(func() {
+		bs.zr.Close()
+
+		if bs.closer != nil {
+			bs.err = bs.closer.Close()
+		}
+	})
+
+	return bs.err
+}
+
+// lazyBlock represents the child entries of the directory that were not loaded
+// yet.
+type lazyBlock struct {
+	loader *blockLoader
+	ref    blockRef
+}
+
+// load loads the child entries of the provided entry. If the block cannot be
+// loaded, the entry will have no child entries, and the error will be stored
+// in the loader.
+func (bl *blockLoader) load(e *Entry, lb *lazyBlock) {
+	bl.mx.Lock()
+	defer bl.mx.Unlock()
+
+	// the entry was loaded while waiting for the lock.
+	if e.lazy.Load() != lb {
+		return
+	}
+
+	child, err := bl.readBlock(e.Path, lb.ref)
+	if err != nil && bl.err == nil {
+		bl.err = fmt.Errorf("load %s: %w", e.Path, err)
+	}
+
+	e.Child, e.childIdx = child, nil
+	e.lazy.Store(nil)
+}
+
+// loadAll loads the entire subtree of the provided entry and returns the first
+// error that occurred during loading.
+func (bl *blockLoader) loadAll(e *Entry) error {
+	e.load()
+
+	for _, child := range e.Child {
+		if child.IsDir {
+			if err := bl.loadAll(child); err != nil {
+				return err
+			}
+		}
+	}
+
+	return bl.Err()
+}
+
+// Err returns the first error occurred during loading the blocks.
+// Close releases the decoder and closes the reader. The blocks that were not
+// loaded yet fail to load afterward.
+func (bl *blockLoader) Close() error {
+	bl.mx.Lock()
+	defer bl.mx.Unlock()
+
+	return bl.src.close()
+}
+
+func (bl *blockLoader) Err() error {
+	bl.mx.Lock()
+	defer bl.mx.Unlock()
+
+	return bl.err
+}
+
+func (bl *blockLoader) readBlock(parent string, ref blockRef) ([]*Entry, error) {
+	if ref.length == 0 {
+		return make([]*Entry, 0), nil
+	}
+
+	//nolint:gosec // the offset is validated against the data length
+	if ref.length > maxBlockLen || int64(ref.offset)+int64(ref.length) > bl.dataLen {
+		return nil, fmt.Errorf("%w: invalid block location", ErrCorruptedData)
+	}
+
+	compressed := make([]byte, ref.length)
+
+	//nolint:gosec // validated above
+	if _, err := bl.r.ReadAt(compressed, int64(ref.offset)); err != nil {
+		return nil, fmt.Errorf("%w: read block: %w", ErrCorruptedData, err)
+	}
+
+	if crc64.Checksum(compressed, crcTable) != ref.checksum {
+		return nil, fmt.Errorf("%w: block checksum mismatch", ErrCorruptedData)
+	}
+
+	block, err := bl.zr.DecodeAll(compressed, nil)
+	if err != nil || len(block) < 4 {
+		return nil, fmt.Errorf("%w: decompress block: %w", ErrCorruptedData, err)
+	}
+
+	childCount := binary.LittleEndian.Uint32(block)
+	block = block[4:]
+
+	child := make([]*Entry, 0, min(int(childCount), len(block)/recordLen))
 
 	for range childCount {
-		child := &Entry{}
+		if len(block) < recordLen+blockRefLen+4 {
+			return nil, fmt.Errorf("%w: truncated block", ErrCorruptedData)
+		}
 
-		if err = d.Decode(child); err != nil {
-			return err
+		entry, n, recordErr := bl.readRecord(block, "", ref.offset)
+		if recordErr != nil {
+			return nil, recordErr
 		}
 
-		entry.Child = append(entry.Child, child)
+		name, nameLen, nameErr := readString(block[n:])
+		if nameErr != nil {
+			return nil, fmt.Errorf("%w: read name: %w", ErrCorruptedData, nameErr)
+		}
+
+		entry.Path = filepath.Join(parent, name)
+		block = block[n+nameLen:]
+
+		child = append(child, entry)
 	}
 
-	return nil
+	return child, nil
 }
 
-func (d *Decoder) readString() (string, error) {
-	var l int32
+// readRecord decodes a single entry record and returns the number of consumed
+// bytes. The directory entries will be loaded lazily. The block offsets are
+// resolved against the provided offset of the data containing the record.
+func (bl *blockLoader) readRecord(b []byte, path string, base uint64) (*Entry, int, error) {
+	if len(b) < recordLen+blockRefLen {
+		return nil, 0, fmt.Errorf("%w: truncated record", ErrCorruptedData)
+	}
+
+	//nolint:gosec // how could I
+	entry := &Entry{
+		Path:       path,
+		ModTime:    int64(binary.LittleEndian.Uint64(b[0:])),
+		Size:       int64(binary.LittleEndian.Uint64(b[8:])),
+		LocalDirs:  binary.LittleEndian.Uint64(b[16:]),
+		LocalFiles: binary.LittleEndian.Uint64(b[24:]),
+		TotalDirs:  binary.LittleEndian.Uint64(b[32:]),
+		TotalFiles: binary.LittleEndian.Uint64(b[40:]),
+		IsDir:      b[48] == 1,
+	}
+
+	if entry.IsDir {
+		ref := blockRef{
+			length:   binary.LittleEndian.Uint32(b[recordLen+8:]),
+			checksum: binary.LittleEndian.Uint64(b[recordLen+12:]),
+			span:     binary.LittleEndian.Uint64(b[recordLen+20:]),
+		}
 
-	if err := binary.Read(d.r, binary.LittleEndian, &l); err != nil {
+		if ref.length != 0 {
+			distance := binary.LittleEndian.Uint64(b[recordLen:])
+			if distance > base {
+				return nil, 0, fmt.Errorf("%w: invalid block location", ErrCorruptedData)
+			}
+
+			ref.offset = base - distance
+		}
+
+		entry.lazy.Store(&lazyBlock{loader: bl, ref: ref})
+	}
+
+	return entry, recordLen + blockRefLen, nil
+}
+
+func readHeader(r io.Reader) (Header, error) {
+	var (
+		h   Header
+		buf [4 + 2 + 8 + 8]byte
+	)
+
+	if _, err := io.ReadFull(r, buf[:]); err != nil || [4]byte(buf[:4]) != formatMagic {
+		return h, ErrIncompatibleFormat
+	}
+
+	h.Version = binary.LittleEndian.Uint16(buf[4:])
+
+	if h.Version != FormatVersion {
+		return h, fmt.Errorf(
+			"%w: version %d, expected %d", ErrIncompatibleFormat, h.Version, FormatVersion,
+		)
+	}
+
+	//nolint:gosec // how could I
+	h.Created = time.Unix(0, int64(binary.LittleEndian.Uint64(buf[6:])))
+	h.Entries = binary.LittleEndian.Uint64(buf[14:])
+
+	var err error
+
+	if h.Root, err = readStringFrom(r); err != nil {
+		return h, fmt.Errorf("%w: read root: %w", ErrCorruptedData, err)
+	}
+
+	if h.Options, err = readStringFrom(r); err != nil {
+		return h, fmt.Errorf("%w: read options: %w", ErrCorruptedData, err)
+	}
+
+	return h, nil
+}
+
+// appendRecord appends the entry record. The block offset is stored as the
+// distance from the provided offset of the data containing the record.
+func appendRecord(b []byte, e *Entry, ref blockRef, base uint64) []byte {
+	//nolint:gosec // too bad
+	{
+		b = binary.LittleEndian.AppendUint64(b, uint64(e.ModTime))
+		b = binary.LittleEndian.AppendUint64(b, uint64(e.Size))
+	}
+
+	b = binary.LittleEndian.AppendUint64(b, e.LocalDirs)
+	b = binary.LittleEndian.AppendUint64(b, e.LocalFiles)
+	b = binary.LittleEndian.AppendUint64(b, e.TotalDirs)
+	b = binary.LittleEndian.AppendUint64(b, e.TotalFiles)
+
+	isDir := byte(0)
+	if e.IsDir {
+		isDir = 1
+	}
+
+	distance := uint64(0)
+	if ref.length != 0 {
+		distance = base - ref.offset
+	}
+
+	b = append(b, isDir)
+	b = binary.LittleEndian.AppendUint64(b, distance)
+	b = binary.LittleEndian.AppendUint32(b, ref.length)
+	b = binary.LittleEndian.AppendUint64(b, ref.checksum)
+
+	return binary.LittleEndian.AppendUint64(b, ref.span)
+}
+
+func appendString(b []byte, s string) []byte {
+	//nolint:gosec // ...
+	b = binary.LittleEndian.AppendUint32(b, uint32(len(s)))
+
+	return append(b, unsafeBytes(s)...)
+}
+
+func readString(b []byte) (string, int, error) {
+	if len(b) < 4 {
+		return "", 0, io.ErrUnexpectedEOF
+	}
+
+	l := binary.LittleEndian.Uint32(b)
+	if l > maxStringLen || int(l) > len(b)-4 {
+		return "", 0, fmt.Errorf("invalid string length %d", l)
+	}
+
+	return string(b[4 : 4+l]), 4 + int(l), nil
+}
+
+func readStringFrom(r io.Reader) (string, error) {
+	var lb [4]byte
+
+	if _, err := io.ReadFull(r, lb[:]); err != nil {
 		return "", err
 	}
 
+	l := binary.LittleEndian.Uint32(lb[:])
+	if l > maxStringLen {
+		return "", fmt.Errorf("invalid string length %d", l)
+	}
+
 	b := make([]byte, l)
-	_, err := io.ReadFull(d.r, b)
+	_, err := io.ReadFull(r, b)
 
 	return unsafeString(b), err
 }
 
+// countEntries returns the number of entries in the subtree. The not loaded
+// directories are counted by their totals, so they're not decoded.
+func countEntries(e *Entry) uint64 {
+	if !e.loaded() {
+		return 1 + e.TotalDirs + e.TotalFiles
+	}
+
+	count := uint64(1)
+
+	for _, child := range e.Child {
+		count += countEntries(child)
+	}
+
+	return count
+}
+
 func unsafeString(b []byte) string {
 	return *(*string)(unsafe.Pointer(&b))
 }
@@ -0,0 +1,245 @@
+package structure_test
+
+import (
+	"bytes"
+	"encoding/binary"
+	"math"
+	"os"
+	"slices"
+	"testing"
+	"time"
+
+	"github.com/crumbyte/noxdir/structure"
+
+	"github.com/stretchr/testify/require"
+)
+
+// footerLen defines the size of the encoded footer: the root record, its block
+// location, and the checksum.
+const footerLen = 49 + 28 + 8
+
+func TestEncoder_RoundTrip(t *testing.T) {
+	root := buildTestTree()
+	created := time.Unix(0, 1_700_000_000_123_456_789)
+
+	var buf bytes.Buffer
+
+	err := structure.NewEncoder(&buf).Encode(
+		&structure.CacheData{
+			Root:   root,
+			Header: structure.Header{Created: created, Options: "options"},
+		},
+	)
+	require.NoError(t, err)
+
+	var data structure.CacheData
+
+	require.NoError(t, structure.NewDecoder(bytes.NewReader(buf.Bytes())).Decode(&data))
+
+	require.Equal(t, structure.FormatVersion, data.Header.Version)
+	require.True(t, created.Equal(data.Header.Created))
+	require.Equal(t, root.Path, data.Header.Root)
+	require.Equal(t, "options", data.Header.Options)
+	require.Equal(t, uint64(4), data.Header.Entries)
+
+	requireEntriesEqual(t, root, data.Root)
+
+	h, err := structure.NewDecoder(bytes.NewReader(buf.Bytes())).ReadHeader()
+	require.NoError(t, err)
+	require.Equal(t, data.Header, h)
+}
+
+func TestDecoder_Reject(t *testing.T) {
+	var buf bytes.Buffer
+
+	err := structure.NewEncoder(&buf).Encode(
+		&structure.CacheData{Root: buildTestTree()},
+	)
+	require.NoError(t, err)
+
+	encoded := buf.Bytes()
+
+	tableData := []struct {
+		expected error
+		modify   func([]byte) []byte
+		name     string
+	}{
+		{
+			name:     "empty",
+			expected: structure.ErrIncompatibleFormat,
+			modify:   func([]byte) []byte { return nil },
+		},
+		{
+			name:     "magic",
+			expected: structure.ErrIncompatibleFormat,
+			modify: func(b []byte) []byte {
+				b[0] = 'X'
+
+				return b
+			},
+		},
+		{
+			name:     "version",
+			expected: structure.ErrIncompatibleFormat,
+			modify: func(b []byte) []byte {
+				binary.LittleEndian.PutUint16(b[4:], structure.FormatVersion+1)
+
+				return b
+			},
+		},
+		{
+			name:     "checksum",
+			expected: structure.ErrCorruptedData,
+			modify: func(b []byte) []byte {
+				b[len(b)-20]++
+
+				return b
+			},
+		},
+		{
+			name:     "block",
+			expected: structure.ErrCorruptedData,
+			modify: func(b []byte) []byte {
+				b[len(b)-footerLen-1]++
+
+				return b
+			},
+		},
+		{
+			name:     "truncated",
+			expected: structure.ErrCorruptedData,
+			modify:   func(b []byte) []byte { return b[:len(b)-30] },
+		},
+	}
+
+	for _, data := range tableData {
+		t.Run(data.name, func(t *testing.T) {
+			modified := data.modify(bytes.Clone(encoded))
+			target := structure.CacheData{}
+
+			err = structure.NewDecoder(bytes.NewReader(modified)).Decode(&target)
+
+			require.ErrorIs(t, err, data.expected)
+			require.Nil(t, target.Root)
+		})
+	}
+}
+
+func TestDecodeLazy(t *testing.T) {
+	root := buildTestTree()
+
+	var buf bytes.Buffer
+
+	require.NoError(t, structure.NewEncoder(&buf).Encode(&structure.CacheData{Root: root}))
+
+	encoded := buf.Bytes()
+
+	data, err := structure.DecodeLazy(bytes.NewReader(encoded), int64(len(encoded)))
+	require.NoError(t, err)
+	require.Equal(t, root.Path, data.Header.Root)
+
+	// the root's block is the last one, so corrupting it after decoding proves
+	// that the child entries are decoded on demand.
+	rootBlockEnd := len(encoded) - footerLen - 1
+	encoded[rootBlockEnd]++
+
+	require.True(t, data.Root.HasChild())
+	require.Empty(t, slices.Collect(data.Root.Entries()))
+
+	encoded[rootBlockEnd]--
+
+	data, err = structure.DecodeLazy(bytes.NewReader(encoded), int64(len(encoded)))
+	require.NoError(t, err)
+
+	sep := string(os.PathSeparator)
+
+	file := data.Root.FindChild(sep + "root" + sep + "dir" + sep + "file")
+	require.NotNil(t, file)
+	require.Equal(t, int64(math.MaxInt64), file.Size)
+
+	requireEntriesEqual(t, root, data.Root)
+}
+
+func TestEncoder_CopyBlocks(t *testing.T) {
+	root := buildTestTree()
+
+	var buf bytes.Buffer
+
+	require.NoError(t, structure.NewEncoder(&buf).Encode(&structure.CacheData{Root: root}))
+
+	encoded := buf.Bytes()
+
+	reencode := func(data *structure.CacheData) []byte {
+		var out bytes.Buffer
+
+		require.NoError(t, structure.NewEncoder(&out).Encode(data))
+
+		return out.Bytes()
+	}
+
+	// the partially loaded tree must be encoded entirely.
+	data, err := structure.DecodeLazy(bytes.NewReader(encoded), int64(len(encoded)))
+	require.NoError(t, err)
+	require.Len(t, slices.Collect(data.Root.Entries()), 2)
+
+	var decoded structure.CacheData
+
+	reencoded := reencode(data)
+	require.NoError(t, structure.NewDecoder(bytes.NewReader(reencoded)).Decode(&decoded))
+	requireEntriesEqual(t, root, decoded.Root)
+
+	// the "dir" block is the first one after the header. It's not decoded
+	// while encoding, so the corruption is only detected by the new decoder.
+	headerLen := 4 + 2 + 8 + 8 + 4 + len(root.Path) + 4
+
+	data, err = structure.DecodeLazy(bytes.NewReader(encoded), int64(len(encoded)))
+	require.NoError(t, err)
+	require.Len(t, slices.Collect(data.Root.Entries()), 2)
+
+	encoded[headerLen]++
+
+	reencoded = reencode(data)
+
+	err = structure.NewDecoder(bytes.NewReader(reencoded)).Decode(&decoded)
+	require.ErrorIs(t, err, structure.ErrCorruptedData)
+}
+
+func buildTestTree() *structure.Entry {
+	sep := string(os.PathSeparator)
+
+	root := structure.NewDirEntry(sep+"root", 100)
+	dir := structure.NewDirEntry(sep+"root"+sep+"dir", 200)
+	file := structure.NewFileEntry(sep+"root"+sep+"dir"+sep+"file", math.MaxInt64, 300)
+	rootFile := structure.NewFileEntry(sep+"root"+sep+"file", 1, 400)
+
+	dir.AddChild(file)
+	root.AddChild(dir)
+	root.AddChild(rootFile)
+
+	// counters must not be truncated to 32 bits.
+	root.Size = math.MaxInt64
+	root.LocalDirs = math.MaxUint32 + 1
+	root.LocalFiles = math.MaxUint32 + 2
+	root.TotalDirs = math.MaxUint64
+	root.TotalFiles = math.MaxUint64 - 1
+
+	return root
+}
+
+func requireEntriesEqual(t *testing.T, expected, actual *structure.Entry) {
+	t.Helper()
+
+	require.Equal(t, expected.Path, actual.Path)
+	require.Equal(t, expected.IsDir, actual.IsDir)
+	require.Equal(t, expected.ModTime, actual.ModTime)
+	require.Equal(t, expected.Size, actual.Size)
+	require.Equal(t, expected.LocalDirs, actual.LocalDirs)
+	require.Equal(t, expected.LocalFiles, actual.LocalFiles)
+	require.Equal(t, expected.TotalDirs, actual.TotalDirs)
+	require.Equal(t, expected.TotalFiles, actual.TotalFiles)
+	require.Len(t, actual.Child, len(expected.Child))
+
+	for i := range expected.Child {
+		requireEntriesEqual(t, expected.Child[i], actual.Child[i])
+	}
+}
@@ -6,13 +6,18 @@ import (
 	"iter"
 	"maps"
 	"os"
-	"path/filepath"
 	"slices"
 	"strings"
+	"sync/atomic"
 
 	"github.com/crumbyte/noxdir/drive"
 )
 
+// childIndexThreshold defines the minimal number of child entries required for
+// building the child index. Smaller directories are scanned linearly since it
+// is cheaper than maintaining the map.
+const childIndexThreshold = 32
+
 const (
 	SortPath       drive.SortKey = "1"
 	SortSize       drive.SortKey = "2"
@@ -57,6 +62,15 @@ type Entry struct {
 	// zero if the current instance represents a file.
 	TotalFiles uint64
 
+	// childIdx contains a lazily built index of child entries by their names.
+	// It is invalidated on each change of the child entries list and rebuilt on
+	// the next lookup.
+	childIdx map[string]*Entry
+
+	// lazy contains the location of the child entries that were not decoded
+	// yet. It's nil if the child entries are loaded. See DecodeLazy.
+	lazy atomic.Pointer[lazyBlock]
+
 	// IsDir defines whether the current instance represents a dir or a file.
 	IsDir bool
 }
@@ -101,6 +115,8 @@ func (e *Entry) Ext() string {
 // or files.
 func (e *Entry) EntriesByType(dirs bool) iter.Seq[*Entry] {
 	return func(yield func(*Entry) bool) {
+		e.load()
+
 		for i := range e.Child {
 			if e.Child[i].IsDir == dirs && !yield(e.Child[i]) {
 				break
@@ -112,6 +128,8 @@ func (e *Entry) EntriesByType(dirs bool) iter.Seq[*Entry] {
 // Entries returns an iterator for all the current node's child elements.
 func (e *Entry) Entries() iter.Seq[*Entry] {
 	return func(yield func(*Entry) bool) {
+		e.load()
+
 		for i := range e.Child {
 			if !yield(e.Child[i]) {
 				break
@@ -123,50 +141,91 @@ func (e *Entry) Entries() iter.Seq[*Entry] {
 // GetChildByName tries to find a child element by its name. The search will be
 // done only on the first level of the child entries. If such an entry was not
 // found, a nil value will be returned.
+//
+// For directories with a large number of child entries, the lookup is done
+// using the index which is built lazily on the first call and rebuilt after
+// the list of child entries changes.
 func (e *Entry) GetChildByName(name string) *Entry {
-	path := filepath.Join(e.Path, name)
+	e.load()
 
-	for _, child := range e.Child {
-		if child.Path == path {
-			return child
+	if len(e.Child) < childIndexThreshold {
+		for _, child := range e.Child {
+			if child.Name() == name {
+				return child
+			}
 		}
+
+		return nil
 	}
 
-	return nil
+	idx := e.childIdx
+
+	if len(idx) != len(e.Child) {
+		idx = make(map[string]*Entry, len(e.Child))
+
+		for _, child := range e.Child {
+			idx[child.Name()] = child
+		}
+
+		e.childIdx = idx
+	}
+
+	return idx[name]
 }
 
 // FindChild tries to find a child element by its full path. Unlike the GetChildByName
 // method, which searches within the top level, it searches through the entire
 // root entry structure until it finds the path matching.
+//
+// The path is split into segments relative to the current entry path, and each
+// segment is resolved with the GetChildByName, therefore the lookup complexity
+// depends on the path depth rather than on the number of entries in the tree.
 func (e *Entry) FindChild(path string) *Entry {
-	queue := []*Entry{e}
+	if path == e.Path {
+		return e
+	}
 
-	for len(queue) > 0 {
-		entry := queue[0]
-		queue = queue[1:]
+	rel, ok := strings.CutPrefix(path, e.Path)
+	if !ok || len(rel) == 0 {
+		return nil
+	}
 
-		if entry.Path == path {
-			return entry
+	// the root path of a drive/volume already ends with a separator, so the
+	// relative part will not contain the leading separator.
+	if e.Path[len(e.Path)-1] != os.PathSeparator {
+		if rel[0] != os.PathSeparator {
+			return nil
 		}
 
-		for _, child := range entry.Child {
-			if entry.IsDir {
-				queue = append(queue, child)
-			}
+		rel = rel[1:]
+	}
+
+	entry := e
+
+	for segment := range strings.SplitSeq(rel, string(os.PathSeparator)) {
+		if len(segment) == 0 {
+			continue
+		}
+
+		if entry = entry.GetChildByName(segment); entry == nil {
+			return nil
 		}
 	}
 
-	return nil
+	return entry
 }
 
 // AddChild adds the provided [*Entry] instance to a list of child entries. The
 // counters will be updated respectively depending on the type of child entry.
 func (e *Entry) AddChild(child *Entry) {
+	e.load()
+
 	if e.Child == nil {
 		e.Child = make([]*Entry, 0, 10)
 	}
 
 	e.Child = append(e.Child, child)
+	e.childIdx = nil
 }
 
 // RemoveChild removes the current *Entry instance child entry. It returns a
@@ -174,6 +233,8 @@ func (e *Entry) AddChild(child *Entry) {
 // child item was not found or an unexpected error occurred a boolean false
 // value will be returned.
 func (e *Entry) RemoveChild(child *Entry) bool {
+	e.load()
+
 	if len(e.Child) == 0 {
 		return false
 	}
@@ -202,11 +263,18 @@ func (e *Entry) RemoveChild(child *Entry) bool {
 	}
 
 	e.Child = append(e.Child[:offsetIdx], e.Child[offsetIdx+1:]...)
+	e.childIdx = nil
 
 	return true
 }
 
+// HasChild checks whether the entry has any child entries. It does not load
+// the lazily decoded entries and relies on their stored counters instead.
 func (e *Entry) HasChild() bool {
+	if !e.loaded() {
+		return e.LocalDirs+e.LocalFiles != 0
+	}
+
 	return len(e.Child) != 0
 }
 
@@ -218,6 +286,8 @@ func (e *Entry) SortedChild(sk drive.SortKey, desc bool) *Entry {
 		sortMod = -1
 	}
 
+	e.load()
+
 	slices.SortFunc(e.Child, func(a, b *Entry) int {
 		var x, y int64
 
@@ -240,6 +310,31 @@ func (e *Entry) SortedChild(sk drive.SortKey, desc bool) *Entry {
 	return e
 }
 
+// load decodes the lazily loaded child entries. It does nothing if the child
+// entries are already loaded.
+func (e *Entry) load() {
+	if lb := e.lazy.Load(); lb != nil {
+		lb.loader.load(e, lb)
+	}
+}
+
+// loaded checks whether the child entries are loaded. Only the entries decoded
+// with DecodeLazy can be not loaded.
+func (e *Entry) loaded() bool {
+	return e.lazy.Load() == nil
+}
+
+// replace replaces the entry's state with the state of the provided entry,
+// including the not loaded child entries.
+func (e *Entry) replace(src *Entry) {
+	e.Path, e.Child, e.childIdx = src.Path, src.Child, nil
+	e.ModTime, e.Size, e.IsDir = src.ModTime, src.Size, src.IsDir
+	e.LocalDirs, e.LocalFiles = src.LocalDirs, src.LocalFiles
+	e.TotalDirs, e.TotalFiles = src.TotalDirs, src.TotalFiles
+
+	e.lazy.Store(src.lazy.Load())
+}
+
 func (e *Entry) Copy() *Entry {
 	return &Entry{
 		Path:       e.Path,
@@ -254,22 +349,80 @@ func (e *Entry) Copy() *Entry {
 	}
 }
 
+// snapshot returns a deep copy of the entry and its loaded child entries. The
+// not loaded child entries are not decoded, and the copy refers to the same
+// encoded data instead.
+func (e *Entry) snapshot() *Entry {
+	c := e.Copy()
+
+	if lb := e.lazy.Load(); lb != nil {
+		c.lazy.Store(lb)
+
+		return c
+	}
+
+	for _, child := range e.Child {
+		c.Child = append(c.Child, child.snapshot())
+	}
+
+	return c
+}
+
+// Diff returns the delta between the current and the provided entry states. It
+// compares the entire structure level by level and reports the added, removed,
+// and changed entries. The changed entries include both files and directories,
+// where the directory delta represents the rolled-up delta of all its nested
+// entries.
 func (e *Entry) Diff(ne *Entry) *Diff {
+	return e.diff(ne, EntryList.Diff)
+}
+
+// Compare returns the delta between two different directories, e.g., a backup
+// and its source. Unlike the Diff function, the entries are matched by their
+// paths relative to the compared directories. The entries existing only in the
+// current directory are reported as removed, the entries existing only in the
+// provided directory are reported as added, and the entries existing in both
+// directories with different sizes are reported as changed. The child entries
+// of a directory that is empty in the provided directory are reported as
+// removed as well.
+func (e *Entry) Compare(other *Entry) *Diff {
+	return e.diff(other, func(el, nl EntryList) *Diff {
+		return el.diff(nl, (*Entry).Name)
+	})
+}
+
+// diff compares the entries level by level. The child entries of the compared
+// pair are compared by the provided listDiff function.
+func (e *Entry) diff(ne *Entry, listDiff func(el, nl EntryList) *Diff) *Diff {
 	var ep EntryPair
 
-	d := Diff{Added: make([]*Entry, 0), Removed: make([]*Entry, 0)}
+	d := Diff{
+		Added:   make([]*Entry, 0),
+		Removed: make([]*Entry, 0),
+		Changed: make([]EntryChange, 0),
+	}
 
 	queue := []EntryPair{{e, ne}}
 
 	for len(queue) > 0 {
 		ep, queue = queue[0], queue[1:]
 
-		diff := EntryList(ep[0].Child).Diff(ep[1].Child)
+		ep[0].load()
+		ep[1].load()
+
+		diff := listDiff(ep[0].Child, ep[1].Child)
 
 		d.Added = append(d.Added, diff.Added...)
 		d.Removed = append(d.Removed, diff.Removed...)
 
 		for _, sameEntries := range diff.Same {
+			if sameEntries[0].Size != sameEntries[1].Size {
+				d.Changed = append(
+					d.Changed,
+					EntryChange{Old: sameEntries[0], New: sameEntries[1]},
+				)
+			}
+
 			if sameEntries[0].IsDir {
 				queue = append(queue, sameEntries)
 			}
@@ -281,14 +434,28 @@ func (e *Entry) Diff(ne *Entry) *Diff {
 
 type EntryPair [2]*Entry
 
+// EntryChange represents an entry that exists in both compared states, but its
+// size has been changed. It contains both the old and the new entry states.
+type EntryChange struct {
+	Old *Entry
+	New *Entry
+}
+
+// Delta returns the signed size difference between the new and the old entry
+// states. A positive value means that the entry has grown.
+func (ec EntryChange) Delta() int64 {
+	return ec.New.Size - ec.Old.Size
+}
+
 type Diff struct {
 	Same    []EntryPair
 	Added   []*Entry
 	Removed []*Entry
+	Changed []EntryChange
 }
 
 func (d *Diff) Empty() bool {
-	return len(d.Added) == 0 && len(d.Removed) == 0
+	return len(d.Added) == 0 && len(d.Removed) == 0 && len(d.Changed) == 0
 }
 
 func (d *Diff) Sort() *Diff {
@@ -300,9 +467,40 @@ func (d *Diff) Sort() *Diff {
 		return cmp.Compare(b.Size, a.Size)
 	})
 
+	slices.SortFunc(d.Changed, func(a, b EntryChange) int {
+		return CompareDelta(a.Delta(), b.Delta())
+	})
+
 	return d
 }
 
+// CompareDelta compares the size deltas by their absolute values in descending
+// order, so the biggest changes go first regardless of their direction.
+func CompareDelta(a, b int64) int {
+	return cmp.Compare(absInt64(b), absInt64(a))
+}
+
+// ChangeStats returns the total number of bytes the changed entries have grown
+// and shrunk by. Only file changes are counted since the directory deltas are
+// already rolled up from the nested files.
+func ChangeStats(changes []EntryChange) (int64, int64) {
+	grown, shrunk := int64(0), int64(0)
+
+	for _, change := range changes {
+		if change.New.IsDir {
+			continue
+		}
+
+		if delta := change.Delta(); delta > 0 {
+			grown += delta
+		} else {
+			shrunk -= delta
+		}
+	}
+
+	return grown, shrunk
+}
+
 func DiffStats(entries []*Entry) (uint64, uint64, int64) {
 	dirs, files := uint64(0), uint64(0)
 	total := int64(0)
@@ -333,31 +531,43 @@ type EntryList []*Entry
 //
 // It uses a straightforward approach by comparing the EntryList items one by one
 // for each set. If there is an item in the provided EntryList that does not
-// exist in the current EntryList, then it is considered to be "added".
+// exist in the current EntryList, then it is considered to be "added". If the
+// provided EntryList is empty, the delta is empty as well.
 func (el EntryList) Diff(newList EntryList) *Diff {
+	if len(newList) == 0 {
+		return &Diff{
+			Same:    make([]EntryPair, 0),
+			Added:   make([]*Entry, 0),
+			Removed: make([]*Entry, 0),
+		}
+	}
+
+	return el.diff(newList, func(entry *Entry) string { return entry.Path })
+}
+
+// diff matches the entries of both lists by the provided key. Unlike the Diff
+// function, all entries of the current list are reported as removed if the
+// provided list is empty.
+func (el EntryList) diff(newList EntryList, key func(*Entry) string) *Diff {
 	d := &Diff{
 		Same:    make([]EntryPair, 0),
 		Added:   make([]*Entry, 0),
 		Removed: make([]*Entry, 0),
 	}
 
-	if len(newList) == 0 {
-		return d
-	}
-
 	elMap := make(map[string]*Entry, len(el))
 
 	for _, entry := range el {
-		elMap[entry.Path] = entry
+		elMap[key(entry)] = entry
 	}
 
 	for _, newChild := range newList {
-		oldChild, ok := elMap[newChild.Path]
+		oldChild, ok := elMap[key(newChild)]
 
 		if ok && oldChild.IsDir == newChild.IsDir {
 			d.Same = append(d.Same, EntryPair{oldChild, newChild})
 
-			delete(elMap, newChild.Path)
+			delete(elMap, key(newChild))
 
 			continue
 		}
@@ -371,3 +581,11 @@ func (el EntryList) Diff(newList EntryList) *Diff {
 
 	return d
 }
+
+func absInt64(v int64) int64 {
+	if v < 0 {
+		return -v
+	}
+
+	return v
+}
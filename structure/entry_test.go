@@ -0,0 +1,128 @@
+package structure_test
+
+import (
+	"os"
+	"path/filepath"
+	"strconv"
+	"testing"
+
+	"github.com/crumbyte/noxdir/structure"
+
+	"github.com/stretchr/testify/require"
+)
+
+const benchDirEntries = 100_000
+
+func TestEntry_GetChildByName(t *testing.T) {
+	root := buildWideEntry("root", 100)
+
+	for _, i := range []int{0, 31, 32, 99} {
+		name := "child_" + strconv.Itoa(i)
+
+		child := root.GetChildByName(name)
+		require.NotNil(t, child, name)
+		require.Equal(t, name, child.Name())
+	}
+
+	require.Nil(t, root.GetChildByName("unknown"))
+
+	newChild := structure.NewFileEntry(filepath.Join("root", "new_child"), 1, 0)
+	root.AddChild(newChild)
+
+	require.Same(t, newChild, root.GetChildByName("new_child"))
+
+	require.True(t, root.RemoveChild(newChild))
+	require.Nil(t, root.GetChildByName("new_child"))
+}
+
+func TestEntry_FindChild(t *testing.T) {
+	sep := string(os.PathSeparator)
+
+	root := structure.NewDirEntry("root", 0)
+	level1 := structure.NewDirEntry(filepath.Join("root", "level1"), 0)
+	level2 := structure.NewDirEntry(filepath.Join("root", "level1", "level2"), 0)
+	file := structure.NewFileEntry(filepath.Join("root", "level1", "level2", "file"), 1, 0)
+
+	root.AddChild(level1)
+	level1.AddChild(level2)
+	level2.AddChild(file)
+
+	tableData := []struct {
+		expected *structure.Entry
+		path     string
+	}{
+		{expected: root, path: "root"},
+		{expected: level1, path: filepath.Join("root", "level1")},
+		{expected: level2, path: filepath.Join("root", "level1", "level2")},
+		{expected: file, path: filepath.Join("root", "level1", "level2", "file")},
+		{expected: level1, path: "root" + sep + "level1" + sep},
+		{expected: nil, path: filepath.Join("root", "level1", "unknown")},
+		{expected: nil, path: "root_other" + sep + "level1"},
+		{expected: nil, path: "other"},
+	}
+
+	for _, data := range tableData {
+		t.Run(data.path, func(t *testing.T) {
+			require.Same(t, data.expected, root.FindChild(data.path))
+		})
+	}
+
+	driveRoot := structure.NewDirEntry(sep, 0)
+	dir := structure.NewDirEntry(sep+"dir", 0)
+	driveRoot.AddChild(dir)
+
+	require.Same(t, dir, driveRoot.FindChild(sep+"dir"))
+	require.Same(t, dir, structure.NewTree(driveRoot).Find(sep+"dir"))
+}
+
+func BenchmarkEntry_GetChildByName(b *testing.B) {
+	root := buildWideEntry("root", benchDirEntries)
+	name := "child_" + strconv.Itoa(benchDirEntries-1)
+
+	// the first lookup builds the index.
+	root.GetChildByName(name)
+
+	for b.Loop() {
+		if root.GetChildByName(name) == nil {
+			b.Fatal("child not found")
+		}
+	}
+}
+
+func BenchmarkEntry_FindChild(b *testing.B) {
+	root := buildWideEntry("root", benchDirEntries)
+	parent := root
+
+	// build a deep path where each level contains a wide directory.
+	for i := range 5 {
+		wide := buildWideEntry(filepath.Join(parent.Path, "level_"+strconv.Itoa(i)), benchDirEntries)
+
+		parent.AddChild(wide)
+		parent = wide
+	}
+
+	target := filepath.Join(parent.Path, "child_"+strconv.Itoa(benchDirEntries-1))
+
+	// the first lookup builds the indexes for each level.
+	root.FindChild(target)
+
+	for b.Loop() {
+		if root.FindChild(target) == nil {
+			b.Fatal("child not found")
+		}
+	}
+}
+
+func buildWideEntry(path string, size int) *structure.Entry {
+	root := structure.NewDirEntry(path, 0)
+
+	for i := range size {
+		root.AddChild(
+			structure.NewFileEntry(
+				filepath.Join(path, "child_"+strconv.Itoa(i)), 1, 0,
+			),
+		)
+	}
+
+	return root
+}
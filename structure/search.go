@@ -0,0 +1,86 @@
+package structure
+
+import (
+	"fmt"
+	"path"
+	"strings"
+)
+
+// Search walks the subtree of the root entry and collects the entries whose
+// names match the pattern. The walk is done in steps of a limited size, so the
+// caller can stream the results of a large tree without blocking for the
+// entire walk.
+//
+// The pattern is case-insensitive. If it contains any of the glob special
+// characters "*", "?", or "[", the entire name must match the glob pattern.
+// Otherwise, the name must contain the pattern.
+type Search struct {
+	match   func(name string) bool
+	pending []*Entry
+	scanned uint64
+}
+
+// NewSearch creates a new search within the root entry. The root entry itself
+// is not checked. An error is returned if the pattern is a malformed glob
+// pattern.
+func NewSearch(root *Entry, pattern string) (*Search, error) {
+	pattern = strings.ToLower(pattern)
+
+	match := func(name string) bool {
+		return strings.Contains(strings.ToLower(name), pattern)
+	}
+
+	if strings.ContainsAny(pattern, "*?[") {
+		if _, err := path.Match(pattern, ""); err != nil {
+			return nil, fmt.Errorf("invalid pattern: %s", pattern)
+		}
+
+		match = func(name string) bool {
+			ok, _ := path.Match(pattern, strings.ToLower(name))
+
+			return ok
+		}
+	}
+
+	s := &Search{match: match}
+
+	if root != nil && root.IsDir {
+		s.pending = append(s.pending, root)
+	}
+
+	return s, nil
+}
+
+// Step checks at least n entries, or all remaining entries if there are fewer
+// of them, and returns the matched ones. The directory's child entries are
+// always checked together, so a step can exceed the limit. The second value
+// tells whether the walk is finished.
+func (s *Search) Step(n int) ([]*Entry, bool) {
+	var matched []*Entry
+
+	for checked := 0; checked < n && len(s.pending) > 0; {
+		dir := s.pending[len(s.pending)-1]
+		s.pending = s.pending[:len(s.pending)-1]
+
+		for child := range dir.Entries() {
+			checked++
+
+			if s.match(child.Name()) {
+				matched = append(matched, child)
+			}
+
+			if child.IsDir {
+				s.pending = append(s.pending, child)
+			}
+		}
+
+		s.scanned += uint64(len(dir.Child))
+	}
+
+	return matched, len(s.pending) == 0
+}
+
+// Scanned returns the number of entries checked so far.
+func (s *Search) Scanned() uint64 {
+	return s.scanned
+}
@@ -0,0 +1,61 @@
+package structure_test
+
+import (
+	"path/filepath"
+	"slices"
+	"testing"
+
+	"github.com/crumbyte/noxdir/structure"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestSearch(t *testing.T) {
+	root := structure.NewDirEntry("root", 0)
+	vms := structure.NewDirEntry(filepath.Join("root", "vms"), 0)
+	nested := structure.NewDirEntry(filepath.Join("root", "vms", "Nested.vmdk"), 0)
+
+	root.AddChild(vms)
+	root.AddChild(structure.NewFileEntry(filepath.Join("root", "disk.VMDK"), 1, 0))
+	root.AddChild(structure.NewFileEntry(filepath.Join("root", "notes.txt"), 1, 0))
+	vms.AddChild(nested)
+	vms.AddChild(structure.NewFileEntry(filepath.Join("root", "vms", "linux.vmdk"), 1, 0))
+	nested.AddChild(structure.NewFileEntry(filepath.Join("root", "vms", "Nested.vmdk", "a.vmdk"), 1, 0))
+
+	tableData := []struct {
+		pattern  string
+		expected []string
+	}{
+		{pattern: "*.vmdk", expected: []string{"Nested.vmdk", "a.vmdk", "disk.VMDK", "linux.vmdk"}},
+		{pattern: "vm", expected: []string{"Nested.vmdk", "a.vmdk", "disk.VMDK", "linux.vmdk", "vms"}},
+		{pattern: "l?nux.*", expected: []string{"linux.vmdk"}},
+		{pattern: "*.iso", expected: nil},
+	}
+
+	for _, td := range tableData {
+		s, err := structure.NewSearch(root, td.pattern)
+		require.NoError(t, err)
+
+		var names []string
+
+		for {
+			matched, done := s.Step(1)
+
+			for _, e := range matched {
+				names = append(names, e.Name())
+			}
+
+			if done {
+				break
+			}
+		}
+
+		slices.Sort(names)
+
+		require.Equal(t, td.expected, names, td.pattern)
+		require.Equal(t, uint64(6), s.Scanned())
+	}
+
+	_, err := structure.NewSearch(root, "[vmdk")
+	require.Error(t, err)
+}
@@ -1,7 +1,11 @@
 package structure_test
 
 import (
+	"bytes"
+	"container/heap"
+	"os"
 	"slices"
+	"strconv"
 	"testing"
 
 	"github.com/crumbyte/noxdir/structure"
@@ -133,3 +137,59 @@ func TestTopEntries_ScanDirs(t *testing.T) {
 		require.True(t, slices.Contains(expected, tf.Name()))
 	}
 }
+
+func TestTopEntries_ScanLazy(t *testing.T) {
+	sep := string(os.PathSeparator)
+
+	root := structure.NewDirEntry(sep+"root", 0)
+	tree := structure.NewTree(root)
+
+	parent := root
+
+	for level := range 4 {
+		dir := structure.NewDirEntry(parent.Path+sep+"level_"+strconv.Itoa(level), 0)
+
+		parent.AddChild(dir)
+		parent.AddChild(structure.NewFileEntry(dir.Path+"_file", int64(level+1)*100, 0))
+		dir.AddChild(structure.NewFileEntry(dir.Path+sep+"file", int64(level+1)*1000, 0))
+
+		parent = dir
+	}
+
+	tree.CalculateSize()
+
+	var buf bytes.Buffer
+
+	require.NoError(t, structure.NewEncoder(&buf).Encode(&structure.CacheData{Root: root}))
+
+	data, err := structure.DecodeLazy(bytes.NewReader(buf.Bytes()), int64(buf.Len()))
+	require.NoError(t, err)
+
+	// the top entries of the lazily decoded tree must cover the not loaded
+	// directories as well.
+	expected, actual := structure.NewTopEntries(5), structure.NewTopEntries(5)
+
+	expected.ScanFiles(root)
+	expected.ScanDirs(root)
+
+	actual.ScanFiles(data.Root)
+	actual.ScanDirs(data.Root)
+
+	require.Equal(t, topPaths(t, expected.Files()), topPaths(t, actual.Files()))
+	require.Equal(t, topPaths(t, expected.Dirs()), topPaths(t, actual.Dirs()))
+}
+
+func topPaths(t *testing.T, h heap.Interface) []string {
+	t.Helper()
+
+	paths := make([]string, 0, h.Len())
+
+	for h.Len() > 0 {
+		e, ok := heap.Pop(h).(*structure.Entry)
+		require.True(t, ok)
+
+		paths = append(paths, e.Path)
+	}
+
+	return paths
+}
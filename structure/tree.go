@@ -2,8 +2,11 @@ package structure
 
 import (
 	"errors"
+	"fmt"
 	"path/filepath"
 	"runtime"
+	"slices"
+	"strconv"
 	"strings"
 	"sync"
 	"sync/atomic"
@@ -23,6 +26,39 @@ const (
 // TreeOpt defines a custom type for configuring a *Tree instance.
 type TreeOpt func(*Tree)
 
+// ScanOptions describes the scan settings that affect the tree structure. The
+// options are stored along with the cached tree, so the cache created with
+// different settings will not be used.
+type ScanOptions struct {
+	// SizeLimit contains the raw size limits definition for the scanned files.
+	SizeLimit string
+
+	// Exclude contains a list of excluded directory names.
+	Exclude []string
+
+	// NoHidden defines whether the hidden entries were skipped.
+	NoHidden bool
+}
+
+// String returns the canonical representation of the options. The options
+// with the same set of values always produce the same string regardless of
+// the excluded names order or case.
+func (so ScanOptions) String() string {
+	exclude := make([]string, 0, len(so.Exclude))
+
+	for _, e := range so.Exclude {
+		if e = strings.ToLower(strings.TrimSpace(e)); len(e) != 0 {
+			exclude = append(exclude, e)
+		}
+	}
+
+	slices.Sort(exclude)
+
+	return "exclude=" + strings.Join(slices.Compact(exclude), ",") +
+		";size-limit=" + strings.ToLower(strings.TrimSpace(so.SizeLimit)) +
+		";no-hidden=" + strconv.FormatBool(so.NoHidden)
+}
+
 // WithExclude allows setting a list of directory names that must be excluded
 // from the traversal during the tree build-up process. The directory name can
 // represent an absolute path or just a part of the name. In the last case, all
@@ -54,6 +90,15 @@ func WithFileInfoFilter(fl []drive.FileInfoFilter) TreeOpt {
 	}
 }
 
+// WithScanOptions sets the scan options describing the tree's settings. The
+// options do not affect the traversal itself, but they're used to validate the
+// cached tree state.
+func WithScanOptions(so ScanOptions) TreeOpt {
+	return func(t *Tree) {
+		t.scanOptions = so
+	}
+}
+
 func WithCache(c *cache.Cache) TreeOpt {
 	return func(t *Tree) {
 		t.cache = c
@@ -78,10 +123,17 @@ type Tree struct {
 	cache            *cache.Cache
 	exclude          []string
 	fiFilters        []drive.FileInfoFilter
+	scanOptions      ScanOptions
+	loader           atomic.Pointer[blockLoader]
 	calculateSizeSem uint32
 	partialRoot      bool
 	useCache         bool
-	dirty            bool
+
+	// changes and persisted contain the number of the tree state changes and
+	// the number of changes included into the last persisted cache snapshot.
+	// The tree must be persisted if they differ.
+	changes   atomic.Uint64
+	persisted atomic.Uint64
 }
 
 func NewTree(root *Entry, opts ...TreeOpt) *Tree {
@@ -104,6 +156,7 @@ func (t *Tree) Clone(root *Entry, opts ...TreeOpt) *Tree {
 		cache:       t.cache,
 		exclude:     t.exclude,
 		fiFilters:   t.fiFilters,
+		scanOptions: t.scanOptions,
 		partialRoot: t.partialRoot,
 	}
 
@@ -119,9 +172,21 @@ func (t *Tree) Root() *Entry {
 	return t.root
 }
 
+// Find returns the *Entry instance by its full path or nil if the path does not
+// belong to the current tree. The lookup is done by resolving each path segment
+// starting from the root, so it does not traverse the entire tree.
+func (t *Tree) Find(path string) *Entry {
+	if t.root == nil {
+		return nil
+	}
+
+	return t.root.FindChild(path)
+}
+
 // SetRoot changes the current root of the tree instance.
 func (t *Tree) SetRoot(root *Entry) {
 	t.root = root
+	t.loader.Store(nil)
 }
 
 // SetPartialRoot allows setting a partial root state value. It can be used in
@@ -154,7 +219,8 @@ func (t *Tree) CalculateSize() {
 
 	var calculate func(e *Entry) int64
 	calculate = func(e *Entry) int64 {
-		if !e.IsDir {
+		// the not loaded entries keep their cached totals.
+		if !e.IsDir || !e.loaded() {
 			return e.Size
 		}
 
@@ -185,7 +251,7 @@ func (t *Tree) CalculateSize() {
 }
 
 func (t *Tree) MarkDirty() {
-	t.dirty = true
+	t.changes.Add(1)
 }
 
 // Traverse traverses the current root entry instance for all internal files, and
@@ -205,12 +271,12 @@ func (t *Tree) Traverse(skipCache bool) error {
 	)
 
 	if !skipCache && t.cachingEnabled() {
-		if err := t.cache.Get(t.root.Path, t.root); err == nil {
+		if err := t.loadCache(); err == nil {
 			return nil
 		}
 	}
 
-	t.dirty = true
+	t.MarkDirty()
 
 	drive.InoFilterInstance.Reset()
 
@@ -236,32 +302,144 @@ func (t *Tree) Traverse(skipCache bool) error {
 	return errors.Join(errList...)
 }
 
-func (t *Tree) Cached() (*Tree, error) {
-	if t.cachingEnabled() {
-		return t, nil
+// Snapshots returns a list of cache snapshots stored for the current root. The
+// list is sorted by the creation time, starting from the most recent snapshot.
+func (t *Tree) Snapshots() ([]cache.Snapshot, error) {
+	if t.cache == nil || t.root == nil {
+		return nil, nil
 	}
 
-	if !t.cache.Has(t.root.Path) {
-		return nil, nil
+	return t.cache.Snapshots(t.cacheKey(t.root.Path))
+}
+
+// DeleteSnapshot deletes the current root's cache snapshot by its identifier.
+func (t *Tree) DeleteSnapshot(id string) error {
+	if t.cache == nil || t.root == nil {
+		return cache.ErrNoCache
 	}
 
-	tree := NewTree(NewDirEntry(t.root.Path, time.Now().Unix()))
-	if err := t.cache.Get(tree.root.Path, tree.root); err != nil {
+	return t.cache.DeleteSnapshot(t.cacheKey(t.root.Path), id)
+}
+
+// CachedSnapshot restores the provided cache snapshot into a new *Tree instance.
+// The current tree state remains unchanged. The snapshot is decoded lazily, so
+// the returned tree must be closed once it's not used anymore. See Tree.Close.
+func (t *Tree) CachedSnapshot(s cache.Snapshot) (*Tree, error) {
+	if t.cache == nil || t.root == nil {
+		return nil, cache.ErrNoCache
+	}
+
+	data, err := t.decodeSnapshot(s, t.root.Path)
+	if err != nil {
 		return nil, err
 	}
 
+	tree := NewTree(data.Root)
+	tree.loader.Store(data.loader)
+
 	return tree, nil
 }
 
-func (t *Tree) PersistCache() (chan struct{}, error) {
-	if t.cache == nil || t.partialRoot || t.root == nil || !t.dirty {
+// Close releases the cache snapshot the tree was lazily restored from, so the
+// snapshot file is closed and can be removed. The entries that were not loaded
+// yet have no child entries afterward, and the tree cannot be persisted anymore.
+// Closing the tree that was not restored from the cache has no effect.
+func (t *Tree) Close() error {
+	if loader := t.loader.Load(); loader != nil {
+		return loader.Close()
+	}
+
+	return nil
+}
+
+// PersistCache saves the current tree state as a new cache snapshot in the
+// background. The state will be saved only if it was changed since it was
+// loaded or persisted last time. The snapshot is encoded from a copy of the
+// tree, so the tree can be modified while the snapshot is being written. The
+// returned channel receives the write result and is closed afterward.
+func (t *Tree) PersistCache() chan error {
+	changes := t.changes.Load()
+
+	if t.cache == nil || t.root == nil || changes == t.persisted.Load() {
+		result := make(chan error)
+		close(result)
+
+		return result
+	}
+
+	// the tree with the blocks that failed to load contains incomplete data,
+	// and it must not overwrite the cache.
+	if err := t.LoadErr(); err != nil {
+		result := make(chan error, 1)
+		result <- err
+		close(result)
+
+		return result
+	}
+
+	written := t.cache.SetAsync(
+		t.cacheKey(t.root.Path),
+		&CacheData{
+			Root:   t.root.snapshot(),
+			Header: Header{Options: t.scanOptions.String()},
+		},
+	)
+
+	result := make(chan error, 1)
+
+	go func() {
+		defer close(result)
+
+		err := <-written
+
+		// the failed write keeps the tree dirty, so it will be retried on the
+		// next call.
+		if err == nil {
+			t.markPersisted(changes)
+		}
+
+		result <- err
+	}()
+
+	return result
+}
+
+// markPersisted stores the number of changes included into the persisted
+// snapshot. The value is never decreased, since the writes started earlier
+// can finish later.
+func (t *Tree) markPersisted(changes uint64) {
+	for {
+		persisted := t.persisted.Load()
+
+		if persisted >= changes || t.persisted.CompareAndSwap(persisted, changes) {
+			return
+		}
+	}
+}
+
+// LoadErr returns the first error that occurred while loading the lazily
+// decoded entries restored from the cache. The directory that failed to load
+// has no child entries; hence, the tree root must be rescanned to restore the
+// actual state. See TraverseNodeAsync.
+func (t *Tree) LoadErr() error {
+	if loader := t.loader.Load(); loader != nil {
+		return loader.Err()
+	}
+
+	return nil
+}
+
+// WaitCache returns a channel that will be closed when all pending cache writes
+// are finished, including the ones started for the previous roots.
+func (t *Tree) WaitCache() chan struct{} {
+	if t.cache == nil {
 		done := make(chan struct{})
 		close(done)
 
-		return done, nil
+		return done
 	}
 
-	return t.cache.SetAsync(t.root.Path, t.root)
+	return t.cache.Wait()
 }
 
 // scanQueue represents a queue for *Entry instances scheduled for traversal.
@@ -295,8 +473,18 @@ func (sq *scanQueue) Get() (*Entry, bool) {
 	return entry, true
 }
 
+// TraverseNodeAsync rescans the provided node skipping the cache. If the node is
+// the tree root, the entire cached state is discarded, including the entries
+// that failed to load.
 func (t *Tree) TraverseNodeAsync(node *Entry) (chan struct{}, chan error) {
-	t.dirty, node.Child = true, nil
+	t.MarkDirty()
+
+	if node == t.root {
+		t.loader.Store(nil)
+	}
+
+	node.Child, node.childIdx = nil, nil
+	node.lazy.Store(nil)
 
 	return t.Clone(node, WithPartialRoot()).TraverseAsync(true)
 }
@@ -308,21 +496,49 @@ func (t *Tree) TraverseAsync(skipCache bool) (chan struct{}, chan error) {
 		return nil, nil
 	}
 
+	if !skipCache && t.cachingEnabled() {
+		if _, ok := t.cacheSource(); ok {
+			return t.loadCacheAsync()
+		}
+	}
+
+	return t.scanAsync()
+}
+
+// loadCacheAsync restores the tree state from the cache in the background. If
+// the cache cannot be used, e.g., it's corrupted or was created with different
+// settings, the full scan will be performed instead.
+func (t *Tree) loadCacheAsync() (chan struct{}, chan error) {
 	done, errChan := make(chan struct{}), make(chan error, 1)
 
-	if !skipCache && t.cachingEnabled() && t.cache.Has(t.root.Path) {
-		go func() {
-			if err := t.cache.Get(t.root.Path, t.root); err == nil {
-				close(done)
-			}
+	go func() {
+		defer func() {
+			close(done)
+			close(errChan)
 		}()
 
-		return done, errChan
-	}
+		if err := t.loadCache(); err == nil {
+			return
+		}
+
+		scanDone, scanErrChan := t.scanAsync()
+
+		for err := range scanErrChan {
+			errChan <- err
+		}
+
+		<-scanDone
+	}()
+
+	return done, errChan
+}
+
+func (t *Tree) scanAsync() (chan struct{}, chan error) {
+	done, errChan := make(chan struct{}), make(chan error, 1)
 
 	var wg sync.WaitGroup
 
-	t.dirty = true
+	t.MarkDirty()
 
 	queue := scanQueue{entries: make([]*Entry, 0, bfsQueueSize)}
 	queue.Push(t.root)
@@ -450,6 +666,104 @@ func (t *Tree) filterFileInfo(fi drive.FileInfo) bool {
 	return true
 }
 
+// loadCache restores the latest cache snapshot into the current root entry. If
+// the partial root has no cache of its own, it will be restored from the cache
+// of the closest ancestor directory, e.g., a full drive scan. The root remains
+// unchanged if the cache does not exist or cannot be used.
+func (t *Tree) loadCache() error {
+	source, ok := t.cacheSource()
+	if !ok {
+		return cache.ErrNoCache
+	}
+
+	snapshots, err := t.cache.Snapshots(t.cacheKey(source))
+	if err != nil {
+		return err
+	}
+
+	if len(snapshots) == 0 {
+		return cache.ErrNoCache
+	}
+
+	data, err := t.decodeSnapshot(snapshots[0], source)
+	if err != nil {
+		return err
+	}
+
+	cachedRoot := data.Root.FindChild(t.root.Path)
+	if cachedRoot == nil || !cachedRoot.IsDir {
+		_ = data.Close()
+
+		return cache.ErrNoCache
+	}
+
+	t.root.replace(cachedRoot)
+	t.loader.Store(data.loader)
+
+	return nil
+}
+
+// decodeSnapshot lazily decodes the provided snapshot and validates it against
+// the expected root path. Only the root entry is decoded immediately, and the
+// nested entries will be decoded on demand.
+func (t *Tree) decodeSnapshot(s cache.Snapshot, root string) (*CacheData, error) {
+	f, err := t.cache.OpenFile(s)
+	if err != nil {
+		return nil, err
+	}
+
+	data, err := DecodeLazy(f, s.Size)
+	if err != nil {
+		_ = f.Close()
+
+		return nil, err
+	}
+
+	if err = t.validateHeader(data.Header, root); err != nil {
+		_ = data.Close()
+
+		return nil, err
+	}
+
+	return data, nil
+}
+
+// cacheSource returns the path of the cached tree containing the current root.
+// It's either the root itself or, for partial roots, the closest ancestor that
+// was cached with the same scan options.
+func (t *Tree) cacheSource() (string, bool) {
+	for path := t.root.Path; ; path = filepath.Dir(path) {
+		if t.cache.Has(t.cacheKey(path)) {
+			return path, true
+		}
+
+		if !t.partialRoot || filepath.Dir(path) == path {
+			return "", false
+		}
+	}
+}
+
+// validateHeader checks whether the cached tree was built for the provided root
+// and with the same scan options.
+func (t *Tree) validateHeader(h Header, root string) error {
+	if h.Root != root {
+		return fmt.Errorf("%w: root %q does not match", ErrIncompatibleFormat, h.Root)
+	}
+
+	if h.Options != t.scanOptions.String() {
+		return fmt.Errorf("%w: scan options %q do not match", ErrIncompatibleFormat, h.Options)
+	}
+
+	return nil
+}
+
+// cacheKey returns the key identifying the cache of the tree with the provided
+// root path. The key consists of the root path and the scan options, so the
+// trees built with different options never share the cache.
+func (t *Tree) cacheKey(root string) string {
+	return root + "\x00" + t.scanOptions.String()
+}
+
 func (t *Tree) cachingEnabled() bool {
 	return t.useCache && t.cache != nil
 }
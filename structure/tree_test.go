@@ -2,6 +2,7 @@ package structure_test
 
 import (
 	"errors"
+	"io"
 	"os"
 	"path/filepath"
 	"slices"
@@ -9,6 +10,7 @@ import (
 	"testing"
 	"time"
 
+	"github.com/crumbyte/noxdir/pkg/cache"
 	"github.com/crumbyte/noxdir/structure"
 
 	"github.com/stretchr/testify/require"
@@ -177,6 +179,338 @@ func TestTree_TraverseAsync(t *testing.T) {
 	require.NoError(t, os.RemoveAll(entryRoot))
 }
 
+func TestTree_TraverseCacheOptions(t *testing.T) {
+	root, err := filepath.Abs(".")
+	require.NoError(t, err)
+
+	entryRoot := initTmpEntry(t, &testEntryInstance, root)
+
+	defer func() {
+		require.NoError(t, os.RemoveAll(entryRoot))
+	}()
+
+	c, err := cache.NewCache(
+		func(w io.Writer) cache.Encoder { return structure.NewEncoder(w) },
+		func(r io.Reader) cache.Decoder { return structure.NewDecoder(r) },
+		false,
+		t.TempDir(),
+	)
+	require.NoError(t, err)
+
+	newTree := func(so structure.ScanOptions) *structure.Tree {
+		return structure.NewTree(
+			structure.NewDirEntry(entryRoot, 0),
+			structure.WithCache(c),
+			structure.WithUseCache(),
+			structure.WithScanOptions(so),
+		)
+	}
+
+	options := structure.ScanOptions{Exclude: []string{"b", "A"}}
+
+	tree := newTree(options)
+	require.NoError(t, tree.Traverse(true))
+
+	require.NoError(t, <-tree.PersistCache())
+
+	newFile := filepath.Join(entryRoot, "new_file")
+	require.NoError(t, os.WriteFile(newFile, nil, 0600))
+
+	// the same options in a different order must use the cache.
+	tree = newTree(structure.ScanOptions{Exclude: []string{"a", "b"}})
+	require.NoError(t, tree.Traverse(false))
+	require.Nil(t, tree.Find(newFile))
+
+	// different options must not use the cache and rescan the root.
+	tree = newTree(structure.ScanOptions{NoHidden: true})
+	require.NoError(t, tree.Traverse(false))
+	require.NotNil(t, tree.Find(newFile))
+	require.NoError(t, <-tree.PersistCache())
+
+	// the cache for different options must not overwrite the existing one.
+	tree = newTree(options)
+	require.NoError(t, tree.Traverse(false))
+	require.Nil(t, tree.Find(newFile))
+
+	snapshots, err := tree.Snapshots()
+	require.NoError(t, err)
+	require.Len(t, snapshots, 1)
+}
+
+func TestTree_TraversePartialRootCache(t *testing.T) {
+	root, err := filepath.Abs(".")
+	require.NoError(t, err)
+
+	entryRoot := initTmpEntry(t, &testEntryInstance, root)
+
+	defer func() {
+		require.NoError(t, os.RemoveAll(entryRoot))
+	}()
+
+	c, err := cache.NewCache(
+		func(w io.Writer) cache.Encoder { return structure.NewEncoder(w) },
+		func(r io.Reader) cache.Decoder { return structure.NewDecoder(r) },
+		false,
+		t.TempDir(),
+	)
+	require.NoError(t, err)
+
+	newTree := func(path string) *structure.Tree {
+		return structure.NewTree(
+			structure.NewDirEntry(path, 0),
+			structure.WithCache(c),
+			structure.WithUseCache(),
+			structure.WithPartialRoot(),
+		)
+	}
+
+	tree := newTree(entryRoot)
+	require.NoError(t, tree.Traverse(true))
+	tree.CalculateSize()
+	require.NoError(t, <-tree.PersistCache())
+
+	subRoot := filepath.Join(entryRoot, "level_1_3")
+	newFile := filepath.Join(subRoot, "new_file")
+	require.NoError(t, os.WriteFile(newFile, nil, 0600))
+
+	// the nested root must be restored from the ancestor's cache.
+	tree = newTree(subRoot)
+	require.NoError(t, tree.Traverse(false))
+	require.Nil(t, tree.Find(newFile))
+	require.Equal(t, uint64(3), tree.Root().LocalDirs)
+	require.Equal(t, uint64(12), tree.Root().TotalFiles)
+
+	snapshots, err := tree.Snapshots()
+	require.NoError(t, err)
+	require.Empty(t, snapshots)
+
+	// the rescanned nested root must have its own cache.
+	tree = newTree(subRoot)
+	require.NoError(t, tree.Traverse(true))
+	require.NotNil(t, tree.Find(newFile))
+	require.NoError(t, <-tree.PersistCache())
+
+	tree = newTree(subRoot)
+	require.NoError(t, tree.Traverse(false))
+	require.NotNil(t, tree.Find(newFile))
+
+	snapshots, err = tree.Snapshots()
+	require.NoError(t, err)
+	require.Len(t, snapshots, 1)
+}
+
+func TestTree_LazyCache(t *testing.T) {
+	root, err := filepath.Abs(".")
+	require.NoError(t, err)
+
+	entryRoot := initTmpEntry(t, &testEntryInstance, root)
+
+	defer func() {
+		require.NoError(t, os.RemoveAll(entryRoot))
+	}()
+
+	c, err := cache.NewCache(
+		func(w io.Writer) cache.Encoder { return structure.NewEncoder(w) },
+		func(r io.Reader) cache.Decoder { return structure.NewDecoder(r) },
+		false,
+		t.TempDir(),
+	)
+	require.NoError(t, err)
+
+	newTree := func() *structure.Tree {
+		return structure.NewTree(
+			structure.NewDirEntry(entryRoot, 0),
+			structure.WithCache(c),
+			structure.WithUseCache(),
+		)
+	}
+
+	tree := newTree()
+	require.NoError(t, tree.Traverse(true))
+	tree.CalculateSize()
+	require.NoError(t, <-tree.PersistCache())
+
+	// the not loaded entries must keep their cached totals.
+	tree = newTree()
+	require.NoError(t, tree.Traverse(false))
+	tree.CalculateSize()
+
+	require.Equal(t, uint64(21), tree.Root().TotalFiles)
+	require.Equal(t, uint64(9), tree.Root().TotalDirs)
+
+	verifyEntryStructure(t, tree.Root(), &testEntryInstance)
+
+	snapshots, err := tree.Snapshots()
+	require.NoError(t, err)
+	require.Len(t, snapshots, 1)
+
+	// corrupt the root's block, which precedes the footer.
+	encoded, err := os.ReadFile(snapshots[0].Path)
+	require.NoError(t, err)
+
+	encoded[len(encoded)-footerLen-1]++
+	require.NoError(t, os.WriteFile(snapshots[0].Path, encoded, 0600))
+
+	tree = newTree()
+	require.NoError(t, tree.Traverse(false))
+	require.NoError(t, tree.LoadErr())
+	require.Empty(t, slices.Collect(tree.Root().Entries()))
+	require.ErrorIs(t, tree.LoadErr(), structure.ErrCorruptedData)
+
+	// the incomplete tree must not overwrite the cache.
+	tree.MarkDirty()
+	require.ErrorIs(t, <-tree.PersistCache(), structure.ErrCorruptedData)
+
+	// the rescanned root discards the entries that failed to load.
+	done, errChan := tree.TraverseNodeAsync(tree.Root())
+
+	for err = range errChan {
+		require.NoError(t, err)
+	}
+
+	<-done
+
+	tree.CalculateSize()
+
+	require.NoError(t, tree.LoadErr())
+	verifyEntryStructure(t, tree.Root(), &testEntryInstance)
+	require.NoError(t, <-tree.PersistCache())
+}
+
+func TestTree_CloseCache(t *testing.T) {
+	root, err := filepath.Abs(".")
+	require.NoError(t, err)
+
+	entryRoot := initTmpEntry(t, &testEntryInstance, root)
+
+	defer func() {
+		require.NoError(t, os.RemoveAll(entryRoot))
+	}()
+
+	c, err := cache.NewCache(
+		func(w io.Writer) cache.Encoder { return structure.NewEncoder(w) },
+		func(r io.Reader) cache.Decoder { return structure.NewDecoder(r) },
+		false,
+		t.TempDir(),
+		cache.WithRetention(1, 0),
+	)
+	require.NoError(t, err)
+
+	newTree := func() *structure.Tree {
+		return structure.NewTree(
+			structure.NewDirEntry(entryRoot, 0),
+			structure.WithCache(c),
+			structure.WithUseCache(),
+		)
+	}
+
+	tree := newTree()
+	require.NoError(t, tree.Traverse(true))
+	tree.CalculateSize()
+	require.NoError(t, <-tree.PersistCache())
+
+	// the snapshot backing the lazily loaded tree is kept until it's closed.
+	tree = newTree()
+	require.NoError(t, tree.Traverse(false))
+
+	tree.MarkDirty()
+	require.NoError(t, <-tree.PersistCache())
+
+	snapshots, err := tree.Snapshots()
+	require.NoError(t, err)
+	require.Len(t, snapshots, 2)
+	require.ErrorIs(t, c.Remove(snapshots[1]), cache.ErrInUse)
+
+	// the closed tree cannot load the remaining entries, so the next snapshot
+	// is persisted from a new scan.
+	require.NoError(t, tree.Close())
+
+	tree = structure.NewTree(structure.NewDirEntry(entryRoot, 0), structure.WithCache(c))
+	require.NoError(t, tree.Traverse(true))
+	tree.CalculateSize()
+	require.NoError(t, <-tree.PersistCache())
+
+	snapshots, err = tree.Snapshots()
+	require.NoError(t, err)
+	require.Len(t, snapshots, 1)
+
+	// the restored snapshot tree keeps the snapshot open until it's closed.
+	cachedTree, err := tree.CachedSnapshot(snapshots[0])
+	require.NoError(t, err)
+	require.ErrorIs(t, c.Remove(snapshots[0]), cache.ErrInUse)
+	require.NoError(t, cachedTree.Close())
+
+	// the snapshot of another root is closed right away.
+	_, err = structure.NewTree(
+		structure.NewDirEntry(filepath.Dir(entryRoot), 0), structure.WithCache(c),
+	).CachedSnapshot(snapshots[0])
+	require.ErrorIs(t, err, structure.ErrIncompatibleFormat)
+
+	require.NoError(t, c.Remove(snapshots[0]))
+}
+
+func TestTree_PersistCache(t *testing.T) {
+	root, err := filepath.Abs(".")
+	require.NoError(t, err)
+
+	entryRoot := initTmpEntry(t, &testEntryInstance, root)
+
+	defer func() {
+		require.NoError(t, os.RemoveAll(entryRoot))
+	}()
+
+	appPath := t.TempDir()
+	cachePath := filepath.Join(appPath, "cache")
+
+	c, err := cache.NewCache(
+		func(w io.Writer) cache.Encoder { return structure.NewEncoder(w) },
+		func(r io.Reader) cache.Decoder { return structure.NewDecoder(r) },
+		false,
+		appPath,
+	)
+	require.NoError(t, err)
+
+	newTree := func() *structure.Tree {
+		return structure.NewTree(
+			structure.NewDirEntry(entryRoot, 0),
+			structure.WithCache(c),
+			structure.WithUseCache(),
+		)
+	}
+
+	tree := newTree()
+	require.NoError(t, tree.Traverse(true))
+	tree.CalculateSize()
+
+	// the failed write must keep the tree dirty.
+	require.NoError(t, os.RemoveAll(cachePath))
+	require.NoError(t, os.WriteFile(cachePath, nil, 0600))
+	require.Error(t, <-tree.PersistCache())
+
+	require.NoError(t, os.Remove(cachePath))
+	require.NoError(t, os.Mkdir(cachePath, 0750))
+
+	// the tree can be modified while the snapshot is being written.
+	removed := tree.Find(filepath.Join(entryRoot, "level_1_3"))
+	require.NotNil(t, removed)
+
+	result := tree.PersistCache()
+
+	require.True(t, tree.Root().RemoveChild(removed))
+	tree.CalculateSize()
+
+	require.NoError(t, <-result)
+
+	// the persisted tree must not be written again.
+	_, ok := <-tree.PersistCache()
+	require.False(t, ok)
+
+	tree = newTree()
+	require.NoError(t, tree.Traverse(false))
+	require.NotNil(t, tree.Find(removed.Path))
+	verifyEntryStructure(t, tree.Root(), &testEntryInstance)
+}
+
 func TestEntry_AddChild(t *testing.T) {
 	e := structure.NewDirEntry("root", 0)
 	tree := structure.NewTree(e)
@@ -332,6 +666,127 @@ func TestEntry_Diff(t *testing.T) {
 	for _, removed := range diff.Removed {
 		require.True(t, slices.Contains(expectedRemoved, removed.Name()), removed.Name())
 	}
+
+	// the child entries of an emptied directory are not reported, while the
+	// compared directories report them as removed.
+	emptyState := &structure.Entry{
+		Path:  "root",
+		Child: []*structure.Entry{{Path: "level1", IsDir: true}},
+		IsDir: true,
+	}
+
+	diff = currentState.Diff(emptyState)
+
+	require.Empty(t, diff.Added)
+	require.Len(t, diff.Removed, 2)
+
+	diff = currentState.Compare(emptyState)
+
+	require.Empty(t, diff.Added)
+	require.Len(t, diff.Removed, 5)
+}
+
+func TestEntry_DiffChanged(t *testing.T) {
+	oldState := &structure.Entry{
+		Path: "root",
+		Child: []*structure.Entry{
+			{Path: "root_file_1", Size: 100},
+			{Path: "root_file_2", Size: 200},
+			{
+				Path: "level1",
+				Size: 1 << 30,
+				Child: []*structure.Entry{
+					{Path: "level1_file_1", Size: 1 << 30},
+				},
+				IsDir: true,
+			},
+		},
+		IsDir: true,
+	}
+
+	newState := &structure.Entry{
+		Path: "root",
+		Child: []*structure.Entry{
+			{Path: "root_file_1", Size: 100},
+			{Path: "root_file_2", Size: 50},
+			{
+				Path: "level1",
+				Size: 50 << 30,
+				Child: []*structure.Entry{
+					{Path: "level1_file_1", Size: 50 << 30},
+				},
+				IsDir: true,
+			},
+		},
+		IsDir: true,
+	}
+
+	diff := oldState.Diff(newState)
+
+	require.Empty(t, diff.Added)
+	require.Empty(t, diff.Removed)
+	require.Len(t, diff.Changed, 3)
+	require.False(t, diff.Empty())
+
+	// the changes must be sorted by the absolute delta value.
+	require.Equal(t, "level1", diff.Changed[0].New.Path)
+	require.Equal(t, int64(49<<30), diff.Changed[0].Delta())
+	require.Equal(t, "level1_file_1", diff.Changed[1].New.Path)
+	require.Equal(t, "root_file_2", diff.Changed[2].New.Path)
+	require.Equal(t, int64(-150), diff.Changed[2].Delta())
+
+	grown, shrunk := structure.ChangeStats(diff.Changed)
+
+	require.Equal(t, int64(49<<30), grown)
+	require.Equal(t, int64(150), shrunk)
+}
+
+func TestEntry_Compare(t *testing.T) {
+	left := &structure.Entry{
+		Path: "backup",
+		Child: []*structure.Entry{
+			{Path: filepath.Join("backup", "same"), Size: 100},
+			{Path: filepath.Join("backup", "changed"), Size: 100},
+			{Path: filepath.Join("backup", "left_only"), Size: 10},
+			{
+				Path:  filepath.Join("backup", "empty"),
+				IsDir: true,
+				Child: []*structure.Entry{
+					{Path: filepath.Join("backup", "empty", "left_only_nested"), Size: 5},
+				},
+			},
+		},
+		IsDir: true,
+	}
+
+	right := &structure.Entry{
+		Path: "source",
+		Child: []*structure.Entry{
+			{Path: filepath.Join("source", "same"), Size: 100},
+			{Path: filepath.Join("source", "changed"), Size: 300},
+			{Path: filepath.Join("source", "right_only"), Size: 20},
+			{Path: filepath.Join("source", "empty"), IsDir: true},
+		},
+		IsDir: true,
+	}
+
+	// the entries are matched by the relative paths, so the different roots
+	// are comparable.
+	diff := left.Compare(right)
+
+	require.Len(t, diff.Added, 1)
+	require.Equal(t, "right_only", diff.Added[0].Name())
+
+	require.Len(t, diff.Removed, 2)
+	require.Equal(t, "left_only", diff.Removed[0].Name())
+	require.Equal(t, "left_only_nested", diff.Removed[1].Name())
+
+	require.Len(t, diff.Changed, 1)
+	require.Equal(t, filepath.Join("backup", "changed"), diff.Changed[0].Old.Path)
+	require.Equal(t, filepath.Join("source", "changed"), diff.Changed[0].New.Path)
+
+	// the full paths are different, so nothing is matched by the plain diff.
+	require.Empty(t, left.Diff(right).Changed)
 }
 
 func verifyEntryStructure(t *testing.T, e *structure.Entry, te *testEntry) {
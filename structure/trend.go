@@ -0,0 +1,152 @@
+package structure
+
+import (
+	"cmp"
+	"slices"
+	"time"
+)
+
+// TrendSample represents a single state of the tree captured at a specific
+// time, e.g., a cache snapshot or the current scan result.
+type TrendSample struct {
+	Time time.Time
+	Root *Entry
+}
+
+// TrendPoint defines the directory size at a specific point in time.
+type TrendPoint struct {
+	Time time.Time
+	Size int64
+}
+
+// Trend contains the size time series of a single directory. The points are
+// sorted by time, starting from the oldest one. If the directory did not exist
+// at the time of the sample, the corresponding point will have a zero size.
+type Trend struct {
+	Path   string
+	Points []TrendPoint
+}
+
+// Growth returns the size difference between the last and the first points of
+// the time series. The negative value means that the directory has shrunk.
+func (t Trend) Growth() int64 {
+	if len(t.Points) < 2 {
+		return 0
+	}
+
+	return t.Points[len(t.Points)-1].Size - t.Points[0].Size
+}
+
+// Rate returns the average growth rate in bytes per day between the first and
+// the last points of the time series.
+func (t Trend) Rate() float64 {
+	if len(t.Points) < 2 {
+		return 0
+	}
+
+	days := t.Points[len(t.Points)-1].Time.Sub(t.Points[0].Time).Hours() / 24
+	if days <= 0 {
+		return 0
+	}
+
+	return float64(t.Growth()) / days
+}
+
+// Sizes returns the size values of the time series in the same order as the
+// points.
+func (t Trend) Sizes() []int64 {
+	sizes := make([]int64, 0, len(t.Points))
+
+	for _, p := range t.Points {
+		sizes = append(sizes, p.Size)
+	}
+
+	return sizes
+}
+
+// Since returns a copy of the trend limited to the points captured at or after
+// the provided time. The zero time value keeps all points.
+func (t Trend) Since(from time.Time) Trend {
+	idx := slices.IndexFunc(t.Points, func(p TrendPoint) bool {
+		return !p.Time.Before(from)
+	})
+
+	if idx == -1 {
+		return Trend{Path: t.Path}
+	}
+
+	return Trend{Path: t.Path, Points: t.Points[idx:]}
+}
+
+// Trends builds the size time series for the directory with the provided path
+// and all its subdirectories up to the specified depth, using the provided
+// samples. The samples must be sorted by time, starting from the oldest one.
+// The directory's own trend is always the first element of the result, and
+// the rest are sorted by path.
+//
+// Only the directories within the depth limit are loaded, so the lazily
+// decoded cache trees are not restored entirely.
+func Trends(path string, samples []TrendSample, depth int) []Trend {
+	series := make(map[string][]TrendPoint)
+
+	for i, s := range samples {
+		if s.Root == nil {
+			continue
+		}
+
+		entry := s.Root.FindChild(path)
+		if entry == nil || !entry.IsDir {
+			continue
+		}
+
+		collectTrendPoints(entry, i, len(samples), depth, series)
+	}
+
+	trends := make([]Trend, 0, len(series))
+
+	for p, points := range series {
+		for i := range points {
+			points[i].Time = samples[i].Time
+		}
+
+		trends = append(trends, Trend{Path: p, Points: points})
+	}
+
+	slices.SortFunc(trends, func(a, b Trend) int {
+		switch {
+		case a.Path == path:
+			return -1
+		case b.Path == path:
+			return 1
+		}
+
+		return cmp.Compare(a.Path, b.Path)
+	})
+
+	return trends
+}
+
+// collectTrendPoints records the sizes of the entry and its subdirectories for
+// the sample with the provided index. The missing points are filled with zero
+// sizes by default.
+func collectTrendPoints(
+	e *Entry,
+	idx, total, depth int,
+	series map[string][]TrendPoint,
+) {
+	points, ok := series[e.Path]
+	if !ok {
+		points = make([]TrendPoint, total)
+		series[e.Path] = points
+	}
+
+	points[idx].Size = e.Size
+
+	if depth <= 0 {
+		return
+	}
+
+	for child := range e.EntriesByType(true) {
+		collectTrendPoints(child, idx, total, depth-1, series)
+	}
+}
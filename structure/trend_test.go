@@ -0,0 +1,67 @@
+package structure_test
+
+import (
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/crumbyte/noxdir/structure"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestTrends(t *testing.T) {
+	now := time.Now()
+
+	newRoot := func(sizes map[string]int64) *structure.Entry {
+		root := structure.NewDirEntry("root", 0)
+
+		for _, name := range []string{"a", "b"} {
+			size, ok := sizes[name]
+			if !ok {
+				continue
+			}
+
+			child := structure.NewDirEntry(filepath.Join("root", name), 0)
+			child.Size = size
+
+			nested := structure.NewDirEntry(filepath.Join("root", name, "nested"), 0)
+			nested.Size = size
+
+			child.AddChild(nested)
+			root.AddChild(child)
+			root.Size += size
+		}
+
+		return root
+	}
+
+	samples := []structure.TrendSample{
+		{Time: now.Add(-time.Hour * 48), Root: newRoot(map[string]int64{"a": 100})},
+		{Time: now.Add(-time.Hour * 24), Root: newRoot(map[string]int64{"a": 150, "b": 10})},
+		{Time: now, Root: newRoot(map[string]int64{"a": 300, "b": 5})},
+	}
+
+	trends := structure.Trends("root", samples, 1)
+	require.Len(t, trends, 3)
+
+	require.Equal(t, "root", trends[0].Path)
+	require.Equal(t, []int64{100, 160, 305}, trends[0].Sizes())
+	require.Equal(t, int64(205), trends[0].Growth())
+	require.InDelta(t, 102.5, trends[0].Rate(), 0.001)
+
+	require.Equal(t, filepath.Join("root", "a"), trends[1].Path)
+	require.Equal(t, []int64{100, 150, 300}, trends[1].Sizes())
+
+	require.Equal(t, filepath.Join("root", "b"), trends[2].Path)
+	require.Equal(t, []int64{0, 10, 5}, trends[2].Sizes())
+	require.Equal(t, now.Add(-time.Hour*48), trends[2].Points[0].Time)
+
+	windowed := trends[2].Since(now.Add(-time.Hour * 25))
+	require.Equal(t, []int64{10, 5}, windowed.Sizes())
+	require.Equal(t, int64(-5), windowed.Growth())
+
+	require.Empty(t, trends[2].Since(now.Add(time.Hour)).Points)
+	require.Len(t, structure.Trends("root", samples, 2), 5)
+	require.Empty(t, structure.Trends(filepath.Join("root", "c"), samples, 1))
+}